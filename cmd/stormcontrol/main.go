@@ -3,13 +3,16 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/mythvcode/storm-control/internal/admin"
 	"github.com/mythvcode/storm-control/internal/config"
 	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
 	"github.com/mythvcode/storm-control/internal/exporter"
 	"github.com/mythvcode/storm-control/internal/logger"
 	"github.com/mythvcode/storm-control/internal/watcher"
@@ -21,11 +24,39 @@ func init() {
 	flag.StringVar(&cfgPath, "config", "", "Path to config file")
 }
 
+// reload reads config and applies it to running components, invalid config is rejected
+func reload(netWatcher *watcher.Watcher) error {
+	log := logger.GetLogger()
+	cfg, err := config.ReadConfig(cfgPath)
+	if err != nil {
+		log.Errorf("Error reload config, current config is kept: %s", err.Error())
+
+		return err
+	}
+	logLevel, err := logger.ParseLevel(cfg.Logger.Level)
+	if err != nil {
+		log.Errorf("Error reload config, current config is kept: invalid log level: %s", err.Error())
+
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	if err := netWatcher.Reload(cfg); err != nil {
+		log.Errorf("Error reload config, current config is kept: %s", err.Error())
+
+		return err
+	}
+	logger.SetLevel(logLevel)
+	log.Infof("Config reloaded")
+
+	return nil
+}
+
 func main() {
 	flag.Parse()
 	cfg, err := config.ReadConfig(cfgPath)
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	reloadSigs := make(chan os.Signal, 1)
+	signal.Notify(reloadSigs, syscall.SIGHUP)
 
 	if err != nil {
 		if cfgPath != "" {
@@ -35,27 +66,45 @@ func main() {
 		}
 		os.Exit(1)
 	}
-	if err := logger.Init(cfg.Logger.File, cfg.Logger.Level); err != nil {
+	if err := logger.Init(cfg.Logger); err != nil {
 		logger.Default().Errorf("Error init logger: %s", err.Error())
 		os.Exit(1)
 	}
-	eBPFProg, err := ebpfloader.New()
+	pinPath := ""
+	if cfg.EBPF.Pin {
+		pinPath = cfg.EBPF.PinPath
+	}
+	eBPFProg, err := ebpfloader.New(pinPath)
 	if err != nil {
 		logger.GetLogger().Errorf("Error load eBPF program %s", err.Error())
 		os.Exit(1)
 	}
-	netWatcher, err := watcher.New(cfg, eBPFProg)
+	var sinks []events.Sink
+	if cfg.Events.Webhook.Enable {
+		webhook, err := events.NewWebhook(cfg.Events.Webhook)
+		if err != nil {
+			logger.GetLogger().Errorf("Error create webhook sink: %s", err.Error())
+			os.Exit(1)
+		}
+		sinks = append(sinks, webhook)
+	}
+	eventBus := events.NewBus(sinks...)
+	eventBus.Start()
+	defer eventBus.Stop()
+
+	netWatcher, err := watcher.New(cfg, eBPFProg, eventBus)
 	if err != nil {
 		logger.GetLogger().Errorf("Error create watcher: %s", err.Error())
 		os.Exit(1)
 	}
 
 	if cfg.Exporter.Enable {
-		exporter, err := exporter.New(cfg.Exporter, eBPFProg)
+		exporter, err := exporter.New(cfg.Exporter, eBPFProg, netWatcher)
 		if err != nil {
 			logger.GetLogger().Errorf("Error start exporter: %s", err.Error())
 			os.Exit(1)
 		}
+		eventBus.Subscribe(exporter.EventSink())
 		started := make(chan error)
 		go func() {
 			started <- exporter.Start()
@@ -70,10 +119,31 @@ func main() {
 		defer exporter.Stop()
 	}
 
+	if cfg.Admin.Enable {
+		adminServer := admin.New(cfg.Admin, eBPFProg, netWatcher, func() error {
+			return reload(netWatcher)
+		})
+		go func() {
+			if err := adminServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.GetLogger().Errorf("Error start admin API server: %s", err.Error())
+				os.Exit(1)
+			}
+		}()
+
+		defer adminServer.Stop()
+	}
+
 	defer netWatcher.Stop()
 
 	go func() {
 		netWatcher.Start()
 	}()
-	<-sigs
+	for {
+		select {
+		case <-sigs:
+			return
+		case <-reloadSigs:
+			reload(netWatcher) //nolint:errcheck
+		}
+	}
 }
@@ -4,6 +4,11 @@
 #include <bpf/bpf_helpers.h>
 #include <bpf/bpf_endian.h>
 #include <linux/bpf.h>
+#include <linux/in.h>
+#include <linux/ip.h>
+#include <linux/ipv6.h>
+#include <linux/udp.h>
+#include <linux/icmpv6.h>
 #include "xdp_kernel.h"
 
 struct {
@@ -21,6 +26,85 @@ struct {
     __uint(max_entries, CONFIG_MAP_MAX_ELEMENT);
 } drop_intf SEC(".maps");
 
+
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, __u32);
+    __type(value, token_buckets);
+    __uint(max_entries, CONFIG_MAP_MAX_ELEMENT);
+} intf_buckets SEC(".maps");
+
+
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, mac_key);
+    __type(value, __u8);
+    __uint(max_entries, KNOWN_MACS_MAX_ELEMENT);
+} known_macs SEC(".maps");
+
+
+// bridge index by interface index, entry enables unicast check of interface
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, __u32);
+    __type(value, __u32);
+    __uint(max_entries, CONFIG_MAP_MAX_ELEMENT);
+} unicast_intf SEC(".maps");
+
+
+// interfaces with stats and drop config by VLAN, value is not used
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, __u32);
+    __type(value, __u8);
+    __uint(max_entries, CONFIG_MAP_MAX_ELEMENT);
+} vlan_intf SEC(".maps");
+
+
+// entries are created by program on first frame of VLAN
+struct {
+    __uint(type, BPF_MAP_TYPE_PERCPU_HASH);
+    __type(key, vlan_key);
+    __type(value, packet_counter);
+    __uint(max_entries, VLAN_MAP_MAX_ELEMENT);
+} vlan_stats SEC(".maps");
+
+
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, vlan_key);
+    __type(value, drop_pkt);
+    __uint(max_entries, VLAN_MAP_MAX_ELEMENT);
+} vlan_drop SEC(".maps");
+
+
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, vlan_key);
+    __type(value, token_buckets);
+    __uint(max_entries, VLAN_MAP_MAX_ELEMENT);
+} vlan_buckets SEC(".maps");
+
+// interfaces with counters by source address, value is not used
+struct {
+    __uint(type, BPF_MAP_TYPE_HASH);
+    __type(key, __u32);
+    __type(value, __u8);
+    __uint(max_entries, CONFIG_MAP_MAX_ELEMENT);
+} talker_intf SEC(".maps");
+
+
+// key is mac_key with source address, entries are created by program
+struct {
+    __uint(type, BPF_MAP_TYPE_LRU_PERCPU_HASH);
+    __type(key, mac_key);
+    __type(value, talker_counter);
+    __uint(max_entries, TALKERS_MAP_MAX_ELEMENT);
+} src_talkers SEC(".maps");
+
+// initial value of VLAN counters, packet_counter is too big for stack
+const packet_counter ZERO_COUNTER = {};
+
 static __always_inline int proto_is_vlan(__u16 h_proto) {
     return !!(h_proto == bpf_htons(ETH_P_8021Q) ||
               h_proto == bpf_htons(ETH_P_8021AD));
@@ -28,10 +112,13 @@ static __always_inline int proto_is_vlan(__u16 h_proto) {
 
 // used to find h_proto value in case of vlan tags
 // support maximum of two vlans(q-in-q)
-// return value in big endian
-static __always_inline __be16 get_h_proto(struct ethhdr *eth, void *data_end) {
+// return value in big endian, l3 is set to header after vlan tags,
+// vlan_id is set to id of outer tag or 0 for untagged frame
+static __always_inline __be16 get_h_proto(struct ethhdr *eth, void *data_end, void **l3, __u16 *vlan_id) {
     __u16 h_proto = eth->h_proto;
     struct vlan_hdr *vlan;
+    *l3 = eth + 1;
+    *vlan_id = 0;
     if (!proto_is_vlan(h_proto)){
         return h_proto;
     }
@@ -39,7 +126,9 @@ static __always_inline __be16 get_h_proto(struct ethhdr *eth, void *data_end) {
         return h_proto;
     }
     vlan = (void*)(eth + 1);
+    *vlan_id = bpf_ntohs(vlan->h_vlan_TCI) & VLAN_VID_MASK;
     h_proto = vlan->h_vlan_encapsulated_proto;
+    *l3 = vlan + 1;
     if (!proto_is_vlan(vlan->h_vlan_encapsulated_proto)){
         return h_proto;
     }
@@ -49,6 +138,7 @@ static __always_inline __be16 get_h_proto(struct ethhdr *eth, void *data_end) {
     }
     // second vlan header(q-in-q)
     vlan = vlan + 1;
+    *l3 = vlan + 1;
     return vlan->h_vlan_encapsulated_proto;
 }
 
@@ -60,60 +150,385 @@ static __always_inline int is_ipv6_multicast_proto(__be16 h_proto) {
     return bpf_ntohs(h_proto) == ETH_P_IPV6;
 }
 
-static __always_inline void increment_pass_stat(packet_counter *count_s, p_type pt) {
-    traffic_desc *desc[] = { &count_s->broadcast, &count_s->ipv4_mcast, 
-                             &count_s->ipv6_mcast, &count_s->other_mcast };
+// sub-class of broadcast packet, ARP by ethertype and DHCPv4 by UDP destination port
+static __always_inline p_type get_broadcast_type(__be16 h_proto, void *l3, void *data_end) {
+    if (bpf_ntohs(h_proto) == ETH_P_ARP){
+        return ARP;
+    }
+    if (bpf_ntohs(h_proto) != ETH_P_IP){
+        return Broadcast;
+    }
+    struct iphdr *iph = l3;
+    if ((void *)(iph + 1) > data_end || iph->ihl < 5 || iph->protocol != IPPROTO_UDP){
+        return Broadcast;
+    }
+    struct udphdr *udph = (void *)iph + iph->ihl * 4;
+    if ((void *)(udph + 1) > data_end){
+        return Broadcast;
+    }
+    if (bpf_ntohs(udph->dest) == DHCP_SERVER_PORT || bpf_ntohs(udph->dest) == DHCP_CLIENT_PORT){
+        return DHCPv4;
+    }
+
+    return Broadcast;
+}
+
+// sub-class of IPv6 multicast packet by ICMPv6 type,
+// MLD messages are sent with hop-by-hop router alert option which is skipped
+static __always_inline p_type get_ipv6_mcast_type(void *l3, void *data_end) {
+    struct ipv6hdr *ip6h = l3;
+    if ((void *)(ip6h + 1) > data_end){
+        return IPv6MCast;
+    }
+    __u8 nexthdr = ip6h->nexthdr;
+    void *l4 = ip6h + 1;
+    if (nexthdr == IPPROTO_HOPOPTS){
+        struct ipv6_opt_hdr *opth = l4;
+        if ((void *)(opth + 1) > data_end){
+            return IPv6MCast;
+        }
+        nexthdr = opth->nexthdr;
+        l4 += (opth->hdrlen + 1) * 8;
+    }
+    if (nexthdr != IPPROTO_ICMPV6){
+        return IPv6MCast;
+    }
+    struct icmp6hdr *icmp6h = l4;
+    if ((void *)(icmp6h + 1) > data_end){
+        return IPv6MCast;
+    }
+    switch (icmp6h->icmp6_type){
+    case ICMPV6_MLD_QUERY:
+    case ICMPV6_MLD_REPORT:
+    case ICMPV6_MLD_DONE:
+    case ICMPV6_MLD2_REPORT:
+        return MLD;
+    case ICMPV6_ROUTER_ADVERT:
+        return IPv6RA;
+    case ICMPV6_ROUTER_SOLICIT:
+    case ICMPV6_NEIGH_SOLICIT:
+    case ICMPV6_NEIGH_ADVERT:
+    case ICMPV6_REDIRECT:
+        return IPv6ND;
+    }
+
+    return IPv6MCast;
+}
+
+// fields of traffic type are selected by switch, arrays of field pointers would be built on stack for every frame
+static __always_inline traffic_desc *get_traffic_desc(packet_counter *count_s, p_type pt) {
+    switch (pt){
+    case Broadcast:
+        return &count_s->broadcast;
+    case IPv4MCast:
+        return &count_s->ipv4_mcast;
+    case IPv6MCast:
+        return &count_s->ipv6_mcast;
+    case GenericMCast:
+        return &count_s->other_mcast;
+    case ARP:
+        return &count_s->arp;
+    case DHCPv4:
+        return &count_s->dhcpv4;
+    case IPv6ND:
+        return &count_s->ipv6_nd;
+    case MLD:
+        return &count_s->mld;
+    case IPv6RA:
+        return &count_s->ipv6_ra;
+    case UnknownUnicast:
+        return &count_s->unknown_ucast;
+    }
+
+    return 0;
+}
+
+static __always_inline token_bucket *get_token_bucket(token_buckets *buckets, p_type pt) {
+    switch (pt){
+    case Broadcast:
+        return &buckets->broadcast;
+    case IPv4MCast:
+        return &buckets->ipv4_mcast;
+    case IPv6MCast:
+        return &buckets->ipv6_mcast;
+    case GenericMCast:
+        return &buckets->other_mcast;
+    case ARP:
+        return &buckets->arp;
+    case DHCPv4:
+        return &buckets->dhcpv4;
+    case IPv6ND:
+        return &buckets->ipv6_nd;
+    case MLD:
+        return &buckets->mld;
+    case IPv6RA:
+        return &buckets->ipv6_ra;
+    case UnknownUnicast:
+        return &buckets->unknown_ucast;
+    }
+
+    return 0;
+}
+
+static __always_inline rate_limit *get_rate_limit(drop_pkt *drop_desc, p_type pt) {
+    switch (pt){
+    case Broadcast:
+        return &drop_desc->broadcast_rate;
+    case IPv4MCast:
+        return &drop_desc->ipv4_mcast_rate;
+    case IPv6MCast:
+        return &drop_desc->ipv6_mcast_rate;
+    case GenericMCast:
+        return &drop_desc->other_mcast_rate;
+    case ARP:
+        return &drop_desc->arp_rate;
+    case DHCPv4:
+        return &drop_desc->dhcpv4_rate;
+    case IPv6ND:
+        return &drop_desc->ipv6_nd_rate;
+    case MLD:
+        return &drop_desc->mld_rate;
+    case IPv6RA:
+        return &drop_desc->ipv6_ra_rate;
+    case UnknownUnicast:
+        return &drop_desc->unknown_ucast_rate;
+    }
+
+    return 0;
+}
+
+static __always_inline __u8 get_drop_action(drop_pkt *drop_desc, p_type pt) {
+    switch (pt){
+    case Broadcast:
+        return drop_desc->broadcast;
+    case IPv4MCast:
+        return drop_desc->ipv4_mcast;
+    case IPv6MCast:
+        return drop_desc->ipv6_mcast;
+    case GenericMCast:
+        return drop_desc->other_mcast;
+    case ARP:
+        return drop_desc->arp;
+    case DHCPv4:
+        return drop_desc->dhcpv4;
+    case IPv6ND:
+        return drop_desc->ipv6_nd;
+    case MLD:
+        return drop_desc->mld;
+    case IPv6RA:
+        return drop_desc->ipv6_ra;
+    case UnknownUnicast:
+        return drop_desc->unknown_ucast;
+    }
+
+    return ActionPass;
+}
 
-    if (pt >= Broadcast && pt <= GenericMCast) {
-        desc[pt]->passed++;
+static __always_inline void increment_pass_stat(packet_counter *count_s, p_type pt, __u64 pkt_len) {
+    traffic_desc *desc = get_traffic_desc(count_s, pt);
+    if (desc){
+        desc->passed++;
+        desc->passed_bytes += pkt_len;
     }
 }
 
+static __always_inline void increment_drop_stat(packet_counter *count_s, p_type pt, __u64 pkt_len) {
+    traffic_desc *desc = get_traffic_desc(count_s, pt);
+    if (desc){
+        desc->dropped++;
+        desc->dropped_bytes += pkt_len;
+    }
+}
+
+// refill bucket according to elapsed time and take one packet token
+// concurrent updates from different CPUs are not serialized, so limit is approximate
+static __always_inline int consume_token(token_bucket *bucket, rate_limit *limit) {
+    __u64 burst = limit->burst ? limit->burst : limit->rate;
+    __u64 capacity = burst * NSEC_PER_SEC;
+    __u64 now = bpf_ktime_get_ns();
+    __u64 last_refill = bucket->last_refill;
+
+    if (!limit->rate){
+        return 0;
+    }
+    if (now > last_refill){
+        __u64 elapsed = now - last_refill;
+        __s64 tokens = bucket->tokens;
+        if (tokens < 0){
+            tokens = 0;
+        }
+        if (elapsed >= capacity / limit->rate){
+            tokens = capacity;
+        } else {
+            tokens += elapsed * limit->rate;
+            if ((__u64)tokens > capacity){
+                tokens = capacity;
+            }
+        }
+        bucket->tokens = tokens;
+        bucket->last_refill = now;
+    }
+    if (bucket->tokens < (__s64)NSEC_PER_SEC){
+        return 0;
+    }
+    __sync_fetch_and_add(&bucket->tokens, -(__s64)NSEC_PER_SEC);
+
+    return 1;
+}
+
+static __always_inline int is_rate_exceeded(void *buckets_map, void *key, drop_pkt *drop_desc, p_type pt) {
+    token_buckets *buckets = bpf_map_lookup_elem(buckets_map, key);
+    if (!buckets){
+        return 0;
+    }
+    token_bucket *bucket = get_token_bucket(buckets, pt);
+    rate_limit *limit = get_rate_limit(drop_desc, pt);
+    if (!bucket || !limit){
+        return 0;
+    }
+
+    return !consume_token(bucket, limit);
+}
+
+// is_dropped checks drop config of interface or VLAN, buckets are looked up in buckets_map by key
+static __always_inline int is_dropped(void *buckets_map, void *key, drop_pkt *drop_desc, p_type pt) {
+    switch (get_drop_action(drop_desc, pt)){
+    case ActionDrop:
+        return 1;
+    case ActionRateLimit:
+        return is_rate_exceeded(buckets_map, key, drop_desc, pt);
+    }
 
-static __always_inline int get_xdp_action(__u32 ifindex, p_type pt){
+    return 0;
+}
+
+// get_vlan_stats returns counters of VLAN, entry is created on first frame of VLAN
+static __always_inline packet_counter *get_vlan_stats(vlan_key *key) {
+    packet_counter *count_s = bpf_map_lookup_elem(&vlan_stats, key);
+    if (count_s){
+        return count_s;
+    }
+    // entry can be created concurrently by other CPU, result of update is not checked
+    bpf_map_update_elem(&vlan_stats, key, &ZERO_COUNTER, BPF_NOEXIST);
+
+    // not found if map is full, frame is counted only by interface
+    return bpf_map_lookup_elem(&vlan_stats, key);
+}
+
+// frames of VLAN aware interface are counted by interface and by VLAN,
+// frame is dropped by drop config of interface or by drop config of VLAN
+static __always_inline int get_xdp_action(__u32 ifindex, __u16 vlan_id, p_type pt, __u64 pkt_len){
     drop_pkt *drop_desc = bpf_map_lookup_elem(&drop_intf, &ifindex);
     packet_counter *count_s = bpf_map_lookup_elem(&intf_stats, &ifindex);
+    packet_counter *vlan_s = 0;
+    vlan_key key = {};
     if (!count_s){
         return XDP_PASS;
     }
-    if (!drop_desc){
-        increment_pass_stat(count_s, pt);
-        return XDP_PASS;
+    if (bpf_map_lookup_elem(&vlan_intf, &ifindex)){
+        key.ifindex = ifindex;
+        key.vlan = vlan_id;
+        vlan_s = get_vlan_stats(&key);
     }
-    if (drop_desc->broadcast && pt == Broadcast){
-        count_s->broadcast.dropped++;
-        return XDP_DROP;
-    } else if (drop_desc->ipv4_mcast && pt == IPv4MCast){
-        count_s->ipv4_mcast.dropped++;
-        return XDP_DROP;
-    } else if (drop_desc->ipv6_mcast && pt == IPv6MCast){
-        count_s->ipv6_mcast.dropped++;
-        return XDP_DROP;
-    } else if (drop_desc->other_mcast && pt == GenericMCast){
-        count_s->other_mcast.dropped++;
+    // frames of interface without drop config are dropped only by drop config of VLAN
+    int dropped = 0;
+    if (drop_desc){
+        dropped = is_dropped(&intf_buckets, &ifindex, drop_desc, pt);
+    }
+    if (!dropped && vlan_s){
+        drop_pkt *vlan_drop_desc = bpf_map_lookup_elem(&vlan_drop, &key);
+        if (vlan_drop_desc){
+            dropped = is_dropped(&vlan_buckets, &key, vlan_drop_desc, pt);
+        }
+    }
+    if (dropped){
+        increment_drop_stat(count_s, pt, pkt_len);
+        if (vlan_s){
+            increment_drop_stat(vlan_s, pt, pkt_len);
+        }
         return XDP_DROP;
     }
-    increment_pass_stat(count_s, pt);
+    increment_pass_stat(count_s, pt, pkt_len);
+    if (vlan_s){
+        increment_pass_stat(vlan_s, pt, pkt_len);
+    }
 
     return XDP_PASS;
 }
 
+// count_talker counts broadcast and multicast frame by source address
+// for interfaces with enabled tracking, dropped frames are counted as well
+static __always_inline void count_talker(struct ethhdr *eth, __u32 ifindex, __u64 pkt_len) {
+    if (!bpf_map_lookup_elem(&talker_intf, &ifindex)){
+        return;
+    }
+    mac_key key = {};
+    key.ifindex = ifindex;
+    __builtin_memcpy(key.addr, eth->h_source, ETH_ALEN);
+    talker_counter *counter = bpf_map_lookup_elem(&src_talkers, &key);
+    if (!counter){
+        talker_counter init = { .packets = 1, .bytes = pkt_len };
+        bpf_map_update_elem(&src_talkers, &key, &init, BPF_NOEXIST);
+
+        return;
+    }
+    counter->packets++;
+    counter->bytes += pkt_len;
+}
+
+// unicast frame is unknown if source address is not expected on interface (spoofing)
+// or destination address is not known on bridge of interface, check is made only for interfaces with enabled check
+static __always_inline int is_unknown_unicast(struct ethhdr *eth, __u32 ifindex) {
+    __u32 *bridge = bpf_map_lookup_elem(&unicast_intf, &ifindex);
+    if (!bridge){
+        return 0;
+    }
+    mac_key key = {};
+    key.ifindex = ifindex;
+    key.kind = MacSource;
+    __builtin_memcpy(key.addr, eth->h_source, ETH_ALEN);
+    if (!bpf_map_lookup_elem(&known_macs, &key)){
+        return 1;
+    }
+    key.ifindex = *bridge;
+    key.kind = MacDestination;
+    __builtin_memcpy(key.addr, eth->h_dest, ETH_ALEN);
+
+    return !bpf_map_lookup_elem(&known_macs, &key);
+}
 
 // calculate packets and return xdp_action
 static __always_inline int calculate_pkt(struct ethhdr *eth, void *data_end, __u32 ifindex) {
+    __u64 pkt_len = data_end - (void *)eth;
+
+    void *l3;
+    __u16 vlan_id;
+
+    if (is_multicast(eth->h_dest)){
+        count_talker(eth, ifindex, pkt_len);
+    }
+
     if (is_broadcast(eth->h_dest)){
-        return get_xdp_action(ifindex, Broadcast);
+        __be16 h_proto = get_h_proto(eth, data_end, &l3, &vlan_id);
+
+        return get_xdp_action(ifindex, vlan_id, get_broadcast_type(h_proto, l3, data_end), pkt_len);
 
     } else if (is_multicast(eth->h_dest)){
-        __be16 h_proto = get_h_proto(eth, data_end);
+        __be16 h_proto = get_h_proto(eth, data_end, &l3, &vlan_id);
 
         if (is_ipv4_mcast(eth->h_dest) && is_ipv4_multicast_proto(h_proto))
-            return get_xdp_action(ifindex, IPv4MCast);
+            return get_xdp_action(ifindex, vlan_id, IPv4MCast, pkt_len);
 
         if (is_ipv6_mcast(eth->h_dest) && is_ipv6_multicast_proto(h_proto))
-            return get_xdp_action(ifindex, IPv6MCast);
+            return get_xdp_action(ifindex, vlan_id, get_ipv6_mcast_type(l3, data_end), pkt_len);
+
+        return get_xdp_action(ifindex, vlan_id, GenericMCast, pkt_len);
+    }
+
+    if (is_unknown_unicast(eth, ifindex)){
+        get_h_proto(eth, data_end, &l3, &vlan_id);
 
-        return get_xdp_action(ifindex, GenericMCast);
+        return get_xdp_action(ifindex, vlan_id, UnknownUnicast, pkt_len);
     }
 
     return XDP_PASS;
@@ -1,14 +1,45 @@
 #include <linux/if_ether.h>
 
 #define CONFIG_MAP_MAX_ELEMENT 10000
+// expected source addresses of all interfaces and known destination addresses of all bridges
+#define KNOWN_MACS_MAX_ELEMENT 65536
+// VLANs of all VLAN aware interfaces
+#define VLAN_MAP_MAX_ELEMENT 65536
+#define VLAN_VID_MASK 0x0fff
+// source addresses of interfaces with top talkers tracking, least recently seen are evicted
+#define TALKERS_MAP_MAX_ELEMENT 16384
+#define NSEC_PER_SEC 1000000000ULL
 
+// ARP and DHCPv4 are sub-classes of broadcast, IPv6 ND, MLD and RA are sub-classes of IPv6 multicast,
+// sub-class traffic is not counted in parent type.
+// Unicast is counted only as UnknownUnicast if unicast check is enabled for interface
 typedef enum {
     Broadcast,
     IPv4MCast,
     IPv6MCast,
-    GenericMCast
+    GenericMCast,
+    ARP,
+    DHCPv4,
+    IPv6ND,
+    MLD,
+    IPv6RA,
+    UnknownUnicast
 } p_type;
 
+#define DHCP_SERVER_PORT 67
+#define DHCP_CLIENT_PORT 68
+
+// ICMPv6 types
+#define ICMPV6_MLD_QUERY        130
+#define ICMPV6_MLD_REPORT       131
+#define ICMPV6_MLD_DONE         132
+#define ICMPV6_ROUTER_SOLICIT   133
+#define ICMPV6_ROUTER_ADVERT    134
+#define ICMPV6_NEIGH_SOLICIT    135
+#define ICMPV6_NEIGH_ADVERT     136
+#define ICMPV6_REDIRECT         137
+#define ICMPV6_MLD2_REPORT      143
+
 const unsigned char BROADCAST[ETH_ALEN] = {0xff, 0xff, 0xff, 0xff, 0xff, 0xff};
 const unsigned char IPV4_MAC_PREFIX[3]  = {0x01, 0x00, 0x5e};
 const unsigned char IPV6_MAC_PREFIX[2]  = {0x33, 0x33};
@@ -17,6 +48,8 @@ const unsigned char IPV6_MAC_PREFIX[2]  = {0x33, 0x33};
 typedef struct {
     __u64 passed;
     __u64 dropped;
+    __u64 passed_bytes;
+    __u64 dropped_bytes;
 } traffic_desc;
 
 typedef struct  {
@@ -24,15 +57,99 @@ typedef struct  {
     traffic_desc  ipv4_mcast;
     traffic_desc  ipv6_mcast;
     traffic_desc  other_mcast;
+    traffic_desc  arp;
+    traffic_desc  dhcpv4;
+    traffic_desc  ipv6_nd;
+    traffic_desc  mld;
+    traffic_desc  ipv6_ra;
+    traffic_desc  unknown_ucast;
 } packet_counter;
 
+// drop_pkt actions
+typedef enum {
+    ActionPass,
+    ActionDrop,
+    ActionRateLimit
+} drop_action;
+
+typedef struct {
+    __u64 rate;  // packets per second
+    __u64 burst; // bucket size in packets
+} rate_limit;
+
 typedef struct {
     __u8 broadcast;
     __u8 ipv4_mcast;
     __u8 ipv6_mcast;
     __u8 other_mcast;
+    __u8 arp;
+    __u8 dhcpv4;
+    __u8 ipv6_nd;
+    __u8 mld;
+    __u8 ipv6_ra;
+    __u8 unknown_ucast;
+    __u8 pad[6];
+    // used only with ActionRateLimit
+    rate_limit broadcast_rate;
+    rate_limit ipv4_mcast_rate;
+    rate_limit ipv6_mcast_rate;
+    rate_limit other_mcast_rate;
+    rate_limit arp_rate;
+    rate_limit dhcpv4_rate;
+    rate_limit ipv6_nd_rate;
+    rate_limit mld_rate;
+    rate_limit ipv6_ra_rate;
+    rate_limit unknown_ucast_rate;
 } drop_pkt;
 
+typedef struct {
+    // tokens are scaled by NSEC_PER_SEC, one packet costs NSEC_PER_SEC tokens
+    __s64 tokens;
+    __u64 last_refill;
+} token_bucket;
+
+typedef struct {
+    token_bucket broadcast;
+    token_bucket ipv4_mcast;
+    token_bucket ipv6_mcast;
+    token_bucket other_mcast;
+    token_bucket arp;
+    token_bucket dhcpv4;
+    token_bucket ipv6_nd;
+    token_bucket mld;
+    token_bucket ipv6_ra;
+    token_bucket unknown_ucast;
+} token_buckets;
+
+// kinds of known_macs entries
+typedef enum {
+    // expected source address of interface
+    MacSource,
+    // known destination address of bridge
+    MacDestination
+} mac_kind;
+
+// key of known_macs map, ifindex is index of interface for source address and index of bridge for destination address
+typedef struct {
+    __u32 ifindex;
+    __u8  addr[ETH_ALEN];
+    __u8  kind;
+    __u8  pad;
+} mac_key;
+
+// broadcast and multicast frames received from source address of interface
+typedef struct {
+    __u64 packets;
+    __u64 bytes;
+} talker_counter;
+
+// key of VLAN maps, vlan is id of outer tag, 0 for untagged frames
+typedef struct {
+    __u32 ifindex;
+    __u16 vlan;
+    __u16 pad;
+} vlan_key;
+
 
 struct vlan_hdr {
     __be16  h_vlan_TCI;
@@ -0,0 +1,290 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/watcher"
+)
+
+// NetDevInfo describes watched interface with kernel counters and drop state
+type NetDevInfo struct {
+	Index      int                      `json:"index"`
+	Name       string                   `json:"name"`
+	AttachMode string                   `json:"attach_mode"`
+	Counters   ebpfloader.PacketCounter `json:"counters"`
+	DropConfig ebpfloader.DropPKT       `json:"drop_config"`
+	// counters and drop state by VLAN, set only for VLAN aware interface
+	VLANs []VLANInfo `json:"vlans,omitempty"`
+	// watcher state, empty if info is loaded from pinned maps
+	State *watcher.NetDevState `json:"state,omitempty"`
+}
+
+// VLANInfo describes VLAN of VLAN aware interface, VLAN 0 is untagged traffic
+type VLANInfo struct {
+	VLAN       uint16                   `json:"vlan"`
+	Counters   ebpfloader.PacketCounter `json:"counters"`
+	DropConfig ebpfloader.DropPKT       `json:"drop_config"`
+}
+
+// Status describes running daemon
+type Status struct {
+	StartedAt         time.Time `json:"started_at"`
+	Interfaces        int       `json:"interfaces"`
+	BlockedInterfaces int       `json:"blocked_interfaces"`
+	// manually attached (true) and detached (false) interfaces
+	AttachOverrides map[string]bool `json:"attach_overrides"`
+}
+
+// BlockRequest is body of block and unblock requests.
+// Empty traffic type or "all" selects all types of traffic,
+// duration is Go duration string, e.g. "10m".
+type BlockRequest struct {
+	TrafficType string `json:"traffic_type"`
+	Duration    string `json:"duration"`
+}
+
+// ExemptRequest is body of exempt request, exempt false returns traffic to automatic control
+type ExemptRequest struct {
+	TrafficType string `json:"traffic_type"`
+	Exempt      bool   `json:"exempt"`
+}
+
+var errBadRequest = errors.New("bad request")
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.log.Errorf("Error write response: %s", err.Error())
+	}
+}
+
+func (s *Server) writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, watcher.ErrNetDevNotFound), errors.Is(err, watcher.ErrNetDevNotExist):
+		status = http.StatusNotFound
+	case errors.Is(err, watcher.ErrUnknownTrafficType), errors.Is(err, errBadRequest):
+		status = http.StatusBadRequest
+	case errors.Is(err, watcher.ErrDryRun):
+		status = http.StatusConflict
+	}
+	s.writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+func decodeBody(r *http.Request, body any) error {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		return fmt.Errorf("%w: %s", errBadRequest, err.Error())
+	}
+
+	return nil
+}
+
+func parseDuration(duration string) (time.Duration, error) {
+	if duration == "" {
+		return 0, nil
+	}
+	result, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errBadRequest, err.Error())
+	}
+	if result < 0 {
+		return 0, fmt.Errorf("%w: negative duration %s", errBadRequest, duration)
+	}
+
+	return result, nil
+}
+
+// netDevInfos returns info of watched interfaces sorted by index
+func (s *Server) netDevInfos() ([]NetDevInfo, error) {
+	stats, err := s.statsLoader.GetStatistic()
+	if err != nil {
+		return nil, err
+	}
+	states := s.controller.GetNetDevStates()
+	result := make([]NetDevInfo, 0, len(states))
+	for _, state := range states {
+		index := uint32(state.Index) //nolint:gosec
+		result = append(result, NetDevInfo{
+			Index:      state.Index,
+			Name:       state.Name,
+			AttachMode: stats.AttachModes[index],
+			Counters:   stats.CounterStat[index],
+			DropConfig: stats.DropConf[index],
+			VLANs:      VLANInfos(stats, index),
+			State:      &state,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+
+	return result, nil
+}
+
+// VLANInfos returns VLANs of interface sorted by VLAN id
+func VLANInfos(stats ebpfloader.Statistic, index uint32) []VLANInfo {
+	var result []VLANInfo
+	for key, counters := range stats.VLANCounterStat {
+		if key.Ifindex == index {
+			result = append(result, VLANInfo{VLAN: key.VLAN, Counters: counters, DropConfig: stats.VLANDropConf[key]})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].VLAN < result[j].VLAN })
+
+	return result
+}
+
+func (s *Server) netDevInfo(name string) (NetDevInfo, error) {
+	infos, err := s.netDevInfos()
+	if err != nil {
+		return NetDevInfo{}, err
+	}
+	for _, info := range infos {
+		if info.Name == name {
+			return info, nil
+		}
+	}
+
+	return NetDevInfo{}, fmt.Errorf("%w: %s", watcher.ErrNetDevNotFound, name)
+}
+
+func (s *Server) writeNetDevInfo(w http.ResponseWriter, name string) {
+	info, err := s.netDevInfo(name)
+	if err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	s.writeJSON(w, http.StatusOK, info)
+}
+
+func (s *Server) listInterfaces(w http.ResponseWriter, _ *http.Request) {
+	infos, err := s.netDevInfos()
+	if err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	s.writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) getInterface(w http.ResponseWriter, r *http.Request) {
+	s.writeNetDevInfo(w, r.PathValue("name"))
+}
+
+func (s *Server) block(w http.ResponseWriter, r *http.Request) {
+	s.handleBlock(w, r, s.controller.Block)
+}
+
+func (s *Server) unblock(w http.ResponseWriter, r *http.Request) {
+	s.handleBlock(w, r, s.controller.Unblock)
+}
+
+func (s *Server) handleBlock(w http.ResponseWriter, r *http.Request, action func(string, string, time.Duration) error) {
+	name := r.PathValue("name")
+	var req BlockRequest
+	if err := decodeBody(r, &req); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	duration, err := parseDuration(req.Duration)
+	if err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	if err := action(name, req.TrafficType, duration); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	s.writeNetDevInfo(w, name)
+}
+
+func (s *Server) exempt(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	var req ExemptRequest
+	if err := decodeBody(r, &req); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	if err := s.controller.Exempt(name, req.TrafficType, req.Exempt); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	s.writeNetDevInfo(w, name)
+}
+
+// IsBlocked checks that at least one type of traffic is dropped completely
+func IsBlocked(dropCfg ebpfloader.DropPKT) bool {
+	return dropCfg.Broadcast == ebpfloader.ActionDrop ||
+		dropCfg.IPv4MCast == ebpfloader.ActionDrop ||
+		dropCfg.IPv6MCast == ebpfloader.ActionDrop ||
+		dropCfg.Multicast == ebpfloader.ActionDrop ||
+		dropCfg.ARP == ebpfloader.ActionDrop ||
+		dropCfg.DHCPv4 == ebpfloader.ActionDrop ||
+		dropCfg.IPv6ND == ebpfloader.ActionDrop ||
+		dropCfg.MLD == ebpfloader.ActionDrop ||
+		dropCfg.IPv6RA == ebpfloader.ActionDrop ||
+		dropCfg.UnknownUcast == ebpfloader.ActionDrop
+}
+
+func (s *Server) status(w http.ResponseWriter, _ *http.Request) {
+	infos, err := s.netDevInfos()
+	if err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	result := Status{
+		StartedAt:       s.startedAt,
+		Interfaces:      len(infos),
+		AttachOverrides: s.controller.GetAttachOverrides(),
+	}
+	for _, info := range infos {
+		if IsBlocked(info.DropConfig) {
+			result.BlockedInterfaces++
+		}
+	}
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) reloadConfig(w http.ResponseWriter, _ *http.Request) {
+	if err := s.reload(); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) attach(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.controller.Attach(name); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	s.writeNetDevInfo(w, name)
+}
+
+func (s *Server) detach(w http.ResponseWriter, r *http.Request) {
+	if err := s.controller.Detach(r.PathValue("name")); err != nil {
+		s.writeError(w, err)
+
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
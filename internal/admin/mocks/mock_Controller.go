@@ -0,0 +1,368 @@
+// Code generated by mockery v2.52.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+
+	watcher "github.com/mythvcode/storm-control/internal/watcher"
+)
+
+// MockController is an autogenerated mock type for the Controller type
+type MockController struct {
+	mock.Mock
+}
+
+type MockController_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockController) EXPECT() *MockController_Expecter {
+	return &MockController_Expecter{mock: &_m.Mock}
+}
+
+// Attach provides a mock function with given fields: netDevName
+func (_m *MockController) Attach(netDevName string) error {
+	ret := _m.Called(netDevName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Attach")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(netDevName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockController_Attach_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Attach'
+type MockController_Attach_Call struct {
+	*mock.Call
+}
+
+// Attach is a helper method to define mock.On call
+//   - netDevName string
+func (_e *MockController_Expecter) Attach(netDevName interface{}) *MockController_Attach_Call {
+	return &MockController_Attach_Call{Call: _e.mock.On("Attach", netDevName)}
+}
+
+func (_c *MockController_Attach_Call) Run(run func(netDevName string)) *MockController_Attach_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockController_Attach_Call) Return(_a0 error) *MockController_Attach_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockController_Attach_Call) RunAndReturn(run func(string) error) *MockController_Attach_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Block provides a mock function with given fields: netDevName, trafType, duration
+func (_m *MockController) Block(netDevName string, trafType string, duration time.Duration) error {
+	ret := _m.Called(netDevName, trafType, duration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Block")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration) error); ok {
+		r0 = rf(netDevName, trafType, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockController_Block_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Block'
+type MockController_Block_Call struct {
+	*mock.Call
+}
+
+// Block is a helper method to define mock.On call
+//   - netDevName string
+//   - trafType string
+//   - duration time.Duration
+func (_e *MockController_Expecter) Block(netDevName interface{}, trafType interface{}, duration interface{}) *MockController_Block_Call {
+	return &MockController_Block_Call{Call: _e.mock.On("Block", netDevName, trafType, duration)}
+}
+
+func (_c *MockController_Block_Call) Run(run func(netDevName string, trafType string, duration time.Duration)) *MockController_Block_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *MockController_Block_Call) Return(_a0 error) *MockController_Block_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockController_Block_Call) RunAndReturn(run func(string, string, time.Duration) error) *MockController_Block_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Detach provides a mock function with given fields: netDevName
+func (_m *MockController) Detach(netDevName string) error {
+	ret := _m.Called(netDevName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Detach")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(netDevName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockController_Detach_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Detach'
+type MockController_Detach_Call struct {
+	*mock.Call
+}
+
+// Detach is a helper method to define mock.On call
+//   - netDevName string
+func (_e *MockController_Expecter) Detach(netDevName interface{}) *MockController_Detach_Call {
+	return &MockController_Detach_Call{Call: _e.mock.On("Detach", netDevName)}
+}
+
+func (_c *MockController_Detach_Call) Run(run func(netDevName string)) *MockController_Detach_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockController_Detach_Call) Return(_a0 error) *MockController_Detach_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockController_Detach_Call) RunAndReturn(run func(string) error) *MockController_Detach_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Exempt provides a mock function with given fields: netDevName, trafType, exempt
+func (_m *MockController) Exempt(netDevName string, trafType string, exempt bool) error {
+	ret := _m.Called(netDevName, trafType, exempt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Exempt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, bool) error); ok {
+		r0 = rf(netDevName, trafType, exempt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockController_Exempt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exempt'
+type MockController_Exempt_Call struct {
+	*mock.Call
+}
+
+// Exempt is a helper method to define mock.On call
+//   - netDevName string
+//   - trafType string
+//   - exempt bool
+func (_e *MockController_Expecter) Exempt(netDevName interface{}, trafType interface{}, exempt interface{}) *MockController_Exempt_Call {
+	return &MockController_Exempt_Call{Call: _e.mock.On("Exempt", netDevName, trafType, exempt)}
+}
+
+func (_c *MockController_Exempt_Call) Run(run func(netDevName string, trafType string, exempt bool)) *MockController_Exempt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(bool))
+	})
+	return _c
+}
+
+func (_c *MockController_Exempt_Call) Return(_a0 error) *MockController_Exempt_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockController_Exempt_Call) RunAndReturn(run func(string, string, bool) error) *MockController_Exempt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAttachOverrides provides a mock function with no fields
+func (_m *MockController) GetAttachOverrides() map[string]bool {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAttachOverrides")
+	}
+
+	var r0 map[string]bool
+	if rf, ok := ret.Get(0).(func() map[string]bool); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]bool)
+		}
+	}
+
+	return r0
+}
+
+// MockController_GetAttachOverrides_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAttachOverrides'
+type MockController_GetAttachOverrides_Call struct {
+	*mock.Call
+}
+
+// GetAttachOverrides is a helper method to define mock.On call
+func (_e *MockController_Expecter) GetAttachOverrides() *MockController_GetAttachOverrides_Call {
+	return &MockController_GetAttachOverrides_Call{Call: _e.mock.On("GetAttachOverrides")}
+}
+
+func (_c *MockController_GetAttachOverrides_Call) Run(run func()) *MockController_GetAttachOverrides_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockController_GetAttachOverrides_Call) Return(_a0 map[string]bool) *MockController_GetAttachOverrides_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockController_GetAttachOverrides_Call) RunAndReturn(run func() map[string]bool) *MockController_GetAttachOverrides_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNetDevStates provides a mock function with no fields
+func (_m *MockController) GetNetDevStates() []watcher.NetDevState {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNetDevStates")
+	}
+
+	var r0 []watcher.NetDevState
+	if rf, ok := ret.Get(0).(func() []watcher.NetDevState); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]watcher.NetDevState)
+		}
+	}
+
+	return r0
+}
+
+// MockController_GetNetDevStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNetDevStates'
+type MockController_GetNetDevStates_Call struct {
+	*mock.Call
+}
+
+// GetNetDevStates is a helper method to define mock.On call
+func (_e *MockController_Expecter) GetNetDevStates() *MockController_GetNetDevStates_Call {
+	return &MockController_GetNetDevStates_Call{Call: _e.mock.On("GetNetDevStates")}
+}
+
+func (_c *MockController_GetNetDevStates_Call) Run(run func()) *MockController_GetNetDevStates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockController_GetNetDevStates_Call) Return(_a0 []watcher.NetDevState) *MockController_GetNetDevStates_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockController_GetNetDevStates_Call) RunAndReturn(run func() []watcher.NetDevState) *MockController_GetNetDevStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Unblock provides a mock function with given fields: netDevName, trafType, duration
+func (_m *MockController) Unblock(netDevName string, trafType string, duration time.Duration) error {
+	ret := _m.Called(netDevName, trafType, duration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unblock")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, time.Duration) error); ok {
+		r0 = rf(netDevName, trafType, duration)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockController_Unblock_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Unblock'
+type MockController_Unblock_Call struct {
+	*mock.Call
+}
+
+// Unblock is a helper method to define mock.On call
+//   - netDevName string
+//   - trafType string
+//   - duration time.Duration
+func (_e *MockController_Expecter) Unblock(netDevName interface{}, trafType interface{}, duration interface{}) *MockController_Unblock_Call {
+	return &MockController_Unblock_Call{Call: _e.mock.On("Unblock", netDevName, trafType, duration)}
+}
+
+func (_c *MockController_Unblock_Call) Run(run func(netDevName string, trafType string, duration time.Duration)) *MockController_Unblock_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *MockController_Unblock_Call) Return(_a0 error) *MockController_Unblock_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockController_Unblock_Call) RunAndReturn(run func(string, string, time.Duration) error) *MockController_Unblock_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockController creates a new instance of MockController. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockController(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockController {
+	mock := &MockController{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,77 @@
+// Code generated by mockery v2.52.3. DO NOT EDIT.
+
+package mocks
+
+import mock "github.com/stretchr/testify/mock"
+
+// MockReloadFunc is an autogenerated mock type for the ReloadFunc type
+type MockReloadFunc struct {
+	mock.Mock
+}
+
+type MockReloadFunc_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockReloadFunc) EXPECT() *MockReloadFunc_Expecter {
+	return &MockReloadFunc_Expecter{mock: &_m.Mock}
+}
+
+// Execute provides a mock function with no fields
+func (_m *MockReloadFunc) Execute() error {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Execute")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockReloadFunc_Execute_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Execute'
+type MockReloadFunc_Execute_Call struct {
+	*mock.Call
+}
+
+// Execute is a helper method to define mock.On call
+func (_e *MockReloadFunc_Expecter) Execute() *MockReloadFunc_Execute_Call {
+	return &MockReloadFunc_Execute_Call{Call: _e.mock.On("Execute")}
+}
+
+func (_c *MockReloadFunc_Execute_Call) Run(run func()) *MockReloadFunc_Execute_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockReloadFunc_Execute_Call) Return(_a0 error) *MockReloadFunc_Execute_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockReloadFunc_Execute_Call) RunAndReturn(run func() error) *MockReloadFunc_Execute_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockReloadFunc creates a new instance of MockReloadFunc. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockReloadFunc(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockReloadFunc {
+	mock := &MockReloadFunc{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
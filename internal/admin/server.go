@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/logger"
+	"github.com/mythvcode/storm-control/internal/watcher"
+)
+
+type StatsLoader interface {
+	GetStatistic() (ebpfloader.Statistic, error)
+}
+
+// Controller provides watcher state and manual block control
+type Controller interface {
+	GetNetDevStates() []watcher.NetDevState
+	Block(netDevName, trafType string, duration time.Duration) error
+	Unblock(netDevName, trafType string, duration time.Duration) error
+	Exempt(netDevName, trafType string, exempt bool) error
+	Attach(netDevName string) error
+	Detach(netDevName string) error
+	GetAttachOverrides() map[string]bool
+}
+
+// ReloadFunc reads and applies daemon config
+type ReloadFunc func() error
+
+// Server is local admin API server listening on unix socket
+type Server struct {
+	server      *http.Server
+	config      config.AdminConfig
+	statsLoader StatsLoader
+	controller  Controller
+	reload      ReloadFunc
+	startedAt   time.Time
+	log         *logger.Logger
+}
+
+func New(cfg config.AdminConfig, statsLoader StatsLoader, controller Controller, reload ReloadFunc) *Server {
+	server := &Server{
+		config:      cfg,
+		statsLoader: statsLoader,
+		controller:  controller,
+		reload:      reload,
+		startedAt:   time.Now(),
+		log:         logger.GetLogger().With(slog.String(logger.Component, "admin-api-server")),
+	}
+	timeout := time.Duration(cfg.RequestTimeout) * time.Second
+	server.server = &http.Server{
+		Handler:      server.middlewareLogging(server.routes()),
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+		IdleTimeout:  timeout,
+	}
+
+	return server
+}
+
+func (s *Server) routes() *http.ServeMux {
+	httpMux := http.NewServeMux()
+	httpMux.HandleFunc("GET /v1/status", s.status)
+	httpMux.HandleFunc("POST /v1/reload", s.reloadConfig)
+	httpMux.HandleFunc("GET /v1/interfaces", s.listInterfaces)
+	httpMux.HandleFunc("GET /v1/interfaces/{name}", s.getInterface)
+	httpMux.HandleFunc("POST /v1/interfaces/{name}/block", s.block)
+	httpMux.HandleFunc("POST /v1/interfaces/{name}/unblock", s.unblock)
+	httpMux.HandleFunc("POST /v1/interfaces/{name}/exempt", s.exempt)
+	httpMux.HandleFunc("POST /v1/interfaces/{name}/attach", s.attach)
+	httpMux.HandleFunc("POST /v1/interfaces/{name}/detach", s.detach)
+
+	return httpMux
+}
+
+func (s *Server) middlewareLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(respwr http.ResponseWriter, req *http.Request) {
+		s.log.With(
+			slog.String("method", req.Method),
+			slog.String("agent", req.UserAgent()),
+		).Debugf("%s", req.URL.Path)
+
+		next.ServeHTTP(respwr, req)
+	})
+}
+
+// ErrSocketInUse is returned if admin socket is served by other process
+var ErrSocketInUse = errors.New("admin socket is in use")
+
+// socketDialTimeout is timeout of check that socket is served by other process
+const socketDialTimeout = time.Second
+
+// removeStaleSocket removes socket of previous instance, socket which accepts connections is kept
+func removeStaleSocket(socketPath string) error {
+	conn, err := net.DialTimeout("unix", socketPath, socketDialTimeout)
+	if err == nil {
+		conn.Close()
+
+		return fmt.Errorf("%w: %s", ErrSocketInUse, socketPath)
+	}
+	switch {
+	case errors.Is(err, syscall.ENOENT):
+		return nil
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return os.Remove(socketPath)
+	}
+
+	return err
+}
+
+// listen creates unix socket, stale socket of previous instance is removed.
+// Socket is accessible only by owner, missing directory of socket is created accessible only by owner.
+func (s *Server) listen() (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(s.config.SocketPath), 0o700); err != nil {
+		return nil, err
+	}
+	if err := removeStaleSocket(s.config.SocketPath); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", s.config.SocketPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(s.config.SocketPath, 0o600); err != nil {
+		listener.Close()
+
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// Start starts admin API server, blocks until server is stopped
+func (s *Server) Start() error {
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+	s.log.Infof("Starting admin API server on %s", s.config.SocketPath)
+
+	return s.server.Serve(listener)
+}
+
+func (s *Server) Stop() {
+	s.log.Infof("Stopping admin API server")
+	if err := s.server.Close(); err != nil {
+		s.log.Errorf("Error stop admin API server: %s", err.Error())
+	}
+}
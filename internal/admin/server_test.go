@@ -0,0 +1,284 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/admin/mocks"
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/watcher"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestServer(t *testing.T) (*Server, *mocks.MockStatsLoader, *mocks.MockController) {
+	t.Helper()
+	statsMock := mocks.NewMockStatsLoader(t)
+	controllerMock := mocks.NewMockController(t)
+
+	return New(config.AdminConfig{RequestTimeout: 1}, statsMock, controllerMock, func() error {
+		return nil
+	}), statsMock, controllerMock
+}
+
+func makeTestStatistic() ebpfloader.Statistic {
+	return ebpfloader.Statistic{
+		CounterStat: ebpfloader.CounterStat{
+			5: {Broadcast: ebpfloader.TrafInfo{Passed: 10, Dropped: 20}},
+			7: {IPv4MCast: ebpfloader.TrafInfo{Passed: 30}},
+		},
+		DropConf: ebpfloader.DropConf{
+			5: {Broadcast: ebpfloader.ActionDrop},
+			7: {},
+		},
+		AttachModes: ebpfloader.AttachModes{5: ebpfloader.AttachModeNative, 7: ebpfloader.AttachModeGeneric},
+	}
+}
+
+func makeTestStates() []watcher.NetDevState {
+	return []watcher.NetDevState{
+		{Index: 7, Name: "tap7", Policy: "default"},
+		{Index: 5, Name: "tap5", Policy: "default"},
+	}
+}
+
+func doRequest(t *testing.T, server *Server, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	server.routes().ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestListInterfaces(t *testing.T) {
+	server, statsMock, controllerMock := makeTestServer(t)
+	statsMock.EXPECT().GetStatistic().Return(makeTestStatistic(), nil)
+	controllerMock.EXPECT().GetNetDevStates().Return(makeTestStates())
+
+	resp := doRequest(t, server, http.MethodGet, "/v1/interfaces", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var infos []NetDevInfo
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &infos))
+	require.Len(t, infos, 2)
+	require.Equal(t, "tap5", infos[0].Name)
+	require.Equal(t, ebpfloader.AttachModeNative, infos[0].AttachMode)
+	require.Equal(t, uint64(20), infos[0].Counters.Broadcast.Dropped)
+	require.Equal(t, ebpfloader.ActionDrop, infos[0].DropConfig.Broadcast)
+	require.Equal(t, "default", infos[0].State.Policy)
+	require.Equal(t, "tap7", infos[1].Name)
+	require.Equal(t, uint64(30), infos[1].Counters.IPv4MCast.Passed)
+}
+
+func TestListInterfacesVLAN(t *testing.T) {
+	server, statsMock, controllerMock := makeTestServer(t)
+	stats := makeTestStatistic()
+	stats.VLANCounterStat = ebpfloader.VLANCounterStat{
+		{Ifindex: 5, VLAN: 20}: {Broadcast: ebpfloader.TrafInfo{Dropped: 15}},
+		{Ifindex: 5, VLAN: 10}: {Broadcast: ebpfloader.TrafInfo{Passed: 5}},
+	}
+	stats.VLANDropConf = ebpfloader.VLANDropConf{
+		{Ifindex: 5, VLAN: 20}: {Broadcast: ebpfloader.ActionDrop},
+	}
+	statsMock.EXPECT().GetStatistic().Return(stats, nil)
+	controllerMock.EXPECT().GetNetDevStates().Return(makeTestStates())
+
+	resp := doRequest(t, server, http.MethodGet, "/v1/interfaces", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var infos []NetDevInfo
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &infos))
+	require.Len(t, infos, 2)
+	require.Equal(t, []VLANInfo{
+		{VLAN: 10, Counters: ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: 5}}},
+		{
+			VLAN:       20,
+			Counters:   ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 15}},
+			DropConfig: ebpfloader.DropPKT{Broadcast: ebpfloader.ActionDrop},
+		},
+	}, infos[0].VLANs)
+	require.Empty(t, infos[1].VLANs)
+}
+
+func TestListInterfacesError(t *testing.T) {
+	server, statsMock, _ := makeTestServer(t)
+	statsMock.EXPECT().GetStatistic().Return(ebpfloader.Statistic{}, errors.New("map error"))
+
+	resp := doRequest(t, server, http.MethodGet, "/v1/interfaces", "")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.JSONEq(t, `{"error":"map error"}`, resp.Body.String())
+}
+
+func TestGetInterface(t *testing.T) {
+	server, statsMock, controllerMock := makeTestServer(t)
+	statsMock.EXPECT().GetStatistic().Return(makeTestStatistic(), nil)
+	controllerMock.EXPECT().GetNetDevStates().Return(makeTestStates())
+
+	resp := doRequest(t, server, http.MethodGet, "/v1/interfaces/tap7", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var info NetDevInfo
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &info))
+	require.Equal(t, 7, info.Index)
+	require.Equal(t, ebpfloader.AttachModeGeneric, info.AttachMode)
+
+	resp = doRequest(t, server, http.MethodGet, "/v1/interfaces/tap1", "")
+	require.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestBlock(t *testing.T) {
+	server, statsMock, controllerMock := makeTestServer(t)
+	statsMock.EXPECT().GetStatistic().Return(makeTestStatistic(), nil)
+	controllerMock.EXPECT().GetNetDevStates().Return(makeTestStates())
+	controllerMock.EXPECT().Block("tap5", "broadcast", 10*time.Minute).Return(nil)
+
+	resp := doRequest(t, server, http.MethodPost, "/v1/interfaces/tap5/block", `{"traffic_type":"broadcast","duration":"10m"}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	var info NetDevInfo
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &info))
+	require.Equal(t, "tap5", info.Name)
+}
+
+func TestBlockErrors(t *testing.T) {
+	server, _, controllerMock := makeTestServer(t)
+	controllerMock.EXPECT().Block("tap1", "", time.Duration(0)).Return(watcher.ErrNetDevNotFound)
+	controllerMock.EXPECT().Block("tap5", "unicast", time.Duration(0)).Return(watcher.ErrUnknownTrafficType)
+	controllerMock.EXPECT().Block("tap7", "", time.Duration(0)).Return(watcher.ErrDryRun)
+
+	resp := doRequest(t, server, http.MethodPost, "/v1/interfaces/tap1/block", `{}`)
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	resp = doRequest(t, server, http.MethodPost, "/v1/interfaces/tap5/block", `{"traffic_type":"unicast"}`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = doRequest(t, server, http.MethodPost, "/v1/interfaces/tap7/block", `{}`)
+	require.Equal(t, http.StatusConflict, resp.Code)
+	resp = doRequest(t, server, http.MethodPost, "/v1/interfaces/tap5/block", `{"duration":"10"}`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = doRequest(t, server, http.MethodPost, "/v1/interfaces/tap5/block", `{"duration":"-1s"}`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = doRequest(t, server, http.MethodPost, "/v1/interfaces/tap5/block", `not json`)
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = doRequest(t, server, http.MethodGet, "/v1/interfaces/tap5/block", ``)
+	require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+}
+
+func TestUnblockAndExempt(t *testing.T) {
+	server, statsMock, controllerMock := makeTestServer(t)
+	statsMock.EXPECT().GetStatistic().Return(makeTestStatistic(), nil)
+	controllerMock.EXPECT().GetNetDevStates().Return(makeTestStates())
+	controllerMock.EXPECT().Unblock("tap5", "", time.Duration(0)).Return(nil)
+	controllerMock.EXPECT().Exempt("tap7", "ipv6_multicast", true).Return(nil)
+
+	resp := doRequest(t, server, http.MethodPost, "/v1/interfaces/tap5/unblock", `{}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = doRequest(t, server, http.MethodPost, "/v1/interfaces/tap7/exempt", `{"traffic_type":"ipv6_multicast","exempt":true}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestStatus(t *testing.T) {
+	server, statsMock, controllerMock := makeTestServer(t)
+	statsMock.EXPECT().GetStatistic().Return(makeTestStatistic(), nil)
+	controllerMock.EXPECT().GetNetDevStates().Return(makeTestStates())
+	controllerMock.EXPECT().GetAttachOverrides().Return(map[string]bool{"eth1": true})
+
+	resp := doRequest(t, server, http.MethodGet, "/v1/status", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var status Status
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+	require.Equal(t, 2, status.Interfaces)
+	require.Equal(t, 1, status.BlockedInterfaces)
+	require.Equal(t, map[string]bool{"eth1": true}, status.AttachOverrides)
+	require.WithinDuration(t, time.Now(), status.StartedAt, time.Minute)
+}
+
+func TestAttachDetach(t *testing.T) {
+	server, statsMock, controllerMock := makeTestServer(t)
+	statsMock.EXPECT().GetStatistic().Return(makeTestStatistic(), nil)
+	controllerMock.EXPECT().GetNetDevStates().Return(makeTestStates())
+	controllerMock.EXPECT().Attach("tap5").Return(nil)
+	controllerMock.EXPECT().Attach("tap1").Return(watcher.ErrNetDevNotExist)
+	controllerMock.EXPECT().Detach("tap7").Return(nil)
+
+	resp := doRequest(t, server, http.MethodPost, "/v1/interfaces/tap5/attach", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = doRequest(t, server, http.MethodPost, "/v1/interfaces/tap1/attach", "")
+	require.Equal(t, http.StatusNotFound, resp.Code)
+	resp = doRequest(t, server, http.MethodPost, "/v1/interfaces/tap7/detach", "")
+	require.Equal(t, http.StatusNoContent, resp.Code)
+}
+
+func TestReload(t *testing.T) {
+	reloadErr := errors.New("invalid config")
+	server := New(config.AdminConfig{}, mocks.NewMockStatsLoader(t), mocks.NewMockController(t), func() error {
+		return reloadErr
+	})
+	resp := doRequest(t, server, http.MethodPost, "/v1/reload", "")
+	require.Equal(t, http.StatusInternalServerError, resp.Code)
+	require.JSONEq(t, `{"error":"invalid config"}`, resp.Body.String())
+
+	reloadErr = nil
+	resp = doRequest(t, server, http.MethodPost, "/v1/reload", "")
+	require.Equal(t, http.StatusNoContent, resp.Code)
+}
+
+func TestStartUnixSocket(t *testing.T) {
+	statsMock := mocks.NewMockStatsLoader(t)
+	controllerMock := mocks.NewMockController(t)
+	socketPath := filepath.Join(t.TempDir(), "admin", "admin.sock")
+	server := New(config.AdminConfig{SocketPath: socketPath, RequestTimeout: 1}, statsMock, controllerMock, nil)
+	statsMock.EXPECT().GetStatistic().Return(ebpfloader.Statistic{}, nil)
+	controllerMock.EXPECT().GetNetDevStates().Return(nil)
+
+	started := make(chan error)
+	go func() {
+		started <- server.Start()
+	}()
+	defer server.Stop()
+	client := http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("http://admin/v1/interfaces")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		return resp.StatusCode == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+	server.Stop()
+	require.ErrorIs(t, <-started, http.ErrServerClosed)
+}
+
+func TestListenSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "admin", "admin.sock")
+	server := New(config.AdminConfig{SocketPath: socketPath, RequestTimeout: 1}, nil, nil, nil)
+	// socket of previous instance which is not served is removed
+	stale, err := server.listen()
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+	listener, err := server.listen()
+	require.NoError(t, err)
+	defer listener.Close()
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	info, err = os.Stat(filepath.Dir(socketPath))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	// socket served by other process is kept
+	_, err = server.listen()
+	require.ErrorIs(t, err, ErrSocketInUse)
+	_, err = os.Stat(socketPath)
+	require.NoError(t, err)
+}
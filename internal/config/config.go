@@ -15,19 +15,177 @@ type StormControlConfig struct {
 	Watcher  WatcherConfig `yaml:"watcher"`
 	Logger   LoggerConfig  `yaml:"logger"`
 	Exporter Exporter      `yaml:"exporter"`
+	EBPF     EBPFConfig    `yaml:"ebpf"`
+	Admin    AdminConfig   `yaml:"admin"`
+	Events   EventsConfig  `env:",prefix=EVENTS_" yaml:"events"`
+}
+
+// EventsConfig describes notification sinks of block and unblock events.
+type EventsConfig struct {
+	Webhook WebhookConfig `env:",prefix=WEBHOOK_" yaml:"webhook"`
+}
+
+// WebhookConfig describes HTTP webhook sink. Event is sent as JSON body of POST request,
+// Template is Go text/template of body executed with event, by default event is sent as is.
+// Failed requests are retried Retries times with RetryInterval seconds doubled after each attempt.
+// Only events with Actions are sent.
+type WebhookConfig struct {
+	Enable        bool              `default:"false"                   env:"ENABLE"         yaml:"enable"`
+	URL           string            `default:""                        env:"URL"            yaml:"url"`
+	Timeout       int               `default:"5"                       env:"TIMEOUT"        yaml:"timeout"`
+	Retries       int               `default:"3"                       env:"RETRIES"        yaml:"retries"`
+	RetryInterval int               `default:"1"                       env:"RETRY_INTERVAL" yaml:"retry_interval"`
+	QueueSize     int               `default:"100"                     env:"QUEUE_SIZE"     yaml:"queue_size"`
+	Template      string            `default:""                        env:"TEMPLATE"       yaml:"template"`
+	Actions       []string          `default:"[\"block\",\"unblock\"]" env:"ACTIONS"        yaml:"actions"`
+	Headers       map[string]string `env:"HEADERS"                     yaml:"headers"`
+}
+
+// AdminConfig describes local admin API used for status and manual block control.
+type AdminConfig struct {
+	Enable         bool   `default:"true"                          env:"ADMIN_ENABLE"          yaml:"enable"`
+	SocketPath     string `default:"/run/storm-control/admin.sock" env:"ADMIN_SOCKET_PATH"     yaml:"socket_path"`
+	RequestTimeout int    `default:"10"                            env:"ADMIN_REQUEST_TIMEOUT" yaml:"request_timeout"`
+}
+
+// EBPFConfig describes pinning of eBPF maps and links to bpffs.
+// Pinned program stays attached after daemon stop, counters and drop state are kept between restarts.
+type EBPFConfig struct {
+	Pin     bool   `default:"false"                     env:"EBPF_PIN"      yaml:"pin"`
+	PinPath string `default:"/sys/fs/bpf/storm_control" env:"EBPF_PIN_PATH" yaml:"pin_path"`
 }
 
 type LoggerConfig struct {
-	Level string `default:"debug" env:"LOG_LEVEL" yaml:"level"`
-	File  string `default:""      env:"LOG_FILE"  yaml:"file"`
+	Level    string         `default:"debug"              env:"LOG_LEVEL" yaml:"level"`
+	File     string         `default:""                   env:"LOG_FILE"  yaml:"file"`
+	Journald JournaldConfig `env:",prefix=LOG_JOURNALD_" yaml:"journald"`
+	Syslog   SyslogConfig   `env:",prefix=LOG_SYSLOG_"   yaml:"syslog"`
+}
+
+// JournaldConfig describes sending logs to systemd journal by native protocol.
+type JournaldConfig struct {
+	Enable     bool   `default:"false"                       env:"ENABLE"      yaml:"enable"`
+	SocketPath string `default:"/run/systemd/journal/socket" env:"SOCKET_PATH" yaml:"socket_path"`
 }
 
+// SyslogConfig describes sending logs in RFC 5424 format.
+// Network is unixgram, unix, udp or tcp, stream messages are framed by octet counting.
+type SyslogConfig struct {
+	Enable   bool   `default:"false"         env:"ENABLE"   yaml:"enable"`
+	Network  string `default:"unixgram"      env:"NETWORK"  yaml:"network"`
+	Address  string `default:"/dev/log"      env:"ADDRESS"  yaml:"address"`
+	Facility string `default:"daemon"        env:"FACILITY" yaml:"facility"`
+	Tag      string `default:"storm-control" env:"TAG"      yaml:"tag"`
+}
+
+// WatcherConfig describes interfaces selection and block decisions.
+// In dry run mode block decisions of drop mode are made, logged and reported by events and metrics
+// regardless of BlockEnabled and BlockMode, but drop config of interfaces is never changed.
 type WatcherConfig struct {
-	BlockDelay     int      `default:"10"             env:"BLOCK_DELAY"     yaml:"block_delay"`
-	BlockEnabled   bool     `default:"false"          env:"BLOCK_ENABLED"   yaml:"block_enabled"`
-	BlockThreshold uint64   `default:"100"            env:"BLOCK_THRESHOLD" yaml:"block_threshold"`
-	StaticDevList  []string `default:"[]"             env:"STATIC_DEV_LIST" yaml:"device_list"`
-	DevRegEx       string   `default:"^tap.{8}-.{2}$" env:"DEV_REGEX"       yaml:"device_regex"`
+	BlockDelay          int                `default:"10"             env:"BLOCK_DELAY"           yaml:"block_delay"`
+	BlockEnabled        bool               `default:"false"          env:"BLOCK_ENABLED"         yaml:"block_enabled"`
+	BlockThreshold      uint64             `default:"100"            env:"BLOCK_THRESHOLD"       yaml:"block_threshold"`
+	BlockThresholdBytes uint64             `default:"0"              env:"BLOCK_THRESHOLD_BYTES" yaml:"block_threshold_bytes"`
+	BlockMode           string             `default:"drop"           env:"BLOCK_MODE"            yaml:"block_mode"`
+	BlockBurst          uint64             `default:"0"              env:"BLOCK_BURST"           yaml:"block_burst"`
+	AttachMode          string             `default:"auto"           env:"ATTACH_MODE"           yaml:"attach_mode"`
+	DryRun              bool               `default:"false"          env:"DRY_RUN"               yaml:"dry_run"`
+	TrafficLimits       TrafficLimits      `yaml:"traffic_limits"`
+	Unblock             UnblockConfig      `env:",prefix=UNBLOCK_"       yaml:"unblock"`
+	Backoff             BackoffConfig      `env:",prefix=BACKOFF_"       yaml:"backoff"`
+	UnicastCheck        UnicastCheckConfig `env:",prefix=UNICAST_CHECK_" yaml:"unicast_check"`
+	TopTalkers          TopTalkersConfig   `env:",prefix=TOP_TALKERS_"   yaml:"top_talkers"`
+	StaticDevList       []string           `default:"[]"             env:"STATIC_DEV_LIST"       yaml:"device_list"`
+	DevRegEx            string             `default:"^tap.{8}-.{2}$" env:"DEV_REGEX"             yaml:"device_regex"`
+	ResyncInterval      int                `default:"60"             env:"RESYNC_INTERVAL"       yaml:"resync_interval"`
+	Policies            []Policy           `yaml:"policies"`
+}
+
+// UnblockConfig describes unblock process after block delay.
+// Traffic is unblocked when dropped packets (and bytes) rate is below unblock threshold
+// during QuietWindows consecutive intervals of RecheckInterval seconds.
+type UnblockConfig struct {
+	// packets per second, if 0 ThresholdRatio of block threshold is used.
+	// Bytes unblock threshold is always ThresholdRatio of bytes block threshold.
+	Threshold       uint64  `default:"0" env:"THRESHOLD"        yaml:"threshold"`
+	ThresholdRatio  float64 `default:"1" env:"THRESHOLD_RATIO"  yaml:"threshold_ratio"`
+	RecheckInterval int     `default:"3" env:"RECHECK_INTERVAL" yaml:"recheck_interval"`
+	QuietWindows    int     `default:"1" env:"QUIET_WINDOWS"    yaml:"quiet_windows"`
+}
+
+// BackoffConfig describes growth of block duration for repeatedly blocked traffic.
+// Every next block lasts Multiplier times longer than previous one up to MaxDelay seconds,
+// each DecayAfter seconds without blocks decrease duration back to block_delay.
+type BackoffConfig struct {
+	Enabled    bool    `default:"false" env:"ENABLED"     yaml:"enabled"`
+	Multiplier float64 `default:"2"     env:"MULTIPLIER"  yaml:"multiplier"`
+	MaxDelay   int     `default:"600"   env:"MAX_DELAY"   yaml:"max_delay"`
+	DecayAfter int     `default:"300"   env:"DECAY_AFTER" yaml:"decay_after"`
+}
+
+// UnicastCheckConfig enables counting of unknown unicast frames on ports of Linux bridges.
+// Addresses learned by bridge on port and AllowedMACs are expected source addresses of port,
+// addresses of bridge and AllowedMACs are known destination addresses.
+// Addresses are read from bridge forwarding database every LearnInterval seconds.
+type UnicastCheckConfig struct {
+	Enabled       bool     `default:"false" env:"ENABLED"        yaml:"enabled"`
+	AllowedMACs   []string `default:"[]"    env:"ALLOWED_MACS"   yaml:"allowed_macs"`
+	LearnInterval int      `default:"5"     env:"LEARN_INTERVAL" yaml:"learn_interval"`
+}
+
+// TopTalkersConfig enables counting of broadcast and multicast frames by source address.
+// Count sources with highest rate of last second are reported in block events, admin API and metrics.
+type TopTalkersConfig struct {
+	Enabled bool `default:"false" env:"ENABLED" yaml:"enabled"`
+	Count   int  `default:"5"     env:"COUNT"   yaml:"count"`
+}
+
+// Policy overrides watcher settings for matched interfaces.
+// All specified match conditions must be satisfied, first matched policy is used.
+type Policy struct {
+	Name                string        `yaml:"name"`
+	InterfaceName       string        `yaml:"interface_name"`
+	InterfaceRegEx      string        `yaml:"interface_regex"`
+	InterfaceIndex      int           `yaml:"interface_index"`
+	BlockEnabled        *bool         `yaml:"block_enabled"`
+	BlockThreshold      uint64        `yaml:"block_threshold"`
+	BlockThresholdBytes uint64        `yaml:"block_threshold_bytes"`
+	BlockDelay          int           `yaml:"block_delay"`
+	BlockMode           string        `yaml:"block_mode"`
+	BlockBurst          uint64        `yaml:"block_burst"`
+	AttachMode          string        `yaml:"attach_mode"`
+	DryRun              *bool         `yaml:"dry_run"`
+	TrafficLimits       TrafficLimits `yaml:"traffic_limits"`
+	// counters and block decisions by VLAN of trunk interface
+	VLANAware bool `yaml:"vlan_aware"`
+	// types of traffic which are never blocked
+	Exempt []string `yaml:"exempt"`
+	// allowed addresses of unicast check, global allowed addresses are used if not specified
+	AllowedMACs []string `yaml:"allowed_macs"`
+}
+
+// TrafficLimits overrides block threshold and block delay for specific type of traffic.
+// ARP, DHCPv4, IPv6 ND, MLD and RA are limited separately from broadcast and IPv6 multicast.
+// UnknownUnicast is used only with enabled unicast check.
+type TrafficLimits struct {
+	Broadcast      TrafficLimit `env:",prefix=BROADCAST_"       yaml:"broadcast"`
+	IPv4Multicast  TrafficLimit `env:",prefix=IPV4_MULTICAST_"  yaml:"ipv4_multicast"`
+	IPv6Multicast  TrafficLimit `env:",prefix=IPV6_MULTICAST_"  yaml:"ipv6_multicast"`
+	OtherMulticast TrafficLimit `env:",prefix=OTHER_MULTICAST_" yaml:"other_multicast"`
+	ARP            TrafficLimit `env:",prefix=ARP_"             yaml:"arp"`
+	DHCPv4         TrafficLimit `env:",prefix=DHCPV4_"          yaml:"dhcpv4"`
+	IPv6ND         TrafficLimit `env:",prefix=IPV6_ND_"         yaml:"ipv6_nd"`
+	MLD            TrafficLimit `env:",prefix=MLD_"             yaml:"mld"`
+	IPv6RA         TrafficLimit `env:",prefix=IPV6_RA_"         yaml:"ipv6_ra"`
+	UnknownUnicast TrafficLimit `env:",prefix=UNKNOWN_UNICAST_" yaml:"unknown_unicast"`
+}
+
+// TrafficLimit zero values mean that global block_threshold, block_threshold_bytes, block_delay and block_burst are used.
+type TrafficLimit struct {
+	BlockThreshold      uint64 `default:"0" env:"BLOCK_THRESHOLD"       yaml:"block_threshold"`
+	BlockThresholdBytes uint64 `default:"0" env:"BLOCK_THRESHOLD_BYTES" yaml:"block_threshold_bytes"`
+	BlockDelay          int    `default:"0" env:"BLOCK_DELAY"           yaml:"block_delay"`
+	BlockBurst          uint64 `default:"0" env:"BLOCK_BURST"           yaml:"block_burst"`
 }
 
 type Exporter struct {
@@ -38,6 +196,10 @@ type Exporter struct {
 	Enable               bool   `default:"true"      env:"EXPORTER_ENABLE"                 yaml:"enable"`
 	EnableRequestLogging bool   `default:"true"      env:"EXPORTER_ENABLE_REQUEST_LOGGING" yaml:"enable_request_logging"`
 	EnableRuntimeMetrics bool   `default:"false"     env:"EXPORTER_ENABLE_RUNTIME_METRICS" yaml:"enable_runtime_metrics"`
+	// rates of top talkers, used only with enabled watcher top talkers
+	EnableTopTalkersMetrics bool `default:"false" env:"EXPORTER_ENABLE_TOP_TALKERS_METRICS" yaml:"enable_top_talkers_metrics"`
+	// windows in seconds of peak and average traffic rates
+	RateWindows []int `default:"[10,60]"   env:"EXPORTER_RATE_WINDOWS"           yaml:"rate_windows"`
 }
 
 func (c *StormControlConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
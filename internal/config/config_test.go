@@ -13,22 +13,92 @@ const testConfig = `
 logger:
   file: "test_file"
   level: info
+  journald:
+    enable: true
+  syslog:
+    enable: true
+    network: udp
+    address: 10.0.0.1:514
+    facility: local3
 watcher:
   block_delay: 123
   block_enabled: true
   block_threshold: 555
+  block_mode: rate_limit
+  block_burst: 1000
+  block_threshold_bytes: 1500000
+  attach_mode: native
+  dry_run: true
+  traffic_limits:
+    broadcast:
+      block_threshold: 50
+      block_threshold_bytes: 64000
+    ipv6_multicast:
+      block_threshold: 1000
+      block_delay: 5
+    dhcpv4:
+      block_threshold: 20
+    unknown_unicast:
+      block_threshold: 1000
+  unblock:
+    threshold_ratio: 0.5
+    recheck_interval: 2
+    quiet_windows: 4
+  backoff:
+    enabled: true
+    max_delay: 120
+  unicast_check:
+    enabled: true
+    allowed_macs:
+    - fa:16:3e:00:00:01
+    learn_interval: 2
+  top_talkers:
+    enabled: true
+    count: 3
   device_list:
   - eth5
   - eth55 
   device_regex: test_regex
+  resync_interval: 30
+  policies:
+  - name: routers
+    interface_regex: ^tapr
+    block_threshold: 5000
+    dry_run: false
+    exempt:
+    - ipv6_multicast
+  - interface_index: 15
+    block_enabled: false
+    attach_mode: generic
+    vlan_aware: true
+    allowed_macs:
+    - fa:16:3e:00:00:fe
+ebpf:
+  pin: true
+  pin_path: /sys/fs/bpf/test
+admin:
+  socket_path: /tmp/test_admin.sock
+  request_timeout: 3
+events:
+  webhook:
+    enable: true
+    url: http://noc.example.com/hook
+    retries: 5
+    template: '{"text": "{{ .Interface }} {{ .Action }}"}'
+    actions:
+    - block
+    headers:
+      Authorization: Bearer test
 exporter:
   enable: false
   enable_request_logging: false
   enable_runtime_metrics: true
+  enable_top_talkers_metrics: true
   server_address: yaml_test_address
   server_port:    1010
   request_timeout: 55555
   telemetry_path: "/test_conf_path"
+  rate_windows: [5, 300]
 
 `
 
@@ -36,18 +106,37 @@ func testDefaults(t *testing.T, cfg StormControlConfig) {
 	t.Helper()
 	require.Empty(t, cfg.Logger.File)
 	require.Equal(t, "debug", cfg.Logger.Level)
+	require.Equal(t, JournaldConfig{SocketPath: "/run/systemd/journal/socket"}, cfg.Logger.Journald)
+	require.Equal(t, SyslogConfig{Network: "unixgram", Address: "/dev/log", Facility: "daemon", Tag: "storm-control"}, cfg.Logger.Syslog)
 	require.Equal(t, 10, cfg.Watcher.BlockDelay)
 	require.Equal(t, uint64(100), cfg.Watcher.BlockThreshold)
+	require.Equal(t, "drop", cfg.Watcher.BlockMode)
+	require.Equal(t, uint64(0), cfg.Watcher.BlockBurst)
+	require.Equal(t, uint64(0), cfg.Watcher.BlockThresholdBytes)
+	require.Equal(t, "auto", cfg.Watcher.AttachMode)
+	require.False(t, cfg.Watcher.DryRun)
+	require.Equal(t, EBPFConfig{PinPath: "/sys/fs/bpf/storm_control"}, cfg.EBPF)
+	require.Equal(t, AdminConfig{Enable: true, SocketPath: "/run/storm-control/admin.sock", RequestTimeout: 10}, cfg.Admin)
+	require.Equal(t, WebhookConfig{Timeout: 5, Retries: 3, RetryInterval: 1, QueueSize: 100, Actions: []string{"block", "unblock"}}, cfg.Events.Webhook)
+	require.Equal(t, TrafficLimits{}, cfg.Watcher.TrafficLimits)
+	require.Equal(t, UnblockConfig{ThresholdRatio: 1, RecheckInterval: 3, QuietWindows: 1}, cfg.Watcher.Unblock)
+	require.Equal(t, BackoffConfig{Multiplier: 2, MaxDelay: 600, DecayAfter: 300}, cfg.Watcher.Backoff)
+	require.Equal(t, UnicastCheckConfig{AllowedMACs: []string{}, LearnInterval: 5}, cfg.Watcher.UnicastCheck)
+	require.Equal(t, TopTalkersConfig{Count: 5}, cfg.Watcher.TopTalkers)
 	require.Equal(t, `^tap.{8}-.{2}$`, cfg.Watcher.DevRegEx)
 	require.False(t, cfg.Watcher.BlockEnabled)
 	require.Empty(t, cfg.Watcher.StaticDevList)
+	require.Equal(t, 60, cfg.Watcher.ResyncInterval)
+	require.Empty(t, cfg.Watcher.Policies)
 	require.True(t, cfg.Exporter.Enable)
 	require.True(t, cfg.Exporter.EnableRequestLogging)
 	require.False(t, cfg.Exporter.EnableRuntimeMetrics)
+	require.False(t, cfg.Exporter.EnableTopTalkersMetrics)
 	require.Equal(t, "localhost", cfg.Exporter.ServerAddress)
 	require.Equal(t, 8080, cfg.Exporter.ServerPort)
 	require.Equal(t, 10, cfg.Exporter.RequestTimeout)
 	require.Equal(t, "/metrics", cfg.Exporter.TelemetryPath)
+	require.Equal(t, []int{10, 60}, cfg.Exporter.RateWindows)
 }
 
 func setEnvVars(t *testing.T) {
@@ -76,6 +165,126 @@ func setEnvVars(t *testing.T) {
 			"BLOCK_THRESHOLD",
 			"55555",
 		},
+		{
+			"BLOCK_MODE",
+			"rate_limit",
+		},
+		{
+			"BLOCK_THRESHOLD_BYTES",
+			"100000",
+		},
+		{
+			"ATTACH_MODE",
+			"generic",
+		},
+		{
+			"DRY_RUN",
+			"true",
+		},
+		{
+			"EBPF_PIN",
+			"true",
+		},
+		{
+			"ADMIN_ENABLE",
+			"false",
+		},
+		{
+			"ADMIN_SOCKET_PATH",
+			"/tmp/env_admin.sock",
+		},
+		{
+			"LOG_JOURNALD_ENABLE",
+			"true",
+		},
+		{
+			"LOG_SYSLOG_NETWORK",
+			"tcp",
+		},
+		{
+			"EVENTS_WEBHOOK_ENABLE",
+			"true",
+		},
+		{
+			"EVENTS_WEBHOOK_URL",
+			"http://env.example.com/hook",
+		},
+		{
+			"EVENTS_WEBHOOK_QUEUE_SIZE",
+			"10",
+		},
+		{
+			"EVENTS_WEBHOOK_ACTIONS",
+			"block,unblock,recheck_failed",
+		},
+		{
+			"EVENTS_WEBHOOK_HEADERS",
+			"X-Token:env_token",
+		},
+		{
+			"BROADCAST_BLOCK_BURST",
+			"300",
+		},
+		{
+			"IPV6_MULTICAST_BLOCK_THRESHOLD_BYTES",
+			"50000",
+		},
+		{
+			"IPV4_MULTICAST_BLOCK_THRESHOLD",
+			"200",
+		},
+		{
+			"OTHER_MULTICAST_BLOCK_DELAY",
+			"30",
+		},
+		{
+			"ARP_BLOCK_THRESHOLD",
+			"500",
+		},
+		{
+			"UNKNOWN_UNICAST_BLOCK_DELAY",
+			"60",
+		},
+		{
+			"UNBLOCK_THRESHOLD",
+			"20",
+		},
+		{
+			"UNBLOCK_RECHECK_INTERVAL",
+			"5",
+		},
+		{
+			"UNBLOCK_QUIET_WINDOWS",
+			"2",
+		},
+		{
+			"BACKOFF_ENABLED",
+			"true",
+		},
+		{
+			"BACKOFF_MULTIPLIER",
+			"1.5",
+		},
+		{
+			"UNICAST_CHECK_ENABLED",
+			"true",
+		},
+		{
+			"UNICAST_CHECK_ALLOWED_MACS",
+			"fa:16:3e:00:00:01,fa:16:3e:00:00:02",
+		},
+		{
+			"UNICAST_CHECK_LEARN_INTERVAL",
+			"10",
+		},
+		{
+			"TOP_TALKERS_ENABLED",
+			"true",
+		},
+		{
+			"TOP_TALKERS_COUNT",
+			"10",
+		},
 		{
 			"STATIC_DEV_LIST",
 			"eth1, eth2",
@@ -84,6 +293,10 @@ func setEnvVars(t *testing.T) {
 			"DEV_REGEX",
 			"test_env_regexp",
 		},
+		{
+			"RESYNC_INTERVAL",
+			"15",
+		},
 		{
 			"EXPORTER_HOST",
 			"test_host",
@@ -112,6 +325,14 @@ func setEnvVars(t *testing.T) {
 			"EXPORTER_ENABLE_RUNTIME_METRICS",
 			"true",
 		},
+		{
+			"EXPORTER_ENABLE_TOP_TALKERS_METRICS",
+			"true",
+		},
+		{
+			"EXPORTER_RATE_WINDOWS",
+			"30,120",
+		},
 	}
 	for _, env := range envVars {
 		t.Setenv(env.envName, env.value)
@@ -141,18 +362,51 @@ func TestLoadFromEnv(t *testing.T) {
 
 	require.Equal(t, "env_log_file", cfg.Logger.File)
 	require.Equal(t, "env_log_level", cfg.Logger.Level)
+	require.Equal(t, JournaldConfig{Enable: true, SocketPath: "/run/systemd/journal/socket"}, cfg.Logger.Journald)
+	require.Equal(t, SyslogConfig{Network: "tcp", Address: "/dev/log", Facility: "daemon", Tag: "storm-control"}, cfg.Logger.Syslog)
 	require.Equal(t, 12345, cfg.Watcher.BlockDelay)
 	require.True(t, cfg.Watcher.BlockEnabled)
 	require.Equal(t, uint64(55555), cfg.Watcher.BlockThreshold)
+	require.Equal(t, "rate_limit", cfg.Watcher.BlockMode)
+	require.Equal(t, uint64(100000), cfg.Watcher.BlockThresholdBytes)
+	require.Equal(t, "generic", cfg.Watcher.AttachMode)
+	require.True(t, cfg.Watcher.DryRun)
+	require.Equal(t, EBPFConfig{Pin: true, PinPath: "/sys/fs/bpf/storm_control"}, cfg.EBPF)
+	require.Equal(t, AdminConfig{SocketPath: "/tmp/env_admin.sock", RequestTimeout: 10}, cfg.Admin)
+	require.Equal(t, WebhookConfig{
+		Enable:        true,
+		URL:           "http://env.example.com/hook",
+		Timeout:       5,
+		Retries:       3,
+		RetryInterval: 1,
+		QueueSize:     10,
+		Actions:       []string{"block", "unblock", "recheck_failed"},
+		Headers:       map[string]string{"X-Token": "env_token"},
+	}, cfg.Events.Webhook)
+	require.Equal(t, TrafficLimits{
+		Broadcast:      TrafficLimit{BlockBurst: 300},
+		IPv4Multicast:  TrafficLimit{BlockThreshold: 200},
+		IPv6Multicast:  TrafficLimit{BlockThresholdBytes: 50000},
+		OtherMulticast: TrafficLimit{BlockDelay: 30},
+		ARP:            TrafficLimit{BlockThreshold: 500},
+		UnknownUnicast: TrafficLimit{BlockDelay: 60},
+	}, cfg.Watcher.TrafficLimits)
+	require.Equal(t, UnblockConfig{Threshold: 20, ThresholdRatio: 1, RecheckInterval: 5, QuietWindows: 2}, cfg.Watcher.Unblock)
+	require.Equal(t, BackoffConfig{Enabled: true, Multiplier: 1.5, MaxDelay: 600, DecayAfter: 300}, cfg.Watcher.Backoff)
+	require.Equal(t, UnicastCheckConfig{Enabled: true, AllowedMACs: []string{"fa:16:3e:00:00:01", "fa:16:3e:00:00:02"}, LearnInterval: 10}, cfg.Watcher.UnicastCheck)
+	require.Equal(t, TopTalkersConfig{Enabled: true, Count: 10}, cfg.Watcher.TopTalkers)
 	require.Equal(t, "test_env_regexp", cfg.Watcher.DevRegEx)
 	require.Equal(t, []string{"eth1", "eth2"}, cfg.Watcher.StaticDevList)
+	require.Equal(t, 15, cfg.Watcher.ResyncInterval)
 	require.False(t, cfg.Exporter.Enable)
 	require.False(t, cfg.Exporter.EnableRequestLogging)
 	require.True(t, cfg.Exporter.EnableRuntimeMetrics)
+	require.True(t, cfg.Exporter.EnableTopTalkersMetrics)
 	require.Equal(t, "test_host", cfg.Exporter.ServerAddress)
 	require.Equal(t, 12345, cfg.Exporter.ServerPort)
 	require.Equal(t, 11111, cfg.Exporter.RequestTimeout)
 	require.Equal(t, "/test_path", cfg.Exporter.TelemetryPath)
+	require.Equal(t, []int{30, 120}, cfg.Exporter.RateWindows)
 }
 
 func TestLoadFromFile(t *testing.T) {
@@ -160,16 +414,66 @@ func TestLoadFromFile(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "test_file", cfg.Logger.File)
 	require.Equal(t, "info", cfg.Logger.Level)
+	require.True(t, cfg.Logger.Journald.Enable)
+	require.Equal(t, SyslogConfig{Enable: true, Network: "udp", Address: "10.0.0.1:514", Facility: "local3", Tag: "storm-control"}, cfg.Logger.Syslog)
 	require.Equal(t, 123, cfg.Watcher.BlockDelay)
 	require.True(t, cfg.Watcher.BlockEnabled)
 	require.Equal(t, uint64(555), cfg.Watcher.BlockThreshold)
+	require.Equal(t, "rate_limit", cfg.Watcher.BlockMode)
+	require.Equal(t, uint64(1000), cfg.Watcher.BlockBurst)
+	require.Equal(t, uint64(1500000), cfg.Watcher.BlockThresholdBytes)
+	require.Equal(t, "native", cfg.Watcher.AttachMode)
+	require.True(t, cfg.Watcher.DryRun)
+	require.Equal(t, EBPFConfig{Pin: true, PinPath: "/sys/fs/bpf/test"}, cfg.EBPF)
+	require.Equal(t, AdminConfig{Enable: true, SocketPath: "/tmp/test_admin.sock", RequestTimeout: 3}, cfg.Admin)
+	require.Equal(t, WebhookConfig{
+		Enable:        true,
+		URL:           "http://noc.example.com/hook",
+		Timeout:       5,
+		Retries:       5,
+		RetryInterval: 1,
+		QueueSize:     100,
+		Template:      `{"text": "{{ .Interface }} {{ .Action }}"}`,
+		Actions:       []string{"block"},
+		Headers:       map[string]string{"Authorization": "Bearer test"},
+	}, cfg.Events.Webhook)
+	require.Equal(t, TrafficLimits{
+		Broadcast:      TrafficLimit{BlockThreshold: 50, BlockThresholdBytes: 64000},
+		IPv6Multicast:  TrafficLimit{BlockThreshold: 1000, BlockDelay: 5},
+		DHCPv4:         TrafficLimit{BlockThreshold: 20},
+		UnknownUnicast: TrafficLimit{BlockThreshold: 1000},
+	}, cfg.Watcher.TrafficLimits)
+	require.Equal(t, UnblockConfig{ThresholdRatio: 0.5, RecheckInterval: 2, QuietWindows: 4}, cfg.Watcher.Unblock)
+	require.Equal(t, BackoffConfig{Enabled: true, Multiplier: 2, MaxDelay: 120, DecayAfter: 300}, cfg.Watcher.Backoff)
+	require.Equal(t, UnicastCheckConfig{Enabled: true, AllowedMACs: []string{"fa:16:3e:00:00:01"}, LearnInterval: 2}, cfg.Watcher.UnicastCheck)
+	require.Equal(t, TopTalkersConfig{Enabled: true, Count: 3}, cfg.Watcher.TopTalkers)
 	require.Equal(t, "test_regex", cfg.Watcher.DevRegEx)
 	require.Equal(t, []string{"eth5", "eth55"}, cfg.Watcher.StaticDevList)
+	require.Equal(t, 30, cfg.Watcher.ResyncInterval)
+	blockEnabled, dryRun := false, false
+	require.Equal(t, []Policy{
+		{
+			Name:           "routers",
+			InterfaceRegEx: "^tapr",
+			BlockThreshold: 5000,
+			DryRun:         &dryRun,
+			Exempt:         []string{"ipv6_multicast"},
+		},
+		{
+			InterfaceIndex: 15,
+			BlockEnabled:   &blockEnabled,
+			AttachMode:     "generic",
+			VLANAware:      true,
+			AllowedMACs:    []string{"fa:16:3e:00:00:fe"},
+		},
+	}, cfg.Watcher.Policies)
 	require.False(t, cfg.Exporter.Enable)
 	require.False(t, cfg.Exporter.EnableRequestLogging)
 	require.True(t, cfg.Exporter.EnableRuntimeMetrics)
+	require.True(t, cfg.Exporter.EnableTopTalkersMetrics)
 	require.Equal(t, "yaml_test_address", cfg.Exporter.ServerAddress)
 	require.Equal(t, 1010, cfg.Exporter.ServerPort)
 	require.Equal(t, 55555, cfg.Exporter.RequestTimeout)
 	require.Equal(t, "/test_conf_path", cfg.Exporter.TelemetryPath)
+	require.Equal(t, []int{5, 300}, cfg.Exporter.RateWindows)
 }
@@ -0,0 +1,152 @@
+package ctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/admin"
+)
+
+// base url of requests, host is ignored by unix socket transport
+const apiURL = "http://storm-control"
+
+var ErrDaemonUnavailable = errors.New("daemon is not running")
+
+// client of daemon admin API
+type client struct {
+	socketPath string
+	http       http.Client
+}
+
+func newClient(socketPath string, timeout time.Duration) *client {
+	return &client{
+		socketPath: socketPath,
+		http: http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// available checks that daemon listens admin socket
+func (c *client) available() bool {
+	conn, err := net.Dial("unix", c.socketPath)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+
+	return true
+}
+
+func (c *client) do(method, path string, body, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, apiURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return fmt.Errorf("%w: %s", ErrDaemonUnavailable, err.Error())
+		}
+
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errResp admin.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+			return fmt.Errorf("request failed with status %s", resp.Status)
+		}
+
+		return errors.New(errResp.Error)
+	}
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func netDevPath(name, action string) string {
+	path := "/v1/interfaces/" + url.PathEscape(name)
+	if action != "" {
+		path += "/" + action
+	}
+
+	return path
+}
+
+func (c *client) Status() (statusOutput, error) {
+	var status admin.Status
+	if err := c.do(http.MethodGet, "/v1/status", nil, &status); err != nil {
+		return statusOutput{}, err
+	}
+
+	return statusOutput{Daemon: daemonRunning, Source: c.socketPath, Status: status}, nil
+}
+
+func (c *client) Interfaces() ([]admin.NetDevInfo, error) {
+	var result []admin.NetDevInfo
+
+	return result, c.do(http.MethodGet, "/v1/interfaces", nil, &result)
+}
+
+func (c *client) Interface(name string) (admin.NetDevInfo, error) {
+	var result admin.NetDevInfo
+
+	return result, c.do(http.MethodGet, netDevPath(name, ""), nil, &result)
+}
+
+func (c *client) Block(name string, req admin.BlockRequest) (admin.NetDevInfo, error) {
+	var result admin.NetDevInfo
+
+	return result, c.do(http.MethodPost, netDevPath(name, "block"), req, &result)
+}
+
+func (c *client) Unblock(name string, req admin.BlockRequest) (admin.NetDevInfo, error) {
+	var result admin.NetDevInfo
+
+	return result, c.do(http.MethodPost, netDevPath(name, "unblock"), req, &result)
+}
+
+func (c *client) Exempt(name string, req admin.ExemptRequest) (admin.NetDevInfo, error) {
+	var result admin.NetDevInfo
+
+	return result, c.do(http.MethodPost, netDevPath(name, "exempt"), req, &result)
+}
+
+func (c *client) Attach(name string) (admin.NetDevInfo, error) {
+	var result admin.NetDevInfo
+
+	return result, c.do(http.MethodPost, netDevPath(name, "attach"), nil, &result)
+}
+
+func (c *client) Detach(name string) error {
+	return c.do(http.MethodPost, netDevPath(name, "detach"), nil, nil)
+}
+
+func (c *client) Reload() error {
+	return c.do(http.MethodPost, "/v1/reload", nil, nil)
+}
@@ -0,0 +1,223 @@
+package ctl
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/admin"
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+)
+
+const usage = `Usage: stormctl [options] <command> [arguments]
+
+Commands:
+  status                                          Show daemon status
+  list                                            List watched interfaces
+  show <interface>                                Show interface counters and drop state
+  top [-interval I] [-n N] [-limit L] [-all]      Show per second traffic rates, noisiest first
+  block <interface> [-type T] [-duration D]       Block traffic, without duration until unblock
+  unblock <interface> [-type T] [-duration D]     Unblock traffic and suppress automatic block for duration
+  exempt <interface> [-type T] [-remove]          Exclude traffic from blocking
+  attach <interface>                              Attach program to interface
+  detach <interface>                              Detach program from interface
+  reload                                          Reload daemon config
+
+Traffic type T is one of broadcast, ipv4_multicast, ipv6_multicast, other_multicast,
+arp, dhcpv4, ipv6_nd, mld, ipv6_ra, unknown_unicast or all (default).
+If daemon is not running, status, list, show, top, block and unblock work with pinned maps directly.
+
+Options:
+`
+
+var commands = []string{"status", "list", "show", "top", "block", "unblock", "exempt", "attach", "detach", "reload"}
+
+// backend executes commands by daemon admin API or directly with pinned maps
+type backend interface {
+	Status() (statusOutput, error)
+	Interfaces() ([]admin.NetDevInfo, error)
+	Interface(name string) (admin.NetDevInfo, error)
+	Block(name string, req admin.BlockRequest) (admin.NetDevInfo, error)
+	Unblock(name string, req admin.BlockRequest) (admin.NetDevInfo, error)
+	Exempt(name string, req admin.ExemptRequest) (admin.NetDevInfo, error)
+	Attach(name string) (admin.NetDevInfo, error)
+	Detach(name string) error
+	Reload() error
+}
+
+var openPinned = func(pinPath string) (pinnedMaps, func(), error) {
+	maps, err := ebpfloader.OpenPinned(pinPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return maps, maps.Close, nil
+}
+
+type options struct {
+	configPath string
+	socketPath string
+	pinPath    string
+	json       bool
+	timeout    time.Duration
+}
+
+// selectBackend returns daemon client if daemon is running, otherwise pinned maps are used
+func selectBackend(opts options, stderr io.Writer) (backend, func(), error) {
+	cfg, err := config.ReadConfig(opts.configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read config: %w", err)
+	}
+	socketPath, pinPath := cfg.Admin.SocketPath, cfg.EBPF.PinPath
+	if opts.socketPath != "" {
+		socketPath = opts.socketPath
+	}
+	if opts.pinPath != "" {
+		pinPath = opts.pinPath
+	}
+	daemon := newClient(socketPath, opts.timeout)
+	if daemon.available() {
+		return daemon, func() {}, nil
+	}
+	maps, closeMaps, err := openPinned(pinPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w (%s) and pinned maps are not available: %s", ErrDaemonUnavailable, socketPath, err.Error())
+	}
+	fmt.Fprintf(stderr, "Daemon is not running, pinned maps %s are used\n", pinPath)
+
+	return &offline{pinPath: pinPath, maps: maps}, closeMaps, nil
+}
+
+// parseNetDevArgs parses interface name and command flags, flags can be specified before or after name
+func parseNetDevArgs(flags *flag.FlagSet, args []string) (string, error) {
+	var name string
+	if len(args) != 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = flags.Arg(0)
+	} else if flags.NArg() != 0 {
+		return "", fmt.Errorf("unexpected arguments %s", strings.Join(flags.Args(), " "))
+	}
+	if name == "" {
+		return "", errors.New("interface name is required")
+	}
+
+	return name, nil
+}
+
+func runCommand(command string, args []string, back backend, out *printer) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	trafType := flags.String("type", allTrafficTypes, "traffic type")
+	duration := flags.String("duration", "", "duration, e.g. 10m")
+	remove := flags.Bool("remove", false, "remove exempt")
+
+	switch command {
+	case "status":
+		status, err := back.Status()
+		if err != nil {
+			return err
+		}
+
+		return out.status(status)
+	case "list":
+		infos, err := back.Interfaces()
+		if err != nil {
+			return err
+		}
+
+		return out.list(infos)
+	case "top":
+		return runTop(args, back, out)
+	case "reload":
+		if err := back.Reload(); err != nil {
+			return err
+		}
+
+		return out.message("Config reloaded")
+	}
+
+	name, err := parseNetDevArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	var info admin.NetDevInfo
+	switch command {
+	case "show":
+		info, err = back.Interface(name)
+	case "block":
+		info, err = back.Block(name, admin.BlockRequest{TrafficType: *trafType, Duration: *duration})
+	case "unblock":
+		info, err = back.Unblock(name, admin.BlockRequest{TrafficType: *trafType, Duration: *duration})
+	case "exempt":
+		info, err = back.Exempt(name, admin.ExemptRequest{TrafficType: *trafType, Exempt: !*remove})
+	case "attach":
+		info, err = back.Attach(name)
+	case "detach":
+		if err := back.Detach(name); err != nil {
+			return err
+		}
+
+		return out.message(fmt.Sprintf("Interface %s detached", name))
+	default:
+		return fmt.Errorf("unknown command %s", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	return out.show(info)
+}
+
+// Run executes stormctl command and returns exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	var opts options
+	flags := flag.NewFlagSet("stormctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&opts.configPath, "config", "", "Path to daemon config file, admin socket and pin path are read from it")
+	flags.StringVar(&opts.socketPath, "socket", "", "Admin API unix socket path")
+	flags.StringVar(&opts.pinPath, "pin-path", "", "bpffs directory with pinned maps")
+	flags.BoolVar(&opts.json, "json", false, "JSON output")
+	flags.DurationVar(&opts.timeout, "timeout", 10*time.Second, "Request timeout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return 2
+	}
+	if !slices.Contains(commands, flags.Arg(0)) {
+		fmt.Fprintf(stderr, "Error: unknown command %s\n", flags.Arg(0))
+		flags.Usage()
+
+		return 2
+	}
+	back, closeBackend, err := selectBackend(opts, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err.Error())
+
+		return 1
+	}
+	defer closeBackend()
+	if err := runCommand(flags.Arg(0), flags.Args()[1:], back, &printer{out: stdout, json: opts.json, terminal: isTerminal(stdout)}); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err.Error())
+
+		return 1
+	}
+
+	return 0
+}
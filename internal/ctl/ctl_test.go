@@ -0,0 +1,225 @@
+package ctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/admin"
+	adminmocks "github.com/mythvcode/storm-control/internal/admin/mocks"
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ctl/mocks"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
+	"github.com/mythvcode/storm-control/internal/watcher"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestStatistic() ebpfloader.Statistic {
+	return ebpfloader.Statistic{
+		CounterStat: ebpfloader.CounterStat{
+			5: {Broadcast: ebpfloader.TrafInfo{Passed: 10, Dropped: 20, PassedBytes: 640, DroppedBytes: 1280}},
+			7: {IPv4MCast: ebpfloader.TrafInfo{Passed: 30}},
+		},
+		DropConf: ebpfloader.DropConf{
+			5: {Broadcast: ebpfloader.ActionDrop},
+			7: {IPv6MCast: ebpfloader.ActionRateLimit, IPv6MCastRate: ebpfloader.RateLimit{Rate: 100}},
+		},
+		AttachModes: ebpfloader.AttachModes{5: ebpfloader.AttachModeNative, 7: ebpfloader.AttachModeGeneric},
+	}
+}
+
+// startTestDaemon starts admin API server on temporary unix socket
+func startTestDaemon(t *testing.T) (string, *adminmocks.MockStatsLoader, *adminmocks.MockController) {
+	t.Helper()
+	statsMock := adminmocks.NewMockStatsLoader(t)
+	controllerMock := adminmocks.NewMockController(t)
+	socketPath := filepath.Join(t.TempDir(), "admin.sock")
+	server := admin.New(
+		config.AdminConfig{SocketPath: socketPath, RequestTimeout: 1},
+		statsMock,
+		controllerMock,
+		func() error { return errors.New("invalid config") },
+	)
+	go server.Start() //nolint:errcheck
+	t.Cleanup(server.Stop)
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("unix", socketPath)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	return socketPath, statsMock, controllerMock
+}
+
+func runCtl(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestParseNetDevArgs(t *testing.T) {
+	tCases := []struct {
+		args     []string
+		name     string
+		trafType string
+		err      bool
+	}{
+		{args: []string{"tap1"}, name: "tap1", trafType: "all"},
+		{args: []string{"tap1", "-type", "broadcast"}, name: "tap1", trafType: "broadcast"},
+		{args: []string{"-type", "broadcast", "tap1"}, name: "tap1", trafType: "broadcast"},
+		{args: []string{}, err: true},
+		{args: []string{"tap1", "tap2"}, err: true},
+		{args: []string{"tap1", "-unknown"}, err: true},
+	}
+	for _, tCase := range tCases {
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(&bytes.Buffer{})
+		trafType := flags.String("type", allTrafficTypes, "")
+		name, err := parseNetDevArgs(flags, tCase.args)
+		if tCase.err {
+			require.Error(t, err, tCase.args)
+
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tCase.name, name)
+		require.Equal(t, tCase.trafType, *trafType)
+	}
+}
+
+func TestRunDaemon(t *testing.T) {
+	socketPath, statsMock, controllerMock := startTestDaemon(t)
+	statsMock.EXPECT().GetStatistic().Return(makeTestStatistic(), nil)
+	controllerMock.EXPECT().GetNetDevStates().Return([]watcher.NetDevState{
+		{Index: 7, Name: "tap7", Policy: "uplink", BlockMode: "rate_limit"},
+		{Index: 5, Name: "tap5", Policy: "default", BlockMode: "drop", DryRun: true, Overrides: map[string]watcher.OverrideState{
+			"broadcast": {Action: watcher.ManualBlock},
+		}, TopTalkers: []events.Talker{{MAC: "fa:16:3e:00:00:01", Rate: 4800, RateBytes: 307200}}},
+	})
+
+	code, stdout, stderr := runCtl(t, "-socket", socketPath, "list")
+	require.Equal(t, 0, code, stderr)
+	require.Equal(t, `INDEX  NAME  MODE     POLICY   BLOCKED    RATE_LIMITED    OVERRIDES
+5      tap5  native   default  broadcast  -               broadcast=block
+7      tap7  generic  uplink   -          ipv6_multicast  -
+`, stdout)
+
+	code, stdout, stderr = runCtl(t, "-socket", socketPath, "show", "tap5")
+	require.Equal(t, 0, code, stderr)
+	require.Contains(t, stdout, "Interface:    tap5 (5)")
+	require.Contains(t, stdout, "Block mode:   drop (dry run)")
+	require.Contains(t, stdout, "broadcast        10      20       640           1280           drop")
+	require.Contains(t, stdout, "fa:16:3e:00:00:01  4800  307200")
+
+	controllerMock.EXPECT().Block("tap5", "broadcast", 10*time.Minute).Return(nil)
+	code, stdout, stderr = runCtl(t, "-socket", socketPath, "-json", "block", "tap5", "-type", "broadcast", "-duration", "10m")
+	require.Equal(t, 0, code, stderr)
+	var info admin.NetDevInfo
+	require.NoError(t, json.Unmarshal([]byte(stdout), &info))
+	require.Equal(t, "tap5", info.Name)
+
+	controllerMock.EXPECT().Detach("tap7").Return(nil)
+	code, stdout, _ = runCtl(t, "-socket", socketPath, "detach", "tap7")
+	require.Equal(t, 0, code)
+	require.Equal(t, "Interface tap7 detached\n", stdout)
+
+	code, _, stderr = runCtl(t, "-socket", socketPath, "reload")
+	require.Equal(t, 1, code)
+	require.Equal(t, "Error: invalid config\n", stderr)
+
+	controllerMock.EXPECT().Exempt("tap1", "all", false).Return(watcher.ErrNetDevNotFound)
+	code, _, stderr = runCtl(t, "-socket", socketPath, "exempt", "-remove", "tap1")
+	require.Equal(t, 1, code)
+	require.Equal(t, "Error: interface is not watched\n", stderr)
+}
+
+func TestRunUsage(t *testing.T) {
+	code, _, stderr := runCtl(t)
+	require.Equal(t, 2, code)
+	require.Contains(t, stderr, "Usage: stormctl")
+	code, _, stderr = runCtl(t, "unknown")
+	require.Equal(t, 2, code)
+	require.Contains(t, stderr, "unknown command unknown")
+}
+
+func setOfflineTest(t *testing.T) *mocks.MockpinnedMaps {
+	t.Helper()
+	mapsMock := mocks.NewMockpinnedMaps(t)
+	openPinned = func(string) (pinnedMaps, func(), error) {
+		return mapsMock, func() {}, nil
+	}
+	interfaceByIndex = func(index int) (*net.Interface, error) {
+		if index == 5 {
+			return &net.Interface{Index: 5, Name: "tap5"}, nil
+		}
+
+		return nil, errors.New("no such network interface")
+	}
+	interfaceByName = func(name string) (*net.Interface, error) {
+		if name == "tap5" {
+			return &net.Interface{Index: 5, Name: "tap5"}, nil
+		}
+
+		return nil, errors.New("no such network interface")
+	}
+	t.Cleanup(func() {
+		openPinned = func(pinPath string) (pinnedMaps, func(), error) {
+			maps, err := ebpfloader.OpenPinned(pinPath)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return maps, maps.Close, nil
+		}
+		interfaceByIndex = net.InterfaceByIndex
+		interfaceByName = net.InterfaceByName
+	})
+
+	return mapsMock
+}
+
+func TestRunOffline(t *testing.T) {
+	mapsMock := setOfflineTest(t)
+	socketPath := filepath.Join(t.TempDir(), "admin.sock")
+	mapsMock.EXPECT().GetStatistic().Return(makeTestStatistic(), nil)
+
+	code, stdout, stderr := runCtl(t, "-socket", socketPath, "-pin-path", "/test/pin", "status")
+	require.Equal(t, 0, code, stderr)
+	require.Equal(t, "Daemon is not running, pinned maps /test/pin are used\n", stderr)
+	require.Equal(t, `Daemon:              not running
+Source:              /test/pin
+Interfaces:          2
+Blocked interfaces:  1
+`, stdout)
+
+	code, stdout, _ = runCtl(t, "-socket", socketPath, "list")
+	require.Equal(t, 0, code)
+	require.Contains(t, stdout, "7      -     generic  -       -          ipv6_multicast  -")
+
+	mapsMock.EXPECT().GetDevDropCfg(5).Return(ebpfloader.DropPKT{Broadcast: ebpfloader.ActionDrop}, nil)
+	mapsMock.EXPECT().UpdateDevDropCfg(5, ebpfloader.DropPKT{}).Return(nil)
+	code, _, stderr = runCtl(t, "-socket", socketPath, "unblock", "tap5")
+	require.Equal(t, 0, code, stderr)
+
+	code, _, stderr = runCtl(t, "-socket", socketPath, "block", "tap5", "-duration", "1m")
+	require.Equal(t, 1, code)
+	require.Contains(t, stderr, "duration: command requires running daemon")
+	code, _, stderr = runCtl(t, "-socket", socketPath, "attach", "tap5")
+	require.Equal(t, 1, code)
+	require.Contains(t, stderr, "command requires running daemon")
+	code, _, stderr = runCtl(t, "-socket", socketPath, "show", "tap7")
+	require.Equal(t, 1, code)
+	require.Contains(t, stderr, "no such network interface")
+}
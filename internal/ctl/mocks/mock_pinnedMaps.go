@@ -0,0 +1,193 @@
+// Code generated by mockery v2.52.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	ebpfloader "github.com/mythvcode/storm-control/internal/ebpfloader"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockpinnedMaps is an autogenerated mock type for the pinnedMaps type
+type MockpinnedMaps struct {
+	mock.Mock
+}
+
+type MockpinnedMaps_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockpinnedMaps) EXPECT() *MockpinnedMaps_Expecter {
+	return &MockpinnedMaps_Expecter{mock: &_m.Mock}
+}
+
+// GetDevDropCfg provides a mock function with given fields: devIndex
+func (_m *MockpinnedMaps) GetDevDropCfg(devIndex int) (ebpfloader.DropPKT, error) {
+	ret := _m.Called(devIndex)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDevDropCfg")
+	}
+
+	var r0 ebpfloader.DropPKT
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (ebpfloader.DropPKT, error)); ok {
+		return rf(devIndex)
+	}
+	if rf, ok := ret.Get(0).(func(int) ebpfloader.DropPKT); ok {
+		r0 = rf(devIndex)
+	} else {
+		r0 = ret.Get(0).(ebpfloader.DropPKT)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(devIndex)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockpinnedMaps_GetDevDropCfg_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDevDropCfg'
+type MockpinnedMaps_GetDevDropCfg_Call struct {
+	*mock.Call
+}
+
+// GetDevDropCfg is a helper method to define mock.On call
+//   - devIndex int
+func (_e *MockpinnedMaps_Expecter) GetDevDropCfg(devIndex interface{}) *MockpinnedMaps_GetDevDropCfg_Call {
+	return &MockpinnedMaps_GetDevDropCfg_Call{Call: _e.mock.On("GetDevDropCfg", devIndex)}
+}
+
+func (_c *MockpinnedMaps_GetDevDropCfg_Call) Run(run func(devIndex int)) *MockpinnedMaps_GetDevDropCfg_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *MockpinnedMaps_GetDevDropCfg_Call) Return(_a0 ebpfloader.DropPKT, _a1 error) *MockpinnedMaps_GetDevDropCfg_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockpinnedMaps_GetDevDropCfg_Call) RunAndReturn(run func(int) (ebpfloader.DropPKT, error)) *MockpinnedMaps_GetDevDropCfg_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetStatistic provides a mock function with no fields
+func (_m *MockpinnedMaps) GetStatistic() (ebpfloader.Statistic, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStatistic")
+	}
+
+	var r0 ebpfloader.Statistic
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (ebpfloader.Statistic, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() ebpfloader.Statistic); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(ebpfloader.Statistic)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockpinnedMaps_GetStatistic_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetStatistic'
+type MockpinnedMaps_GetStatistic_Call struct {
+	*mock.Call
+}
+
+// GetStatistic is a helper method to define mock.On call
+func (_e *MockpinnedMaps_Expecter) GetStatistic() *MockpinnedMaps_GetStatistic_Call {
+	return &MockpinnedMaps_GetStatistic_Call{Call: _e.mock.On("GetStatistic")}
+}
+
+func (_c *MockpinnedMaps_GetStatistic_Call) Run(run func()) *MockpinnedMaps_GetStatistic_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockpinnedMaps_GetStatistic_Call) Return(_a0 ebpfloader.Statistic, _a1 error) *MockpinnedMaps_GetStatistic_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockpinnedMaps_GetStatistic_Call) RunAndReturn(run func() (ebpfloader.Statistic, error)) *MockpinnedMaps_GetStatistic_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateDevDropCfg provides a mock function with given fields: devIndex, cfg
+func (_m *MockpinnedMaps) UpdateDevDropCfg(devIndex int, cfg ebpfloader.DropPKT) error {
+	ret := _m.Called(devIndex, cfg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDevDropCfg")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, ebpfloader.DropPKT) error); ok {
+		r0 = rf(devIndex, cfg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockpinnedMaps_UpdateDevDropCfg_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateDevDropCfg'
+type MockpinnedMaps_UpdateDevDropCfg_Call struct {
+	*mock.Call
+}
+
+// UpdateDevDropCfg is a helper method to define mock.On call
+//   - devIndex int
+//   - cfg ebpfloader.DropPKT
+func (_e *MockpinnedMaps_Expecter) UpdateDevDropCfg(devIndex interface{}, cfg interface{}) *MockpinnedMaps_UpdateDevDropCfg_Call {
+	return &MockpinnedMaps_UpdateDevDropCfg_Call{Call: _e.mock.On("UpdateDevDropCfg", devIndex, cfg)}
+}
+
+func (_c *MockpinnedMaps_UpdateDevDropCfg_Call) Run(run func(devIndex int, cfg ebpfloader.DropPKT)) *MockpinnedMaps_UpdateDevDropCfg_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(ebpfloader.DropPKT))
+	})
+	return _c
+}
+
+func (_c *MockpinnedMaps_UpdateDevDropCfg_Call) Return(_a0 error) *MockpinnedMaps_UpdateDevDropCfg_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockpinnedMaps_UpdateDevDropCfg_Call) RunAndReturn(run func(int, ebpfloader.DropPKT) error) *MockpinnedMaps_UpdateDevDropCfg_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockpinnedMaps creates a new instance of MockpinnedMaps. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockpinnedMaps(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockpinnedMaps {
+	mock := &MockpinnedMaps{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,141 @@
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/mythvcode/storm-control/internal/admin"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+)
+
+var ErrDaemonRequired = errors.New("command requires running daemon")
+
+var (
+	interfaceByName  = net.InterfaceByName
+	interfaceByIndex = net.InterfaceByIndex
+)
+
+type pinnedMaps interface {
+	GetStatistic() (ebpfloader.Statistic, error)
+	GetDevDropCfg(devIndex int) (ebpfloader.DropPKT, error)
+	UpdateDevDropCfg(devIndex int, cfg ebpfloader.DropPKT) error
+}
+
+// offline works with maps pinned by daemon when daemon is not running.
+// Watcher state is not available and traffic is blocked until it is unblocked manually.
+type offline struct {
+	pinPath string
+	maps    pinnedMaps
+}
+
+func (o *offline) Status() (statusOutput, error) {
+	infos, err := o.Interfaces()
+	if err != nil {
+		return statusOutput{}, err
+	}
+	result := statusOutput{Daemon: daemonNotRunning, Source: o.pinPath}
+	result.Interfaces = len(infos)
+	for _, info := range infos {
+		if admin.IsBlocked(info.DropConfig) {
+			result.BlockedInterfaces++
+		}
+	}
+
+	return result, nil
+}
+
+func (o *offline) Interfaces() ([]admin.NetDevInfo, error) {
+	stats, err := o.maps.GetStatistic()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]admin.NetDevInfo, 0, len(stats.CounterStat))
+	for index, counters := range stats.CounterStat {
+		info := admin.NetDevInfo{
+			Index:      int(index),
+			AttachMode: stats.AttachModes[index],
+			Counters:   counters,
+			DropConfig: stats.DropConf[index],
+			VLANs:      admin.VLANInfos(stats, index),
+		}
+		// interface can be already removed
+		if netDev, err := interfaceByIndex(info.Index); err == nil {
+			info.Name = netDev.Name
+		}
+		result = append(result, info)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Index < result[j].Index })
+
+	return result, nil
+}
+
+func (o *offline) Interface(name string) (admin.NetDevInfo, error) {
+	netDev, err := interfaceByName(name)
+	if err != nil {
+		return admin.NetDevInfo{}, err
+	}
+	infos, err := o.Interfaces()
+	if err != nil {
+		return admin.NetDevInfo{}, err
+	}
+	for _, info := range infos {
+		if info.Index == netDev.Index {
+			return info, nil
+		}
+	}
+
+	return admin.NetDevInfo{}, fmt.Errorf("program is not attached to interface %s", name)
+}
+
+// setAction writes drop action of traffic types directly to pinned drop map
+func (o *offline) setAction(name string, req admin.BlockRequest, action uint8) (admin.NetDevInfo, error) {
+	if req.Duration != "" {
+		return admin.NetDevInfo{}, fmt.Errorf("duration: %w", ErrDaemonRequired)
+	}
+	trafTypes, err := parseTrafficTypes(req.TrafficType)
+	if err != nil {
+		return admin.NetDevInfo{}, err
+	}
+	info, err := o.Interface(name)
+	if err != nil {
+		return admin.NetDevInfo{}, err
+	}
+	dropCfg, err := o.maps.GetDevDropCfg(info.Index)
+	if err != nil {
+		return admin.NetDevInfo{}, err
+	}
+	for _, trafType := range trafTypes {
+		*trafType.Action(&dropCfg) = action
+	}
+	if err := o.maps.UpdateDevDropCfg(info.Index, dropCfg); err != nil {
+		return admin.NetDevInfo{}, err
+	}
+
+	return o.Interface(name)
+}
+
+func (o *offline) Block(name string, req admin.BlockRequest) (admin.NetDevInfo, error) {
+	return o.setAction(name, req, ebpfloader.ActionDrop)
+}
+
+func (o *offline) Unblock(name string, req admin.BlockRequest) (admin.NetDevInfo, error) {
+	return o.setAction(name, req, ebpfloader.ActionPass)
+}
+
+func (o *offline) Exempt(string, admin.ExemptRequest) (admin.NetDevInfo, error) {
+	return admin.NetDevInfo{}, ErrDaemonRequired
+}
+
+func (o *offline) Attach(string) (admin.NetDevInfo, error) {
+	return admin.NetDevInfo{}, ErrDaemonRequired
+}
+
+func (o *offline) Detach(string) error {
+	return ErrDaemonRequired
+}
+
+func (o *offline) Reload() error {
+	return ErrDaemonRequired
+}
@@ -0,0 +1,201 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/admin"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+)
+
+const (
+	daemonRunning    = "running"
+	daemonNotRunning = "not running"
+	emptyValue       = "-"
+)
+
+// statusOutput is daemon status, source is admin socket or pin path for not running daemon
+type statusOutput struct {
+	Daemon string `json:"daemon"`
+	Source string `json:"source"`
+	admin.Status
+}
+
+// message is result of commands without interface info in response
+type message struct {
+	Message string `json:"message"`
+}
+
+// printer writes command results as tables or JSON, terminal output is refreshed and colored by top
+type printer struct {
+	out      io.Writer
+	json     bool
+	terminal bool
+}
+
+func (p *printer) printJSON(value any) error {
+	encoder := json.NewEncoder(p.out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+func joinOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return emptyValue
+	}
+
+	return strings.Join(values, ",")
+}
+
+func orEmpty(value string) string {
+	if value == "" {
+		return emptyValue
+	}
+
+	return value
+}
+
+func formatOverrides(info *admin.NetDevInfo) string {
+	if info.State == nil {
+		return emptyValue
+	}
+	result := make([]string, 0, len(info.State.Overrides))
+	for trafType, override := range info.State.Overrides {
+		value := trafType + "=" + override.Action
+		if override.Until != nil {
+			value += "(until " + override.Until.Local().Format(time.DateTime) + ")"
+		}
+		result = append(result, value)
+	}
+	sort.Strings(result)
+
+	return joinOrEmpty(result)
+}
+
+func (p *printer) status(status statusOutput) error {
+	if p.json {
+		return p.printJSON(status)
+	}
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "Daemon:\t%s\n", status.Daemon)
+	fmt.Fprintf(writer, "Source:\t%s\n", status.Source)
+	if !status.StartedAt.IsZero() {
+		fmt.Fprintf(writer, "Started:\t%s (uptime %s)\n",
+			status.StartedAt.Local().Format(time.DateTime), time.Since(status.StartedAt).Truncate(time.Second))
+	}
+	fmt.Fprintf(writer, "Interfaces:\t%d\n", status.Interfaces)
+	fmt.Fprintf(writer, "Blocked interfaces:\t%d\n", status.BlockedInterfaces)
+	var attached, detached []string
+	for name, attach := range status.AttachOverrides {
+		if attach {
+			attached = append(attached, name)
+		} else {
+			detached = append(detached, name)
+		}
+	}
+	sort.Strings(attached)
+	sort.Strings(detached)
+	if len(attached) != 0 {
+		fmt.Fprintf(writer, "Manually attached:\t%s\n", strings.Join(attached, ","))
+	}
+	if len(detached) != 0 {
+		fmt.Fprintf(writer, "Manually detached:\t%s\n", strings.Join(detached, ","))
+	}
+
+	return writer.Flush()
+}
+
+func (p *printer) list(infos []admin.NetDevInfo) error {
+	if p.json {
+		return p.printJSON(infos)
+	}
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "INDEX\tNAME\tMODE\tPOLICY\tBLOCKED\tRATE_LIMITED\tOVERRIDES")
+	for _, info := range infos {
+		policy := emptyValue
+		if info.State != nil {
+			policy = info.State.Policy
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			info.Index,
+			orEmpty(info.Name),
+			orEmpty(info.AttachMode),
+			policy,
+			joinOrEmpty(typesWithAction(info.DropConfig, ebpfloader.ActionDrop)),
+			joinOrEmpty(typesWithAction(info.DropConfig, ebpfloader.ActionRateLimit)),
+			formatOverrides(&info),
+		)
+	}
+
+	return writer.Flush()
+}
+
+func (p *printer) show(info admin.NetDevInfo) error {
+	if p.json {
+		return p.printJSON(info)
+	}
+	writer := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(writer, "Interface:\t%s (%d)\n", orEmpty(info.Name), info.Index)
+	fmt.Fprintf(writer, "Attach mode:\t%s\n", orEmpty(info.AttachMode))
+	if info.State != nil {
+		fmt.Fprintf(writer, "Policy:\t%s\n", info.State.Policy)
+		blockMode := info.State.BlockMode
+		if info.State.DryRun {
+			blockMode += " (dry run)"
+		}
+		fmt.Fprintf(writer, "Block mode:\t%s\n", blockMode)
+	}
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "TYPE\tPASSED\tDROPPED\tPASSED_BYTES\tDROPPED_BYTES\tACTION\tRATE\tBURST\tBACKOFF\tOVERRIDE")
+	for _, trafType := range ebpfloader.TrafficTypes {
+		counters := trafType.Counters(&info.Counters)
+		rate := trafType.Rate(&info.DropConfig)
+		backoff, override := emptyValue, emptyValue
+		if info.State != nil {
+			backoff = strconv.Itoa(info.State.BackoffLevels[trafType.Name])
+			if state, ok := info.State.Overrides[trafType.Name]; ok {
+				override = state.Action
+				if state.Until != nil {
+					override += " until " + state.Until.Local().Format(time.DateTime)
+				}
+			}
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%d\t%s\t%d\t%d\t%s\t%s\n",
+			trafType.Name,
+			counters.Passed,
+			counters.Dropped,
+			counters.PassedBytes,
+			counters.DroppedBytes,
+			actionName(*trafType.Action(&info.DropConfig)),
+			rate.Rate,
+			rate.Burst,
+			backoff,
+			override,
+		)
+	}
+	if info.State != nil && len(info.State.TopTalkers) != 0 {
+		fmt.Fprintln(writer)
+		fmt.Fprintln(writer, "TOP_TALKER\tRATE\tRATE_BYTES")
+		for _, talker := range info.State.TopTalkers {
+			fmt.Fprintf(writer, "%s\t%d\t%d\n", talker.MAC, talker.Rate, talker.RateBytes)
+		}
+	}
+
+	return writer.Flush()
+}
+
+func (p *printer) message(text string) error {
+	if p.json {
+		return p.printJSON(message{Message: text})
+	}
+	_, err := fmt.Fprintln(p.out, text)
+
+	return err
+}
@@ -0,0 +1,224 @@
+package ctl
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/admin"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+)
+
+const (
+	clearScreen = "\033[H\033[2J"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+	colorReset  = "\033[0m"
+)
+
+// trafficRate is per second rate of traffic type on interface between two snapshots
+type trafficRate struct {
+	Index       int     `json:"index"`
+	Name        string  `json:"name"`
+	TrafficType string  `json:"traffic_type"`
+	PassedPPS   float64 `json:"passed_pps"`
+	DroppedPPS  float64 `json:"dropped_pps"`
+	PassedBPS   float64 `json:"passed_bytes_per_second"`
+	DroppedBPS  float64 `json:"dropped_bytes_per_second"`
+	Action      string  `json:"action"`
+}
+
+func (r *trafficRate) pps() float64 {
+	return r.PassedPPS + r.DroppedPPS
+}
+
+// snapshot is state of attached interfaces at time
+type snapshot struct {
+	time   time.Time
+	netDev map[int]admin.NetDevInfo
+}
+
+func takeSnapshot(back backend) (snapshot, error) {
+	infos, err := back.Interfaces()
+	if err != nil {
+		return snapshot{}, err
+	}
+	result := snapshot{time: time.Now(), netDev: make(map[int]admin.NetDevInfo, len(infos))}
+	for _, info := range infos {
+		result.netDev[info.Index] = info
+	}
+
+	return result, nil
+}
+
+// counterRate returns per second rate of counter, reset counter (reattached interface) is counted from zero
+func counterRate(cur, prev uint64, elapsed time.Duration) float64 {
+	if cur < prev {
+		prev = 0
+	}
+
+	return float64(cur-prev) / elapsed.Seconds()
+}
+
+// calcRates returns rates of interfaces present in both snapshots sorted by packets rate,
+// idle traffic types are skipped unless all is set
+func calcRates(prev, cur snapshot, all bool) []trafficRate {
+	elapsed := cur.time.Sub(prev.time)
+	if elapsed <= 0 {
+		return nil
+	}
+	var result []trafficRate
+	for index, curInfo := range cur.netDev {
+		prevInfo, ok := prev.netDev[index]
+		if !ok {
+			continue
+		}
+		for _, trafType := range ebpfloader.TrafficTypes {
+			curCounters := trafType.Counters(&curInfo.Counters)
+			prevCounters := trafType.Counters(&prevInfo.Counters)
+			rate := trafficRate{
+				Index:       index,
+				Name:        curInfo.Name,
+				TrafficType: trafType.Name,
+				PassedPPS:   counterRate(curCounters.Passed, prevCounters.Passed, elapsed),
+				DroppedPPS:  counterRate(curCounters.Dropped, prevCounters.Dropped, elapsed),
+				PassedBPS:   counterRate(curCounters.PassedBytes, prevCounters.PassedBytes, elapsed),
+				DroppedBPS:  counterRate(curCounters.DroppedBytes, prevCounters.DroppedBytes, elapsed),
+				Action:      actionName(*trafType.Action(&curInfo.DropConfig)),
+			}
+			if !all && rate.pps() == 0 {
+				continue
+			}
+			result = append(result, rate)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].pps() != result[j].pps() {
+			return result[i].pps() > result[j].pps()
+		}
+		if result[i].Index != result[j].Index {
+			return result[i].Index < result[j].Index
+		}
+
+		return result[i].TrafficType < result[j].TrafficType
+	})
+
+	return result
+}
+
+// formatBytesRate formats bytes per second rate with binary units
+func formatBytesRate(rate float64) string {
+	units := []string{"B/s", "KiB/s", "MiB/s", "GiB/s"}
+	unit := 0
+	for rate >= 1024 && unit < len(units)-1 {
+		rate /= 1024
+		unit++
+	}
+
+	return fmt.Sprintf("%.1f %s", rate, units[unit])
+}
+
+// isTerminal checks that output is character device, colors and screen refresh are used only for terminal
+func isTerminal(out io.Writer) bool {
+	file, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p *printer) top(rates []trafficRate, limit int) error {
+	if limit > 0 && len(rates) > limit {
+		rates = rates[:limit]
+	}
+	if p.json {
+		return p.printJSON(rates)
+	}
+	var table bytes.Buffer
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "INDEX\tNAME\tTYPE\tPPS\tPASSED_PPS\tDROPPED_PPS\tPASSED_BPS\tDROPPED_BPS\tACTION")
+	for _, rate := range rates {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%.0f\t%.0f\t%.0f\t%s\t%s\t%s\n",
+			rate.Index,
+			orEmpty(rate.Name),
+			rate.TrafficType,
+			rate.pps(),
+			rate.PassedPPS,
+			rate.DroppedPPS,
+			formatBytesRate(rate.PassedBPS),
+			formatBytesRate(rate.DroppedBPS),
+			rate.Action,
+		)
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if !p.terminal {
+		_, err := table.WriteTo(p.out)
+
+		return err
+	}
+	// rows are colored after alignment, escape sequences break tabwriter column width
+	lines := strings.SplitAfter(table.String(), "\n")
+	var screen strings.Builder
+	screen.WriteString(clearScreen)
+	fmt.Fprintf(&screen, "stormctl top - %s\n\n", time.Now().Format(time.DateTime))
+	screen.WriteString(lines[0])
+	for i, rate := range rates {
+		switch rate.Action {
+		case actionName(ebpfloader.ActionDrop):
+			screen.WriteString(colorRed + strings.TrimSuffix(lines[i+1], "\n") + colorReset + "\n")
+		case actionName(ebpfloader.ActionRateLimit):
+			screen.WriteString(colorYellow + strings.TrimSuffix(lines[i+1], "\n") + colorReset + "\n")
+		default:
+			screen.WriteString(lines[i+1])
+		}
+	}
+	_, err := io.WriteString(p.out, screen.String())
+
+	return err
+}
+
+// runTop prints rates of traffic every interval until iterations are done, 0 iterations means infinite loop
+func runTop(args []string, back backend, out *printer) error {
+	flags := flag.NewFlagSet("top", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	interval := flags.Duration("interval", time.Second, "refresh interval")
+	iterations := flags.Int("n", 0, "number of refreshes, 0 is infinite")
+	limit := flags.Int("limit", 20, "max number of rows, 0 is unlimited")
+	all := flags.Bool("all", false, "show idle traffic types")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	prev, err := takeSnapshot(back)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for iteration := 0; *iterations == 0 || iteration < *iterations; iteration++ {
+		<-ticker.C
+		cur, err := takeSnapshot(back)
+		if err != nil {
+			return err
+		}
+		if err := out.top(calcRates(prev, cur, *all), *limit); err != nil {
+			return err
+		}
+		prev = cur
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package ctl
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/admin"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalcRates(t *testing.T) {
+	now := time.Now()
+	prev := snapshot{time: now, netDev: map[int]admin.NetDevInfo{
+		5: {Index: 5, Name: "tap5", Counters: ebpfloader.PacketCounter{
+			Broadcast: ebpfloader.TrafInfo{Passed: 100, Dropped: 100, PassedBytes: 6400, DroppedBytes: 6400},
+			IPv4MCast: ebpfloader.TrafInfo{Passed: 10},
+		}},
+		7: {Index: 7, Name: "tap7", Counters: ebpfloader.PacketCounter{
+			IPv6MCast: ebpfloader.TrafInfo{Passed: 1000},
+		}},
+		9: {Index: 9, Name: "tap9"},
+	}}
+	cur := snapshot{time: now.Add(2 * time.Second), netDev: map[int]admin.NetDevInfo{
+		5: {
+			Index: 5,
+			Name:  "tap5",
+			Counters: ebpfloader.PacketCounter{
+				Broadcast: ebpfloader.TrafInfo{Passed: 100, Dropped: 2100, PassedBytes: 6400, DroppedBytes: 134400},
+				IPv4MCast: ebpfloader.TrafInfo{Passed: 10},
+			},
+			DropConfig: ebpfloader.DropPKT{Broadcast: ebpfloader.ActionDrop},
+		},
+		// counters are reset after reattach
+		7: {Index: 7, Name: "tap7", Counters: ebpfloader.PacketCounter{
+			IPv6MCast: ebpfloader.TrafInfo{Passed: 400},
+		}},
+		// new interface has no previous snapshot
+		11: {Index: 11, Name: "tap11", Counters: ebpfloader.PacketCounter{
+			Broadcast: ebpfloader.TrafInfo{Passed: 1000000},
+		}},
+	}}
+
+	require.Equal(t, []trafficRate{
+		{Index: 5, Name: "tap5", TrafficType: "broadcast", DroppedPPS: 1000, DroppedBPS: 64000, Action: "drop"},
+		{Index: 7, Name: "tap7", TrafficType: "ipv6_multicast", PassedPPS: 200, Action: "pass"},
+	}, calcRates(prev, cur, false))
+
+	rates := calcRates(prev, cur, true)
+	require.Len(t, rates, 2*len(ebpfloader.TrafficTypes))
+	require.Equal(t, "arp", rates[2].TrafficType)
+	require.Equal(t, 5, rates[2].Index)
+
+	require.Empty(t, calcRates(cur, cur, true))
+}
+
+func TestFormatBytesRate(t *testing.T) {
+	require.Equal(t, "0.0 B/s", formatBytesRate(0))
+	require.Equal(t, "512.0 B/s", formatBytesRate(512))
+	require.Equal(t, "1.5 KiB/s", formatBytesRate(1536))
+	require.Equal(t, "2.0 GiB/s", formatBytesRate(2*1024*1024*1024))
+	require.Equal(t, "2048.0 GiB/s", formatBytesRate(2*1024*1024*1024*1024))
+}
+
+func TestPrintTop(t *testing.T) {
+	rates := []trafficRate{
+		{Index: 5, Name: "tap5", TrafficType: "broadcast", DroppedPPS: 1000, DroppedBPS: 64000, Action: "drop"},
+		{Index: 7, Name: "tap7", TrafficType: "ipv6_multicast", PassedPPS: 200, Action: "pass"},
+	}
+	var out bytes.Buffer
+	require.NoError(t, (&printer{out: &out}).top(rates, 0))
+	require.Equal(t, `INDEX  NAME  TYPE            PPS   PASSED_PPS  DROPPED_PPS  PASSED_BPS  DROPPED_BPS  ACTION
+5      tap5  broadcast       1000  0           1000         0.0 B/s     62.5 KiB/s   drop
+7      tap7  ipv6_multicast  200   200         0            0.0 B/s     0.0 B/s      pass
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, (&printer{out: &out, terminal: true}).top(rates, 1))
+	require.True(t, strings.HasPrefix(out.String(), clearScreen))
+	require.Contains(t, out.String(), colorRed+"5      tap5  broadcast  1000  0           1000         0.0 B/s     62.5 KiB/s   drop"+colorReset)
+	require.NotContains(t, out.String(), "tap7")
+}
+
+func TestRunTop(t *testing.T) {
+	mapsMock := setOfflineTest(t)
+	socketPath := filepath.Join(t.TempDir(), "admin.sock")
+	stat := makeTestStatistic()
+	mapsMock.EXPECT().GetStatistic().Return(stat, nil).Once()
+	next := makeTestStatistic()
+	next.CounterStat[5] = ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: 10, Dropped: 1020}}
+	mapsMock.EXPECT().GetStatistic().Return(next, nil).Once()
+
+	code, stdout, stderr := runCtl(t, "-socket", socketPath, "-json", "top", "-interval", "10ms", "-n", "1")
+	require.Equal(t, 0, code, stderr)
+	require.Contains(t, stdout, `"traffic_type": "broadcast"`)
+	require.Contains(t, stdout, `"action": "drop"`)
+	require.NotContains(t, stdout, "ipv4_multicast")
+
+	code, _, stderr = runCtl(t, "-socket", socketPath, "top", "-interval", "0s")
+	require.Equal(t, 1, code)
+	require.Contains(t, stderr, "interval must be positive")
+}
@@ -0,0 +1,48 @@
+package ctl
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+)
+
+const allTrafficTypes = "all"
+
+// parseTrafficTypes returns traffic types by name, empty name or "all" selects all types
+func parseTrafficTypes(name string) ([]ebpfloader.TrafficType, error) {
+	if name == "" || name == allTrafficTypes {
+		return ebpfloader.TrafficTypes, nil
+	}
+	trafType, ok := ebpfloader.LookupTrafficType(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown traffic type %s", name)
+	}
+
+	return []ebpfloader.TrafficType{trafType}, nil
+}
+
+func actionName(action uint8) string {
+	switch action {
+	case ebpfloader.ActionPass:
+		return "pass"
+	case ebpfloader.ActionDrop:
+		return "drop"
+	case ebpfloader.ActionRateLimit:
+		return "rate_limit"
+	}
+
+	return strconv.Itoa(int(action))
+}
+
+// typesWithAction returns traffic types with specified drop action
+func typesWithAction(dropCfg ebpfloader.DropPKT, action uint8) []string {
+	var result []string
+	for _, trafType := range ebpfloader.TrafficTypes {
+		if *trafType.Action(&dropCfg) == action {
+			result = append(result, trafType.Name)
+		}
+	}
+
+	return result
+}
@@ -2,47 +2,220 @@ package ebpfloader
 
 import (
 	"bytes"
+	"errors"
+	"net"
+	"sync/atomic"
 
 	"github.com/cilium/ebpf"
 	"github.com/mythvcode/storm-control/ebpfxdp"
 )
 
 const (
-	ProgramName  = "storm_control"
-	StatsMapName = "intf_stats"
-	DropMapName  = "drop_intf"
+	ProgramName          = "storm_control"
+	StatsMapName         = "intf_stats"
+	DropMapName          = "drop_intf"
+	BucketsMapName       = "intf_buckets"
+	KnownMACsMapName     = "known_macs"
+	UnicastNetDevMapName = "unicast_intf"
+	VLANNetDevMapName    = "vlan_intf"
+	VLANStatsMapName     = "vlan_stats"
+	VLANDropMapName      = "vlan_drop"
+	VLANBucketsMapName   = "vlan_buckets"
+	TalkerNetDevMapName  = "talker_intf"
+	TalkersMapName       = "src_talkers"
+)
+
+// statsBatchSize is number of statistic map entries read by one batch lookup
+const statsBatchSize = 256
+
+var errBatchNotSupported = errors.New("batch lookup is not supported")
+
+// DropPKT actions
+const (
+	ActionPass      uint8 = 0
+	ActionDrop      uint8 = 1
+	ActionRateLimit uint8 = 2
 )
 
 type (
 	CounterStat map[uint32]PacketCounter
 	DropConf    map[uint32]DropPKT
+	AttachModes map[uint32]string
+	// counters and drop configs of VLANs of VLAN aware interfaces
+	VLANCounterStat map[VLANKey]PacketCounter
+	VLANDropConf    map[VLANKey]DropPKT
+	// broadcast and multicast counters by source address of interfaces with top talkers tracking
+	TalkerStat map[TalkerKey]TalkerCounter
 )
 
 type Statistic struct {
 	CounterStat
 	DropConf
+	AttachModes
+	VLANCounterStat
+	VLANDropConf
 }
+
+// VLANKey is key of VLAN maps, VLAN is id of outer tag, 0 for untagged frames
+type VLANKey struct {
+	Ifindex uint32
+	VLAN    uint16
+	_       uint16
+}
+
+// TalkerKey is key of top talkers map, Addr is source address of frames received by interface
+type TalkerKey struct {
+	Ifindex uint32
+	Addr    [6]uint8
+	_       [2]uint8
+}
+
+// TalkerCounter is broadcast and multicast frames received from source address, dropped frames are counted
+type TalkerCounter struct {
+	Packets uint64
+	Bytes   uint64
+}
+
 type TrafInfo struct {
-	Passed  uint64
-	Dropped uint64
+	Passed       uint64 `json:"passed"`
+	Dropped      uint64 `json:"dropped"`
+	PassedBytes  uint64 `json:"passed_bytes"`
+	DroppedBytes uint64 `json:"dropped_bytes"`
+}
+
+// Sub returns difference between counters and previous counters values
+func (t TrafInfo) Sub(prev TrafInfo) TrafInfo {
+	return TrafInfo{
+		Passed:       t.Passed - prev.Passed,
+		Dropped:      t.Dropped - prev.Dropped,
+		PassedBytes:  t.PassedBytes - prev.PassedBytes,
+		DroppedBytes: t.DroppedBytes - prev.DroppedBytes,
+	}
 }
 
+func (t *TrafInfo) add(value TrafInfo) {
+	t.Passed += value.Passed
+	t.Dropped += value.Dropped
+	t.PassedBytes += value.PassedBytes
+	t.DroppedBytes += value.DroppedBytes
+}
+
+// PacketCounter is traffic counters by type, ARP and DHCPv4 are not counted as broadcast,
+// IPv6 ND, MLD and RA are not counted as IPv6 multicast.
+// Unicast is counted only as UnknownUcast by interfaces with unicast check
 type PacketCounter struct {
-	Broadcast  TrafInfo
-	IPv4MCast  TrafInfo
-	IPv6MCast  TrafInfo
-	OtherMcast TrafInfo
+	Broadcast  TrafInfo `json:"broadcast"`
+	IPv4MCast  TrafInfo `json:"ipv4_multicast"`
+	IPv6MCast  TrafInfo `json:"ipv6_multicast"`
+	OtherMcast TrafInfo `json:"other_multicast"`
+	ARP        TrafInfo `json:"arp"`
+	DHCPv4     TrafInfo `json:"dhcpv4"`
+	IPv6ND     TrafInfo `json:"ipv6_nd"`
+	MLD        TrafInfo `json:"mld"`
+	IPv6RA     TrafInfo `json:"ipv6_ra"`
+	// frames with not expected source or unknown destination address
+	UnknownUcast TrafInfo `json:"unknown_unicast"`
+}
+
+// Sub returns difference between counters of all types of traffic and previous counters values
+func (p PacketCounter) Sub(prev PacketCounter) PacketCounter {
+	return PacketCounter{
+		Broadcast:    p.Broadcast.Sub(prev.Broadcast),
+		IPv4MCast:    p.IPv4MCast.Sub(prev.IPv4MCast),
+		IPv6MCast:    p.IPv6MCast.Sub(prev.IPv6MCast),
+		OtherMcast:   p.OtherMcast.Sub(prev.OtherMcast),
+		ARP:          p.ARP.Sub(prev.ARP),
+		DHCPv4:       p.DHCPv4.Sub(prev.DHCPv4),
+		IPv6ND:       p.IPv6ND.Sub(prev.IPv6ND),
+		MLD:          p.MLD.Sub(prev.MLD),
+		IPv6RA:       p.IPv6RA.Sub(prev.IPv6RA),
+		UnknownUcast: p.UnknownUcast.Sub(prev.UnknownUcast),
+	}
+}
+
+// RateLimit is token bucket parameters used with ActionRateLimit
+type RateLimit struct {
+	// packets per second
+	Rate uint64 `json:"rate"`
+	// bucket size in packets, if 0 equals to Rate
+	Burst uint64 `json:"burst"`
 }
 
 type DropPKT struct {
-	Broadcast uint8
-	IPv4MCast uint8
-	IPv6MCast uint8
-	Multicast uint8
+	Broadcast    uint8    `json:"broadcast"`
+	IPv4MCast    uint8    `json:"ipv4_multicast"`
+	IPv6MCast    uint8    `json:"ipv6_multicast"`
+	Multicast    uint8    `json:"other_multicast"`
+	ARP          uint8    `json:"arp"`
+	DHCPv4       uint8    `json:"dhcpv4"`
+	IPv6ND       uint8    `json:"ipv6_nd"`
+	MLD          uint8    `json:"mld"`
+	IPv6RA       uint8    `json:"ipv6_ra"`
+	UnknownUcast uint8    `json:"unknown_unicast"`
+	_            [6]uint8 `json:"-"`
+
+	BroadcastRate    RateLimit `json:"broadcast_rate"`
+	IPv4MCastRate    RateLimit `json:"ipv4_multicast_rate"`
+	IPv6MCastRate    RateLimit `json:"ipv6_multicast_rate"`
+	MulticastRate    RateLimit `json:"other_multicast_rate"`
+	ARPRate          RateLimit `json:"arp_rate"`
+	DHCPv4Rate       RateLimit `json:"dhcpv4_rate"`
+	IPv6NDRate       RateLimit `json:"ipv6_nd_rate"`
+	MLDRate          RateLimit `json:"mld_rate"`
+	IPv6RARate       RateLimit `json:"ipv6_ra_rate"`
+	UnknownUcastRate RateLimit `json:"unknown_unicast_rate"`
+}
+
+type tokenBucket struct {
+	Tokens     int64
+	LastRefill uint64
+}
+
+// token bucket state is managed by kernel program, user space only creates and deletes entries
+type tokenBuckets struct {
+	Broadcast    tokenBucket
+	IPv4MCast    tokenBucket
+	IPv6MCast    tokenBucket
+	Multicast    tokenBucket
+	ARP          tokenBucket
+	DHCPv4       tokenBucket
+	IPv6ND       tokenBucket
+	MLD          tokenBucket
+	IPv6RA       tokenBucket
+	UnknownUcast tokenBucket
+}
+
+// kinds of known MACs map entries
+const (
+	macSource      = 0
+	macDestination = 1
+)
+
+// macKey is key of known MACs map, Ifindex is index of interface for source address
+// and index of bridge for destination address
+type macKey struct {
+	Ifindex uint32
+	Addr    [6]uint8
+	Kind    uint8
+	_       uint8
+}
+
+// KnownMACs are addresses used by unknown unicast check
+type KnownMACs struct {
+	// bridge index by interface index, check is enabled only for interfaces with bridge and expected sources
+	Bridges map[int]int
+	// expected source addresses by interface index
+	Sources map[int][]net.HardwareAddr
+	// known destination addresses by bridge index
+	Destinations map[int][]net.HardwareAddr
 }
 
 type collection struct {
 	*ebpf.Collection
+	// per-CPU maps are iterated by keys if kernel does not support batch operations (before 5.6)
+	noBatch atomic.Bool
+	// drop map is updated by keys if kernel does not support batch operations
+	noBatchUpdate atomic.Bool
 }
 
 func cpuCount() int {
@@ -82,26 +255,115 @@ func (c *collection) getDropMap() *ebpf.Map {
 	return c.Collection.Maps[DropMapName]
 }
 
+func (c *collection) getBucketsMap() *ebpf.Map {
+	return c.Collection.Maps[BucketsMapName]
+}
+
+func (c *collection) getKnownMACsMap() *ebpf.Map {
+	return c.Collection.Maps[KnownMACsMapName]
+}
+
+func (c *collection) getUnicastNetDevMap() *ebpf.Map {
+	return c.Collection.Maps[UnicastNetDevMapName]
+}
+
+func (c *collection) getVLANNetDevMap() *ebpf.Map {
+	return c.Collection.Maps[VLANNetDevMapName]
+}
+
+func (c *collection) getVLANStatsMap() *ebpf.Map {
+	return c.Collection.Maps[VLANStatsMapName]
+}
+
+func (c *collection) getVLANDropMap() *ebpf.Map {
+	return c.Collection.Maps[VLANDropMapName]
+}
+
+func (c *collection) getVLANBucketsMap() *ebpf.Map {
+	return c.Collection.Maps[VLANBucketsMapName]
+}
+
+func (c *collection) getTalkerNetDevMap() *ebpf.Map {
+	return c.Collection.Maps[TalkerNetDevMapName]
+}
+
+func (c *collection) getTalkersMap() *ebpf.Map {
+	return c.Collection.Maps[TalkersMapName]
+}
+
 func (c *collection) getProgram() *ebpf.Program {
 	return c.Collection.Programs[ProgramName]
 }
 
-func (c *collection) getStatsMapValues() (CounterStat, error) {
-	iter := c.getStatsMap().Iterate()
-	var key uint32
-	perCPUValue := make([]PacketCounter, 0, cpuCount())
-	result := make(CounterStat, cpuCount())
+// batchLookupPerCPU reads per-CPU map by batch lookups, values of all CPUs are merged
+func batchLookupPerCPU[K comparable, V any](perCPUMap *ebpf.Map, merge func([]V) V) (map[K]V, error) {
+	cpus := cpuCount()
+	if cpus == 0 {
+		return nil, errBatchNotSupported
+	}
+	keys := make([]K, statsBatchSize)
+	perCPUValues := make([]V, statsBatchSize*cpus)
+	result := make(map[K]V, statsBatchSize)
+	var cursor ebpf.MapBatchCursor
+	for {
+		count, err := perCPUMap.BatchLookup(&cursor, keys, perCPUValues, nil)
+		for i := range count {
+			result[keys[i]] = merge(perCPUValues[i*cpus : (i+1)*cpus])
+		}
+		if errors.Is(err, ebpf.ErrKeyNotExist) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// per-CPU batch lookup does not return error of rejected request, only end of map is reported by error
+		if count == 0 {
+			return nil, errBatchNotSupported
+		}
+	}
+}
+
+// lookupPerCPU reads per-CPU map by batch lookups, map is iterated by keys if batch lookup is not supported
+func lookupPerCPU[K comparable, V any](c *collection, perCPUMap *ebpf.Map, merge func([]V) V) (map[K]V, error) {
+	if !c.noBatch.Load() {
+		result, err := batchLookupPerCPU[K](perCPUMap, merge)
+		if err == nil {
+			return result, nil
+		}
+		if !errors.Is(err, ebpf.ErrNotSupported) && !errors.Is(err, errBatchNotSupported) {
+			return nil, err
+		}
+		c.noBatch.Store(true)
+	}
+
+	return iteratePerCPU[K](perCPUMap, merge)
+}
+
+func iteratePerCPU[K comparable, V any](perCPUMap *ebpf.Map, merge func([]V) V) (map[K]V, error) {
+	iter := perCPUMap.Iterate()
+	var key K
+	perCPUValue := make([]V, 0, cpuCount())
+	result := make(map[K]V, cpuCount())
 	for iter.Next(&key, &perCPUValue) {
-		result[key] = mergeStat(perCPUValue)
+		result[key] = merge(perCPUValue)
 	}
 	if err := iter.Err(); err != nil {
 		return nil, err
 	}
-	perCPUValue = make([]PacketCounter, 0, cpuCount())
 
 	return result, nil
 }
 
+// getStatsMapValues reads statistic map by batch lookups, map is iterated by keys if batch lookup is not supported
+func (c *collection) getStatsMapValues() (CounterStat, error) {
+	return lookupPerCPU[uint32](c, c.getStatsMap(), mergeStat)
+}
+
+// getTalkersMapValues reads counters by source address of all interfaces with top talkers tracking
+func (c *collection) getTalkersMapValues() (TalkerStat, error) {
+	return lookupPerCPU[TalkerKey](c, c.getTalkersMap(), mergeTalkers)
+}
+
 func (c *collection) getDropMapValues() (DropConf, error) {
 	iter := c.getDropMap().Iterate()
 	var key uint32
@@ -143,6 +405,43 @@ func (c *collection) updateDropValue(key uint32, conf DropPKT) error {
 	return nil
 }
 
+// updateDropValues writes drop configs of several interfaces by one batch update,
+// entries are updated one by one if batch update is not supported.
+// Batch update does not accept update flags and creates missing entries, interfaces must be attached.
+func (c *collection) updateDropValues(conf DropConf) error {
+	if len(conf) == 0 {
+		return nil
+	}
+	keys := make([]uint32, 0, len(conf))
+	values := make([]DropPKT, 0, len(conf))
+	for key, value := range conf {
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if !c.noBatchUpdate.Load() {
+		_, err := c.getDropMap().BatchUpdate(keys, values, nil)
+		if !errors.Is(err, ebpf.ErrNotSupported) {
+			return err
+		}
+		c.noBatchUpdate.Store(true)
+	}
+	for i := range keys {
+		if err := c.updateDropValue(keys[i], values[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *collection) putBucketsValue(key uint32) error {
+	return c.getBucketsMap().Put(key, tokenBuckets{})
+}
+
+func (c *collection) deleteBucketsValue(key uint32) error {
+	return c.getBucketsMap().Delete(key)
+}
+
 func (c *collection) deleteStatValue(key uint32) error {
 	return c.getStatsMap().Delete(key)
 }
@@ -163,17 +462,96 @@ func (c *collection) getStatistic() (Statistic, error) {
 		return Statistic{}, err
 	}
 	result.DropConf = dropConf
+	if result.VLANCounterStat, err = c.getVLANStatsMapValues(); err != nil {
+		return Statistic{}, err
+	}
+	if result.VLANDropConf, err = c.getVLANDropMapValues(); err != nil {
+		return Statistic{}, err
+	}
 
 	return result, nil
 }
 
-func (c *collection) lookupStatValue(key uint32) (PacketCounter, error) {
-	perCPUResult := make([]PacketCounter, 0)
-	if err := c.getStatsMap().Lookup(key, &perCPUResult); err != nil {
-		return PacketCounter{}, err
+// getVLANStatsMapValues reads VLAN statistic map by batch lookups, map is iterated by keys if batch lookup is not supported.
+// Only VLAN aware interfaces have entries.
+func (c *collection) getVLANStatsMapValues() (VLANCounterStat, error) {
+	return lookupPerCPU[VLANKey](c, c.getVLANStatsMap(), mergeStat)
+}
+
+func (c *collection) getVLANDropMapValues() (VLANDropConf, error) {
+	iter := c.getVLANDropMap().Iterate()
+	var key VLANKey
+	var value DropPKT
+	result := make(VLANDropConf)
+	for iter.Next(&key, &value) {
+		result[key] = value
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
 	}
 
-	return mergeStat(perCPUResult), nil
+	return result, nil
+}
+
+// lookupVLANDropValue returns drop config of VLAN, traffic of VLAN without drop config is passed
+func (c *collection) lookupVLANDropValue(key VLANKey) (DropPKT, error) {
+	res := DropPKT{}
+	err := c.getVLANDropMap().Lookup(key, &res)
+	if errors.Is(err, ebpf.ErrKeyNotExist) {
+		return DropPKT{}, nil
+	}
+
+	return res, err
+}
+
+// putVLANDropValue writes drop config of VLAN, token buckets of VLAN are created with first config
+func (c *collection) putVLANDropValue(key VLANKey, conf DropPKT) error {
+	err := c.getVLANBucketsMap().Update(key, tokenBuckets{}, ebpf.UpdateNoExist)
+	if err != nil && !errors.Is(err, ebpf.ErrKeyExist) {
+		return err
+	}
+
+	return c.getVLANDropMap().Put(key, conf)
+}
+
+// interfaceVLANKeys returns keys of VLAN map which belong to interface,
+// only keys are read because values of maps have different types
+func interfaceVLANKeys(vlanMap *ebpf.Map, ifindex uint32) ([]VLANKey, error) {
+	var (
+		result []VLANKey
+		prev   any
+		key    VLANKey
+	)
+	for {
+		err := vlanMap.NextKey(prev, &key)
+		if errors.Is(err, ebpf.ErrKeyNotExist) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if key.Ifindex == ifindex {
+			result = append(result, key)
+		}
+		prev = key
+	}
+}
+
+// deleteVLANValues removes counters, drop configs and token buckets of all VLANs of interface
+func (c *collection) deleteVLANValues(ifindex uint32) error {
+	for _, vlanMap := range []*ebpf.Map{c.getVLANStatsMap(), c.getVLANDropMap(), c.getVLANBucketsMap()} {
+		keys, err := interfaceVLANKeys(vlanMap, ifindex)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := vlanMap.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
 func (c *collection) lookupDropValue(key uint32) (DropPKT, error) {
@@ -185,20 +563,115 @@ func (c *collection) lookupDropValue(key uint32) (DropPKT, error) {
 	return res, nil
 }
 
+// syncKnownMACs replaces content of known MACs map by keys, existing entries are kept
+func (c *collection) syncKnownMACs(keys map[macKey]struct{}) error {
+	return syncKeys(c.getKnownMACsMap(), keys)
+}
+
+// syncUnicastNetDevs replaces bridges of interfaces with unicast check
+func (c *collection) syncUnicastNetDevs(bridges map[uint32]uint32) error {
+	return syncValues(c.getUnicastNetDevMap(), bridges)
+}
+
+// syncVLANNetDevs replaces set of VLAN aware interfaces
+func (c *collection) syncVLANNetDevs(keys map[uint32]struct{}) error {
+	return syncKeys(c.getVLANNetDevMap(), keys)
+}
+
+// syncTalkerNetDevs replaces set of interfaces with top talkers tracking
+func (c *collection) syncTalkerNetDevs(keys map[uint32]struct{}) error {
+	return syncKeys(c.getTalkerNetDevMap(), keys)
+}
+
+// syncKeys replaces content of map used as set by keys, existing entries are kept.
+// Keys which are already in map are removed from keys.
+func syncKeys[K comparable](setMap *ebpf.Map, keys map[K]struct{}) error {
+	var (
+		key   K
+		value uint8
+		stale []K
+	)
+	iter := setMap.Iterate()
+	for iter.Next(&key, &value) {
+		if _, ok := keys[key]; ok {
+			delete(keys, key)
+		} else {
+			stale = append(stale, key)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	for _, key := range stale {
+		if err := setMap.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return err
+		}
+	}
+	for key := range keys {
+		if err := setMap.Put(key, uint8(1)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncValues replaces content of map by values, existing entries with the same value are kept.
+// Entries which are already in map are removed from values.
+func syncValues[K, V comparable](valueMap *ebpf.Map, values map[K]V) error {
+	var (
+		key   K
+		value V
+		stale []K
+	)
+	iter := valueMap.Iterate()
+	for iter.Next(&key, &value) {
+		if newValue, ok := values[key]; !ok {
+			stale = append(stale, key)
+		} else if newValue == value {
+			delete(values, key)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	for _, key := range stale {
+		if err := valueMap.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return err
+		}
+	}
+	for key, value := range values {
+		if err := valueMap.Put(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func mergeStat(resSlice []PacketCounter) PacketCounter {
 	result := PacketCounter{}
 	for _, resValue := range resSlice {
-		result.Broadcast.Dropped += resValue.Broadcast.Dropped
-		result.Broadcast.Passed += resValue.Broadcast.Passed
-
-		result.IPv4MCast.Dropped += resValue.IPv4MCast.Dropped
-		result.IPv4MCast.Passed += resValue.IPv4MCast.Passed
+		result.Broadcast.add(resValue.Broadcast)
+		result.IPv4MCast.add(resValue.IPv4MCast)
+		result.IPv6MCast.add(resValue.IPv6MCast)
+		result.OtherMcast.add(resValue.OtherMcast)
+		result.ARP.add(resValue.ARP)
+		result.DHCPv4.add(resValue.DHCPv4)
+		result.IPv6ND.add(resValue.IPv6ND)
+		result.MLD.add(resValue.MLD)
+		result.IPv6RA.add(resValue.IPv6RA)
+		result.UnknownUcast.add(resValue.UnknownUcast)
+	}
 
-		result.IPv6MCast.Dropped += resValue.IPv6MCast.Dropped
-		result.IPv6MCast.Passed += resValue.IPv6MCast.Passed
+	return result
+}
 
-		result.OtherMcast.Dropped += resValue.OtherMcast.Dropped
-		result.OtherMcast.Passed += resValue.OtherMcast.Passed
+func mergeTalkers(resSlice []TalkerCounter) TalkerCounter {
+	result := TalkerCounter{}
+	for _, resValue := range resSlice {
+		result.Packets += resValue.Packets
+		result.Bytes += resValue.Bytes
 	}
 
 	return result
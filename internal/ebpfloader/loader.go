@@ -6,13 +6,28 @@ import (
 	"math"
 	"sync"
 
+	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
 )
 
+// XDP attach modes
+const (
+	// native mode is tried first, generic mode is used if driver does not support XDP
+	AttachModeAuto = "auto"
+	// driver mode, program runs before skb allocation
+	AttachModeNative = "native"
+	// skb mode, supported by all interfaces
+	AttachModeGeneric = "generic"
+)
+
 type EbfProgram struct {
 	Collection *collection
 	lMux       sync.Mutex
 	Links      map[int]link.Link
+	// attach mode which was used for interface
+	attachModes map[int]string
+	// bpffs directory for maps and links, empty if pinning is disabled
+	pinPath string
 }
 
 func toUint32(interfaceIndex int) (uint32, error) {
@@ -26,45 +41,150 @@ func toUint32(interfaceIndex int) (uint32, error) {
 	return uint32(interfaceIndex), nil
 }
 
-func New() (*EbfProgram, error) {
+// New loads eBPF program. If pinPath is not empty maps and links are pinned to bpffs,
+// links and maps pinned by previous instance are reused, so counters and drop state are kept.
+func New(pinPath string) (*EbfProgram, error) {
 	prog := &EbfProgram{
-		Links: make(map[int]link.Link),
+		Links:       make(map[int]link.Link),
+		attachModes: make(map[int]string),
+		pinPath:     pinPath,
+	}
+	if pinPath == "" {
+		col, err := loadCollection()
+		if err != nil {
+			return nil, err
+		}
+		prog.Collection = col
+
+		return prog, nil
 	}
-	col, err := loadCollection()
+	col, err := loadPinnedCollection(pinPath)
 	if err != nil {
 		return nil, err
 	}
 	prog.Collection = col
+	links, modes, err := loadPinnedLinks(pinPath, col.getProgram())
+	if err != nil {
+		col.Close()
+
+		return nil, err
+	}
+	prog.Links, prog.attachModes = links, modes
 
-	return prog, err
+	return prog, nil
 }
 
-func (e *EbfProgram) AttachXDP(ndev int) error {
-	devIndexUint32, err := toUint32(ndev)
+func xdpFlags(mode string) (link.XDPAttachFlags, error) {
+	switch mode {
+	case AttachModeNative:
+		return link.XDPDriverMode, nil
+	case AttachModeGeneric:
+		return link.XDPGenericMode, nil
+	}
+
+	return 0, fmt.Errorf("unknown xdp attach mode %s", mode)
+}
+
+// CheckAttachMode validates xdp attach mode name, offload mode is not supported
+// because offloaded program can not use maps shared with host
+func CheckAttachMode(mode string) error {
+	if mode == AttachModeAuto {
+		return nil
+	}
+	_, err := xdpFlags(mode)
+
+	return err
+}
+
+func (e *EbfProgram) attachXDPLink(ndev int, mode string) (link.Link, error) {
+	flags, err := xdpFlags(mode)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	link, err := link.AttachXDP(
+
+	return link.AttachXDP(
 		link.XDPOptions{
 			Program:   e.Collection.getProgram(),
 			Interface: ndev,
-			Flags:     link.XDPGenericMode,
+			Flags:     flags,
 		})
+}
+
+// resumedLink returns attach mode of link restored from bpffs.
+// Link is detached if it was attached in other mode than requested one.
+func (e *EbfProgram) resumedLink(ndev int, mode string) (string, bool) {
+	e.lMux.Lock()
+	defer e.lMux.Unlock()
+	resumedMode, ok := e.attachModes[ndev]
+	if !ok {
+		return "", false
+	}
+	if mode == AttachModeAuto || mode == resumedMode {
+		return resumedMode, true
+	}
+	e.Links[ndev].Unpin()
+	e.Links[ndev].Close()
+	delete(e.Links, ndev)
+	delete(e.attachModes, ndev)
+
+	return "", false
+}
+
+func (e *EbfProgram) pinLink(xdpLink link.Link, ndev int, mode string) error {
+	if e.pinPath == "" {
+		return nil
+	}
+
+	return xdpLink.Pin(linkPinPath(e.pinPath, ndev, mode))
+}
+
+// AttachXDP attaches program to interface and returns attach mode which was used.
+// In auto mode native mode is tried first with fallback to generic mode.
+// Link restored from bpffs is reused with existing counters and drop state.
+func (e *EbfProgram) AttachXDP(ndev int, mode string) (string, error) {
+	devIndexUint32, err := toUint32(ndev)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if resumedMode, ok := e.resumedLink(ndev, mode); ok {
+		return resumedMode, nil
+	}
+	modes := []string{mode}
+	if mode == AttachModeAuto {
+		modes = []string{AttachModeNative, AttachModeGeneric}
+	}
+	var (
+		xdpLink    link.Link
+		attachErrs []error
+	)
+	for _, mode = range modes {
+		xdpLink, err = e.attachXDPLink(ndev, mode)
+		if err == nil {
+			break
+		}
+		attachErrs = append(attachErrs, fmt.Errorf("%s mode: %w", mode, err))
+	}
+	if xdpLink == nil {
+		return "", errors.Join(attachErrs...)
 	}
 
 	if err := e.addNetDevToMaps(devIndexUint32); err != nil {
-		link.Close()
+		xdpLink.Close()
 
-		return err
+		return "", err
+	}
+	if err := e.pinLink(xdpLink, ndev, mode); err != nil {
+		xdpLink.Close()
+
+		return "", errors.Join(err, e.removeNetDevFromMaps(devIndexUint32))
 	}
 
 	e.lMux.Lock()
 	defer e.lMux.Unlock()
-	e.Links[ndev] = link
+	e.Links[ndev] = xdpLink
+	e.attachModes[ndev] = mode
 
-	return nil
+	return mode, nil
 }
 
 func (e *EbfProgram) DetachXDP(ndev int) error {
@@ -73,6 +193,9 @@ func (e *EbfProgram) DetachXDP(ndev int) error {
 		return err
 	}
 
+	// lock is held while entries are removed, so batch update of drop configs does not recreate them
+	e.lMux.Lock()
+	defer e.lMux.Unlock()
 	if err := e.removeNetDevFromMaps(devIndexUint32); err != nil {
 		return err
 	}
@@ -81,12 +204,14 @@ func (e *EbfProgram) DetachXDP(ndev int) error {
 	if xdpLink == nil {
 		return fmt.Errorf("xdp is not attached to interface %d", ndev)
 	}
+	if err := xdpLink.Unpin(); err != nil {
+		return err
+	}
 	if err := xdpLink.Close(); err != nil {
 		return err
 	}
-	e.lMux.Lock()
-	defer e.lMux.Unlock()
 	delete(e.Links, ndev)
+	delete(e.attachModes, ndev)
 
 	return nil
 }
@@ -96,14 +221,16 @@ func (e *EbfProgram) ForceDetachXDP(ndev int) {
 	if err != nil {
 		return
 	}
+	e.lMux.Lock()
+	defer e.lMux.Unlock()
 	e.removeNetDevFromMaps(devIndexUint32) //nolint
 	xdpLink, exist := e.Links[ndev]
 	if exist {
+		xdpLink.Unpin()
 		xdpLink.Close()
 	}
-	e.lMux.Lock()
-	defer e.lMux.Unlock()
 	delete(e.Links, ndev)
+	delete(e.attachModes, ndev)
 }
 
 func (e *EbfProgram) addNetDevToMaps(ndev uint32) error {
@@ -119,6 +246,10 @@ func (e *EbfProgram) addNetDevToMaps(ndev uint32) error {
 		return err
 	}
 
+	if err := e.Collection.putBucketsValue(ndev); err != nil {
+		return errors.Join(err, e.removeNetDevFromMaps(ndev))
+	}
+
 	return nil
 }
 
@@ -131,20 +262,43 @@ func (e *EbfProgram) removeNetDevFromMaps(ndev uint32) error {
 		return err
 	}
 
-	return nil
+	if err := e.Collection.deleteBucketsValue(ndev); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return err
+	}
+
+	return e.Collection.deleteVLANValues(ndev)
 }
 
-func (e *EbfProgram) GetStatistic() (Statistic, error) {
-	return e.Collection.getStatistic()
+// AttachedNetDevs returns indexes of interfaces with attached program
+func (e *EbfProgram) AttachedNetDevs() []int {
+	e.lMux.Lock()
+	defer e.lMux.Unlock()
+	result := make([]int, 0, len(e.Links))
+	for ndev := range e.Links {
+		result = append(result, ndev)
+	}
+
+	return result
 }
 
-func (e *EbfProgram) GetDevStat(devIndex int) (PacketCounter, error) {
-	devIndexUint32, err := toUint32(devIndex)
+func (e *EbfProgram) GetStatistic() (Statistic, error) {
+	result, err := e.Collection.getStatistic()
 	if err != nil {
-		return PacketCounter{}, err
+		return Statistic{}, err
+	}
+	e.lMux.Lock()
+	defer e.lMux.Unlock()
+	result.AttachModes = make(AttachModes, len(e.attachModes))
+	for ndev, mode := range e.attachModes {
+		result.AttachModes[uint32(ndev)] = mode //nolint:gosec
 	}
 
-	return e.Collection.lookupStatValue(devIndexUint32)
+	return result, nil
+}
+
+// GetCounterStat returns counters of all attached interfaces, counters are read by batch lookups if kernel supports it
+func (e *EbfProgram) GetCounterStat() (CounterStat, error) {
+	return e.Collection.getStatsMapValues()
 }
 
 func (e *EbfProgram) GetDevDropCfg(devIndex int) (DropPKT, error) {
@@ -165,6 +319,128 @@ func (e *EbfProgram) UpdateDevDropCfg(devIndex int, cfg DropPKT) error {
 	return e.Collection.updateDropValue(devIndexUint32, cfg)
 }
 
+// UpdateDevDropCfgs writes drop configs of several interfaces, configs are written by one batch update if kernel supports it.
+// Configs of interfaces which are not attached are skipped, batch update would create their entries again.
+func (e *EbfProgram) UpdateDevDropCfgs(cfgs DropConf) error {
+	e.lMux.Lock()
+	defer e.lMux.Unlock()
+	attached := make(DropConf, len(cfgs))
+	for ndev, cfg := range cfgs {
+		if _, ok := e.Links[int(ndev)]; ok {
+			attached[ndev] = cfg
+		}
+	}
+
+	return e.Collection.updateDropValues(attached)
+}
+
+// UpdateKnownMACs replaces addresses used by unknown unicast check,
+// check is enabled only for interfaces with bridge and expected source addresses.
+// Addresses which are not Ethernet addresses are skipped.
+func (e *EbfProgram) UpdateKnownMACs(known KnownMACs) error {
+	keys := make(map[macKey]struct{})
+	bridges := make(map[uint32]uint32, len(known.Bridges))
+	for ndev, bridge := range known.Bridges {
+		devIndexUint32, err := toUint32(ndev)
+		if err != nil {
+			return err
+		}
+		bridgeIndexUint32, err := toUint32(bridge)
+		if err != nil {
+			return err
+		}
+		for _, addr := range known.Sources[ndev] {
+			if len(addr) == len(macKey{}.Addr) {
+				keys[macKey{Ifindex: devIndexUint32, Addr: [6]uint8(addr), Kind: macSource}] = struct{}{}
+				bridges[devIndexUint32] = bridgeIndexUint32
+			}
+		}
+	}
+	for bridge, destinations := range known.Destinations {
+		bridgeIndexUint32, err := toUint32(bridge)
+		if err != nil {
+			return err
+		}
+		for _, addr := range destinations {
+			if len(addr) == len(macKey{}.Addr) {
+				keys[macKey{Ifindex: bridgeIndexUint32, Addr: [6]uint8(addr), Kind: macDestination}] = struct{}{}
+			}
+		}
+	}
+	// addresses are written before check is enabled for new interfaces
+	if err := e.Collection.syncKnownMACs(keys); err != nil {
+		return err
+	}
+
+	return e.Collection.syncUnicastNetDevs(bridges)
+}
+
+// netDevKeys converts interface indexes to keys of set maps
+func netDevKeys(netDevs []int) (map[uint32]struct{}, error) {
+	keys := make(map[uint32]struct{}, len(netDevs))
+	for _, ndev := range netDevs {
+		devIndexUint32, err := toUint32(ndev)
+		if err != nil {
+			return nil, err
+		}
+		keys[devIndexUint32] = struct{}{}
+	}
+
+	return keys, nil
+}
+
+// UpdateVLANNetDevs replaces set of interfaces with counters and drop configs by VLAN
+func (e *EbfProgram) UpdateVLANNetDevs(netDevs []int) error {
+	keys, err := netDevKeys(netDevs)
+	if err != nil {
+		return err
+	}
+
+	return e.Collection.syncVLANNetDevs(keys)
+}
+
+// UpdateTalkerNetDevs replaces set of interfaces with broadcast and multicast counters by source address
+func (e *EbfProgram) UpdateTalkerNetDevs(netDevs []int) error {
+	keys, err := netDevKeys(netDevs)
+	if err != nil {
+		return err
+	}
+
+	return e.Collection.syncTalkerNetDevs(keys)
+}
+
+// GetTalkerStat returns counters by source address of interfaces with top talkers tracking,
+// counters of detached interfaces stay in map until they are evicted
+func (e *EbfProgram) GetTalkerStat() (TalkerStat, error) {
+	return e.Collection.getTalkersMapValues()
+}
+
+// GetVLANCounterStat returns counters of VLANs of all VLAN aware interfaces
+func (e *EbfProgram) GetVLANCounterStat() (VLANCounterStat, error) {
+	return e.Collection.getVLANStatsMapValues()
+}
+
+// GetVLANDropCfg returns drop config of VLAN, empty config is returned for VLAN without config
+func (e *EbfProgram) GetVLANDropCfg(devIndex int, vlan uint16) (DropPKT, error) {
+	devIndexUint32, err := toUint32(devIndex)
+	if err != nil {
+		return DropPKT{}, err
+	}
+
+	return e.Collection.lookupVLANDropValue(VLANKey{Ifindex: devIndexUint32, VLAN: vlan})
+}
+
+// UpdateVLANDropCfg creates or updates drop config of VLAN
+func (e *EbfProgram) UpdateVLANDropCfg(devIndex int, vlan uint16, cfg DropPKT) error {
+	devIndexUint32, err := toUint32(devIndex)
+	if err != nil {
+		return err
+	}
+
+	return e.Collection.putVLANDropValue(VLANKey{Ifindex: devIndexUint32, VLAN: vlan}, cfg)
+}
+
+// Close releases program resources, pinned links stay attached
 func (e *EbfProgram) Close() {
 	e.lMux.Lock()
 	for _, ln := range e.Links {
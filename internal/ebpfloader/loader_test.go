@@ -0,0 +1,127 @@
+package ebpfloader
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/cilium/ebpf"
+	"github.com/mythvcode/storm-control/ebpfxdp"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	xdpDrop = 1
+	xdpPass = 2
+	// index of loopback interface, program test run requires existing interface
+	testNetDev = 1
+)
+
+// xdpMD is context of XDP program test run
+type xdpMD struct {
+	Data           uint32
+	DataEnd        uint32
+	DataMeta       uint32
+	IngressIfindex uint32
+	RxQueueIndex   uint32
+	EgressIfindex  uint32
+}
+
+// loadTestCollection loads embedded kernel program, so program is checked by verifier.
+// Test is skipped if program is not built or process is not allowed to load programs.
+func loadTestCollection(t *testing.T) *collection {
+	t.Helper()
+	if len(ebpfxdp.KernelProgramBytes) == 0 {
+		t.Skip("kernel program is not built, run make build_xdp")
+	}
+	col, err := loadCollection()
+	if errors.Is(err, os.ErrPermission) {
+		t.Skipf("loading of eBPF programs is not permitted: %s", err)
+	}
+	var verifierErr *ebpf.VerifierError
+	if errors.As(err, &verifierErr) {
+		t.Fatalf("program is rejected by verifier: %+v", verifierErr)
+	}
+	require.NoError(t, err)
+	t.Cleanup(col.Close)
+
+	return col
+}
+
+func runTestFrame(t *testing.T, col *collection, frame []byte) uint32 {
+	t.Helper()
+	ret, err := col.getProgram().Run(&ebpf.RunOptions{
+		Data:    frame,
+		Context: xdpMD{DataEnd: uint32(len(frame)), IngressIfindex: testNetDev}, //nolint:gosec
+	})
+	if errors.Is(err, ebpf.ErrNotSupported) {
+		t.Skipf("test run of XDP program is not supported: %s", err)
+	}
+	require.NoError(t, err)
+
+	return ret
+}
+
+func makeTestFrame(dst, src [6]byte) []byte {
+	frame := make([]byte, 64)
+	copy(frame, dst[:])
+	copy(frame[6:], src[:])
+	// IPv4 ethertype
+	frame[12], frame[13] = 0x08, 0x00
+
+	return frame
+}
+
+func TestLoadProgram(t *testing.T) {
+	col := loadTestCollection(t)
+	require.NotNil(t, col.getProgram())
+	for _, name := range []string{StatsMapName, DropMapName, KnownMACsMapName, UnicastNetDevMapName, VLANStatsMapName, TalkersMapName} {
+		require.Contains(t, col.Maps, name)
+	}
+}
+
+func TestProgramDropConfig(t *testing.T) {
+	col := loadTestCollection(t)
+	frame := makeTestFrame([6]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, [6]byte{0xfa, 0x16, 0x3e, 0, 0, 1})
+	// traffic of not watched interface is passed and not counted
+	require.Equal(t, uint32(xdpPass), runTestFrame(t, col, frame))
+
+	require.NoError(t, col.putStatValue(testNetDev))
+	require.NoError(t, col.putDropValue(testNetDev, DropPKT{}))
+	require.NoError(t, col.putBucketsValue(testNetDev))
+	require.Equal(t, uint32(xdpPass), runTestFrame(t, col, frame))
+	require.NoError(t, col.updateDropValue(testNetDev, DropPKT{Broadcast: ActionDrop}))
+	require.Equal(t, uint32(xdpDrop), runTestFrame(t, col, frame))
+
+	stats, err := col.getStatsMapValues()
+	require.NoError(t, err)
+	require.Equal(t, TrafInfo{Passed: 1, Dropped: 1, PassedBytes: 64, DroppedBytes: 64}, stats[testNetDev].Broadcast)
+}
+
+func TestProgramUnknownUnicast(t *testing.T) {
+	col := loadTestCollection(t)
+	const bridge = 100
+	guestMAC := net.HardwareAddr{0xfa, 0x16, 0x3e, 0, 0, 1}
+	gatewayMAC := net.HardwareAddr{0x02, 0, 0, 0, 0, 0xfe}
+	unknownMAC := net.HardwareAddr{0x02, 0, 0, 0, 0, 0x99}
+	require.NoError(t, col.putStatValue(testNetDev))
+	require.NoError(t, col.putDropValue(testNetDev, DropPKT{UnknownUcast: ActionDrop}))
+	require.NoError(t, col.putBucketsValue(testNetDev))
+	require.NoError(t, (&EbfProgram{Collection: col}).UpdateKnownMACs(KnownMACs{
+		Bridges:      map[int]int{testNetDev: bridge},
+		Sources:      map[int][]net.HardwareAddr{testNetDev: {guestMAC}},
+		Destinations: map[int][]net.HardwareAddr{bridge: {gatewayMAC}},
+	}))
+
+	// guest to gateway is known unicast, it is passed and not counted
+	require.Equal(t, uint32(xdpPass), runTestFrame(t, col, makeTestFrame([6]byte(gatewayMAC), [6]byte(guestMAC))))
+	// destination is not known on bridge
+	require.Equal(t, uint32(xdpDrop), runTestFrame(t, col, makeTestFrame([6]byte(unknownMAC), [6]byte(guestMAC))))
+	// source is not expected on interface
+	require.Equal(t, uint32(xdpDrop), runTestFrame(t, col, makeTestFrame([6]byte(gatewayMAC), [6]byte(unknownMAC))))
+
+	stats, err := col.getStatsMapValues()
+	require.NoError(t, err)
+	require.Equal(t, TrafInfo{Dropped: 2, DroppedBytes: 128}, stats[testNetDev].UnknownUcast)
+}
@@ -0,0 +1,217 @@
+package ebpfloader
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/mythvcode/storm-control/internal/logger"
+)
+
+const linksPinDir = "links"
+
+// maps shared between program instances, must keep layout compatible between versions.
+// Known MACs map, interfaces with unicast check and sets of VLAN aware interfaces and interfaces with top talkers tracking are not pinned,
+// they are filled by every instance after attach. Top talkers counters are not pinned as well.
+var pinnedMaps = []string{StatsMapName, DropMapName, BucketsMapName, VLANStatsMapName, VLANDropMapName, VLANBucketsMapName}
+
+func linkPinPath(pinPath string, ndev int, mode string) string {
+	return filepath.Join(pinPath, linksPinDir, fmt.Sprintf("%d_%s", ndev, mode))
+}
+
+// parseLinkPinName returns interface index and attach mode encoded in link pin file name
+func parseLinkPinName(name string) (int, string, bool) {
+	index, mode, found := strings.Cut(name, "_")
+	if !found {
+		return 0, "", false
+	}
+	ndev, err := strconv.Atoi(index)
+	if err != nil || ndev <= 0 {
+		return 0, "", false
+	}
+	if _, err := xdpFlags(mode); err != nil {
+		return 0, "", false
+	}
+
+	return ndev, mode, true
+}
+
+// loadPinnedCollection loads collection with maps pinned to pinPath.
+// Already pinned maps are reused if their layout is compatible,
+// otherwise stale links and maps are removed and new maps are created.
+func loadPinnedCollection(pinPath string) (*collection, error) {
+	if err := os.MkdirAll(filepath.Join(pinPath, linksPinDir), 0o700); err != nil {
+		return nil, fmt.Errorf("create pin directory: %w", err)
+	}
+	specs, err := getSpecs()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range pinnedMaps {
+		mapSpec, ok := specs.Maps[name]
+		if !ok {
+			return nil, fmt.Errorf("map %s not found in program", name)
+		}
+		mapSpec.Pinning = ebpf.PinByName
+	}
+	opts := ebpf.CollectionOptions{Maps: ebpf.MapOptions{PinPath: pinPath}}
+
+	col, err := ebpf.NewCollectionWithOptions(specs, opts)
+	if errors.Is(err, ebpf.ErrMapIncompatible) {
+		logger.GetLogger().With(slog.String(logger.Component, "ebpf-loader")).Warningf(
+			"Pinned maps in %s are not compatible with program, counters and drop state are reset: %s",
+			pinPath, err.Error(),
+		)
+		if err := removePinned(pinPath); err != nil {
+			return nil, err
+		}
+		col, err = ebpf.NewCollectionWithOptions(specs, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &collection{Collection: col}, nil
+}
+
+// loadPinnedLinks loads links pinned by previous program instance and replaces program of links
+func loadPinnedLinks(pinPath string, prog *ebpf.Program) (map[int]link.Link, map[int]string, error) {
+	linksDir := filepath.Join(pinPath, linksPinDir)
+	entries, err := os.ReadDir(linksDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	links := make(map[int]link.Link, len(entries))
+	modes := make(map[int]string, len(entries))
+	for _, entry := range entries {
+		path := filepath.Join(linksDir, entry.Name())
+		ndev, mode, ok := parseLinkPinName(entry.Name())
+		if !ok {
+			continue
+		}
+		xdpLink, err := link.LoadPinnedLink(path, nil)
+		if err != nil {
+			os.Remove(path)
+
+			continue
+		}
+		// link is detached by kernel in case interface was removed
+		if err := xdpLink.Update(prog); err != nil {
+			xdpLink.Unpin()
+			xdpLink.Close()
+
+			continue
+		}
+		links[ndev] = xdpLink
+		modes[ndev] = mode
+	}
+
+	return links, modes, nil
+}
+
+// removePinned detaches pinned links and removes pinned maps
+func removePinned(pinPath string) error {
+	linksDir := filepath.Join(pinPath, linksPinDir)
+	entries, err := os.ReadDir(linksDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(linksDir, entry.Name())
+		if xdpLink, err := link.LoadPinnedLink(path, nil); err == nil {
+			xdpLink.Unpin()
+			xdpLink.Close()
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	for _, name := range pinnedMaps {
+		if err := os.Remove(filepath.Join(pinPath, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pinnedAttachModes returns attach modes of links pinned to pinPath
+func pinnedAttachModes(pinPath string) (AttachModes, error) {
+	entries, err := os.ReadDir(filepath.Join(pinPath, linksPinDir))
+	if err != nil {
+		return nil, err
+	}
+	result := make(AttachModes, len(entries))
+	for _, entry := range entries {
+		ndev, mode, ok := parseLinkPinName(entry.Name())
+		if !ok {
+			continue
+		}
+		result[uint32(ndev)] = mode //nolint:gosec
+	}
+
+	return result, nil
+}
+
+// PinnedMaps gives access to maps pinned by daemon, used by tools when daemon is not running
+type PinnedMaps struct {
+	Collection *collection
+	pinPath    string
+}
+
+// OpenPinned opens maps pinned to pinPath by daemon
+func OpenPinned(pinPath string) (*PinnedMaps, error) {
+	col := &ebpf.Collection{Maps: make(map[string]*ebpf.Map, len(pinnedMaps))}
+	for _, name := range pinnedMaps {
+		pinnedMap, err := ebpf.LoadPinnedMap(filepath.Join(pinPath, name), nil)
+		if err != nil {
+			col.Close()
+
+			return nil, fmt.Errorf("load pinned map %s: %w", name, err)
+		}
+		col.Maps[name] = pinnedMap
+	}
+
+	return &PinnedMaps{Collection: &collection{Collection: col}, pinPath: pinPath}, nil
+}
+
+func (p *PinnedMaps) GetStatistic() (Statistic, error) {
+	result, err := p.Collection.getStatistic()
+	if err != nil {
+		return Statistic{}, err
+	}
+	result.AttachModes, err = pinnedAttachModes(p.pinPath)
+	if err != nil {
+		return Statistic{}, err
+	}
+
+	return result, nil
+}
+
+func (p *PinnedMaps) GetDevDropCfg(devIndex int) (DropPKT, error) {
+	devIndexUint32, err := toUint32(devIndex)
+	if err != nil {
+		return DropPKT{}, err
+	}
+
+	return p.Collection.lookupDropValue(devIndexUint32)
+}
+
+func (p *PinnedMaps) UpdateDevDropCfg(devIndex int, cfg DropPKT) error {
+	devIndexUint32, err := toUint32(devIndex)
+	if err != nil {
+		return err
+	}
+
+	return p.Collection.updateDropValue(devIndexUint32, cfg)
+}
+
+func (p *PinnedMaps) Close() {
+	p.Collection.Close()
+}
@@ -0,0 +1,85 @@
+package ebpfloader
+
+// TrafficType describes type of traffic handled by kernel program,
+// accessors return fields of the type in counters and drop config
+type TrafficType struct {
+	Name     string
+	Counters func(*PacketCounter) *TrafInfo
+	Action   func(*DropPKT) *uint8
+	Rate     func(*DropPKT) *RateLimit
+}
+
+// TrafficTypes are all types of traffic in order of counters of kernel program
+var TrafficTypes = []TrafficType{
+	{
+		Name:     "broadcast",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.Broadcast },
+		Action:   func(d *DropPKT) *uint8 { return &d.Broadcast },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.BroadcastRate },
+	},
+	{
+		Name:     "ipv4_multicast",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.IPv4MCast },
+		Action:   func(d *DropPKT) *uint8 { return &d.IPv4MCast },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.IPv4MCastRate },
+	},
+	{
+		Name:     "ipv6_multicast",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.IPv6MCast },
+		Action:   func(d *DropPKT) *uint8 { return &d.IPv6MCast },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.IPv6MCastRate },
+	},
+	{
+		Name:     "other_multicast",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.OtherMcast },
+		Action:   func(d *DropPKT) *uint8 { return &d.Multicast },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.MulticastRate },
+	},
+	{
+		Name:     "arp",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.ARP },
+		Action:   func(d *DropPKT) *uint8 { return &d.ARP },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.ARPRate },
+	},
+	{
+		Name:     "dhcpv4",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.DHCPv4 },
+		Action:   func(d *DropPKT) *uint8 { return &d.DHCPv4 },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.DHCPv4Rate },
+	},
+	{
+		Name:     "ipv6_nd",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.IPv6ND },
+		Action:   func(d *DropPKT) *uint8 { return &d.IPv6ND },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.IPv6NDRate },
+	},
+	{
+		Name:     "mld",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.MLD },
+		Action:   func(d *DropPKT) *uint8 { return &d.MLD },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.MLDRate },
+	},
+	{
+		Name:     "ipv6_ra",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.IPv6RA },
+		Action:   func(d *DropPKT) *uint8 { return &d.IPv6RA },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.IPv6RARate },
+	},
+	{
+		Name:     "unknown_unicast",
+		Counters: func(c *PacketCounter) *TrafInfo { return &c.UnknownUcast },
+		Action:   func(d *DropPKT) *uint8 { return &d.UnknownUcast },
+		Rate:     func(d *DropPKT) *RateLimit { return &d.UnknownUcastRate },
+	},
+}
+
+// LookupTrafficType returns traffic type by name
+func LookupTrafficType(name string) (TrafficType, bool) {
+	for _, trafType := range TrafficTypes {
+		if trafType.Name == name {
+			return trafType, true
+		}
+	}
+
+	return TrafficType{}, false
+}
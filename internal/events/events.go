@@ -0,0 +1,138 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// event actions
+const (
+	ActionBlock   = "block"
+	ActionUnblock = "unblock"
+	// dropped traffic rate was above unblock threshold during recheck interval, traffic stays blocked
+	ActionRecheckFailed = "recheck_failed"
+)
+
+// event sources
+const (
+	// decision of watcher based on thresholds
+	SourceAuto = "auto"
+	// operator action by admin API
+	SourceManual = "manual"
+)
+
+// Event describes block or unblock of traffic type on interface
+type Event struct {
+	Time        time.Time `json:"time"`
+	Action      string    `json:"action"`
+	Source      string    `json:"source"`
+	Interface   string    `json:"interface"`
+	Index       int       `json:"index"`
+	TrafficType string    `json:"traffic_type"`
+	// VLAN of VLAN aware interface, not set for events of interface
+	VLAN *uint16 `json:"vlan,omitempty"`
+	// decision is made in dry run mode and traffic is not changed
+	DryRun bool `json:"dry_run,omitempty"`
+	// observed passed packets and bytes per second which exceeded thresholds, set for automatic block
+	Rate           uint64 `json:"rate"`
+	RateBytes      uint64 `json:"rate_bytes"`
+	Threshold      uint64 `json:"threshold"`
+	ThresholdBytes uint64 `json:"threshold_bytes"`
+	// sources of interface with highest broadcast and multicast rate of last second, set for automatic block
+	TopTalkers []Talker `json:"top_talkers,omitempty"`
+	// block duration for block events, zero for manual block without duration.
+	// Time of traffic block for unblock events, zero if it is unknown.
+	Duration time.Duration `json:"-"`
+}
+
+// Talker is source address of broadcast and multicast frames received by interface with per second rates
+type Talker struct {
+	MAC       string `json:"mac"`
+	Rate      uint64 `json:"rate"`
+	RateBytes uint64 `json:"rate_bytes"`
+}
+
+// MarshalJSON writes duration as Go duration string, e.g. "1m30s"
+func (e Event) MarshalJSON() ([]byte, error) {
+	type plain Event
+	payload := struct {
+		plain
+		Duration string `json:"duration,omitempty"`
+	}{plain: plain(e)}
+	if e.Duration > 0 {
+		payload.Duration = e.Duration.String()
+	}
+
+	return json.Marshal(payload)
+}
+
+// Sink receives events
+type Sink interface {
+	// Notify handles or queues event, it must not block publisher
+	Notify(event Event)
+}
+
+// backgroundSink delivers queued events in separate goroutine
+type backgroundSink interface {
+	Sink
+	// Start delivers queued events until Stop is called
+	Start()
+	Stop()
+}
+
+// Bus delivers published events to all sinks
+type Bus struct {
+	mux   sync.RWMutex
+	sinks []Sink
+	wg    sync.WaitGroup
+}
+
+func NewBus(sinks ...Sink) *Bus {
+	return &Bus{sinks: sinks}
+}
+
+// Subscribe adds sink to bus, sinks with background delivery must be added before Start
+func (b *Bus) Subscribe(sink Sink) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.sinks = append(b.sinks, sink)
+}
+
+func (b *Bus) Start() {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	for _, sink := range b.sinks {
+		if background, ok := sink.(backgroundSink); ok {
+			b.wg.Add(1)
+			go func() {
+				defer b.wg.Done()
+				background.Start()
+			}()
+		}
+	}
+}
+
+// Stop stops all sinks and waits for them, undelivered events are lost
+func (b *Bus) Stop() {
+	b.mux.RLock()
+	for _, sink := range b.sinks {
+		if background, ok := sink.(backgroundSink); ok {
+			background.Stop()
+		}
+	}
+	b.mux.RUnlock()
+	b.wg.Wait()
+}
+
+// Publish sends event to all sinks, time of event is set if it is empty
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	for _, sink := range b.sinks {
+		sink.Notify(event)
+	}
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/logger"
+)
+
+var errRetryable = errors.New("temporary error")
+
+// Webhook sends events as POST requests to HTTP endpoint
+type Webhook struct {
+	config   config.WebhookConfig
+	client   *http.Client
+	template *template.Template
+	queue    chan Event
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+	log      *logger.Logger
+}
+
+var templateFuncs = template.FuncMap{
+	// json encodes value, used to escape strings in JSON templates
+	"json": func(value any) (string, error) {
+		result, err := json.Marshal(value)
+
+		return string(result), err
+	},
+}
+
+// NewWebhook validates webhook settings and creates sink, events are delivered after Start
+func NewWebhook(cfg config.WebhookConfig) (*Webhook, error) {
+	target, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("invalid webhook url %q: scheme must be http or https", cfg.URL)
+	}
+	if cfg.QueueSize <= 0 {
+		return nil, fmt.Errorf("invalid webhook queue size %d", cfg.QueueSize)
+	}
+	if cfg.Retries < 0 {
+		return nil, fmt.Errorf("invalid webhook retries %d", cfg.Retries)
+	}
+	var payloadTemplate *template.Template
+	if cfg.Template != "" {
+		payloadTemplate, err = template.New("webhook").Funcs(templateFuncs).Parse(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook template: %w", err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Webhook{
+		config:   cfg,
+		client:   &http.Client{Timeout: time.Duration(cfg.Timeout) * time.Second},
+		template: payloadTemplate,
+		queue:    make(chan Event, cfg.QueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		log:      logger.GetLogger().With(slog.String(logger.Component, "events-webhook")),
+	}, nil
+}
+
+// Notify queues event with configured action, event is dropped if queue is full
+func (w *Webhook) Notify(event Event) {
+	if !slices.Contains(w.config.Actions, event.Action) {
+		return
+	}
+	select {
+	case w.queue <- event:
+	default:
+		w.log.Warningf("Webhook queue is full, %s event of interface %s is dropped", event.Action, event.Interface)
+	}
+}
+
+// Start delivers queued events one by one until Stop is called
+func (w *Webhook) Start() {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case event := <-w.queue:
+			if err := w.deliver(event); err != nil && w.ctx.Err() == nil {
+				w.log.Errorf("Error send %s event of interface %s to webhook: %s", event.Action, event.Interface, err.Error())
+			}
+		}
+	}
+}
+
+// Stop interrupts delivery, queued events are dropped
+func (w *Webhook) Stop() {
+	w.stopOnce.Do(w.cancel)
+}
+
+func (w *Webhook) payload(event Event) ([]byte, error) {
+	if w.template == nil {
+		return json.Marshal(event)
+	}
+	var result bytes.Buffer
+	if err := w.template.Execute(&result, event); err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+
+	return result.Bytes(), nil
+}
+
+// deliver sends event and retries on network errors, 429 and 5xx responses
+func (w *Webhook) deliver(event Event) error {
+	body, err := w.payload(event)
+	if err != nil {
+		return err
+	}
+	retryInterval := time.Duration(w.config.RetryInterval) * time.Second
+	for attempt := 0; ; attempt++ {
+		err = w.send(body)
+		if err == nil || !errors.Is(err, errRetryable) || attempt >= w.config.Retries {
+			return err
+		}
+		w.log.Debugf("Webhook attempt %d failed, retry in %s: %s", attempt+1, retryInterval, err.Error())
+		timer := time.NewTimer(retryInterval)
+		select {
+		case <-w.ctx.Done():
+			timer.Stop()
+
+			return w.ctx.Err()
+		case <-timer.C:
+		}
+		retryInterval *= 2
+	}
+}
+
+func (w *Webhook) send(body []byte) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.config.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errRetryable, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	switch {
+	case resp.StatusCode < http.StatusMultipleChoices:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: status %s", errRetryable, resp.Status)
+	}
+
+	return fmt.Errorf("status %s", resp.Status)
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+var testActions = []string{ActionBlock, ActionUnblock}
+
+var testEvent = Event{
+	Time:        time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC),
+	Action:      ActionBlock,
+	Source:      SourceAuto,
+	Interface:   "tap1",
+	Index:       5,
+	TrafficType: "broadcast",
+	Rate:        5000,
+	Threshold:   100,
+	Duration:    90 * time.Second,
+}
+
+type testRequest struct {
+	header http.Header
+	body   string
+}
+
+// startTestServer returns server which responds with statuses in order, last status is repeated
+func startTestServer(t *testing.T, statuses ...int) (*httptest.Server, chan testRequest) {
+	t.Helper()
+	requests := make(chan testRequest, 10)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- testRequest{header: r.Header, body: string(body)}
+		w.WriteHeader(statuses[min(calls, len(statuses)-1)])
+		calls++
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requests
+}
+
+func makeTestWebhook(t *testing.T, cfg config.WebhookConfig) *Webhook {
+	t.Helper()
+	webhook, err := NewWebhook(cfg)
+	require.NoError(t, err)
+	bus := NewBus(webhook)
+	bus.Start()
+	t.Cleanup(bus.Stop)
+
+	return webhook
+}
+
+func waitRequest(t *testing.T, requests chan testRequest) testRequest {
+	t.Helper()
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(time.Second):
+		require.Fail(t, "webhook request is not received")
+	}
+
+	return testRequest{}
+}
+
+func TestEventMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(testEvent)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"time": "2025-01-01T10:00:00Z",
+		"action": "block",
+		"source": "auto",
+		"interface": "tap1",
+		"index": 5,
+		"traffic_type": "broadcast",
+		"rate": 5000,
+		"rate_bytes": 0,
+		"threshold": 100,
+		"threshold_bytes": 0,
+		"duration": "1m30s"
+	}`, string(data))
+
+	data, err = json.Marshal(Event{Action: ActionUnblock})
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "duration")
+}
+
+func TestNewWebhookValidation(t *testing.T) {
+	_, err := NewWebhook(config.WebhookConfig{URL: "", QueueSize: 1})
+	require.Error(t, err)
+	_, err = NewWebhook(config.WebhookConfig{URL: "ftp://example.com", QueueSize: 1})
+	require.Error(t, err)
+	_, err = NewWebhook(config.WebhookConfig{URL: "http://example.com", QueueSize: 0})
+	require.Error(t, err)
+	_, err = NewWebhook(config.WebhookConfig{URL: "http://example.com", QueueSize: 1, Retries: -1})
+	require.Error(t, err)
+	_, err = NewWebhook(config.WebhookConfig{URL: "http://example.com", QueueSize: 1, Template: "{{ .Interface "})
+	require.Error(t, err)
+}
+
+func TestWebhookDefaultPayload(t *testing.T) {
+	server, requests := startTestServer(t, http.StatusOK)
+	NewBus(makeTestWebhook(t, config.WebhookConfig{
+		URL:       server.URL,
+		Timeout:   1,
+		QueueSize: 10,
+		Actions:   testActions,
+		Headers:   map[string]string{"Authorization": "Bearer test"},
+	})).Publish(testEvent)
+
+	req := waitRequest(t, requests)
+	require.Equal(t, "application/json", req.header.Get("Content-Type"))
+	require.Equal(t, "Bearer test", req.header.Get("Authorization"))
+	expected, err := json.Marshal(testEvent)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), req.body)
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	server, requests := startTestServer(t, http.StatusOK)
+	makeTestWebhook(t, config.WebhookConfig{
+		URL:       server.URL,
+		Timeout:   1,
+		QueueSize: 10,
+		Actions:   testActions,
+		Template:  `{"text": {{ printf "%s %s traffic of %s for %s" .Action .TrafficType .Interface .Duration | json }}}`,
+	}).Notify(testEvent)
+
+	require.JSONEq(t, `{"text": "block broadcast traffic of tap1 for 1m30s"}`, waitRequest(t, requests).body)
+}
+
+func TestWebhookRetries(t *testing.T) {
+	server, requests := startTestServer(t, http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK)
+	webhook := makeTestWebhook(t, config.WebhookConfig{URL: server.URL, Timeout: 1, Retries: 3, QueueSize: 10, Actions: testActions})
+	webhook.Notify(testEvent)
+	for range 3 {
+		waitRequest(t, requests)
+	}
+	// delivered event is not retried
+	select {
+	case <-requests:
+		require.Fail(t, "unexpected request after successful delivery")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWebhookRetryLimit(t *testing.T) {
+	server, requests := startTestServer(t, http.StatusBadGateway)
+	webhook, err := NewWebhook(config.WebhookConfig{URL: server.URL, Timeout: 1, Retries: 2, QueueSize: 10, Actions: testActions})
+	require.NoError(t, err)
+	require.Error(t, webhook.deliver(testEvent))
+	require.Len(t, requests, 3)
+
+	// client errors are not retried
+	server, requests = startTestServer(t, http.StatusBadRequest)
+	webhook, err = NewWebhook(config.WebhookConfig{URL: server.URL, Timeout: 1, Retries: 2, QueueSize: 10, Actions: testActions})
+	require.NoError(t, err)
+	require.Error(t, webhook.deliver(testEvent))
+	require.Len(t, requests, 1)
+}
+
+func TestWebhookQueueFull(t *testing.T) {
+	webhook, err := NewWebhook(config.WebhookConfig{URL: "http://example.com", QueueSize: 2, Actions: testActions})
+	require.NoError(t, err)
+	// events without configured action are skipped
+	webhook.Notify(Event{Action: ActionRecheckFailed})
+	require.Empty(t, webhook.queue)
+	// webhook is not started, events are not delivered
+	for range 5 {
+		webhook.Notify(testEvent)
+	}
+	require.Len(t, webhook.queue, 2)
+}
@@ -4,9 +4,13 @@ import (
 	"log/slog"
 	"net"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
 	"github.com/mythvcode/storm-control/internal/logger"
+	"github.com/mythvcode/storm-control/internal/watcher"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -16,110 +20,229 @@ const (
 	metricsNamespace    = "storm_control"
 	interfaceIndexLabel = "interface_index"
 	interfaceNameLabel  = "interface_name"
+	// VLAN of VLAN aware interface, empty for metrics of interface
+	vlanLabel = "vlan"
+
+	attachModeLabel   = "attach_mode"
+	unknownAttachMode = "unknown"
+
+	sourceLabel = "source"
+	windowLabel = "window"
+	macLabel    = "mac"
+	dryRunLabel = "dry_run"
 
 	trafficTypeLabel   = "traffic_type"
 	broadcastType      = "broadcast"
 	ipv4MulticastType  = "ipv4_multicast"
 	ipv6MulticastType  = "ipv6_multicast"
 	otherMulticastType = "other_multicast"
+	arpType            = "arp"
+	dhcpv4Type         = "dhcpv4"
+	ipv6NDType         = "ipv6_nd"
+	mldType            = "mld"
+	ipv6RAType         = "ipv6_ra"
+	unknownUnicastType = "unknown_unicast"
 )
 
-type StormControlCollector struct {
-	statsLoader             StatsLoader
-	log                     *logger.Logger
-	BroadcastPassedPackets  *prometheus.CounterVec
-	BroadcastDroppedPackets *prometheus.CounterVec
-
-	MulticastPassedPacketsTotal  *prometheus.CounterVec
-	MulticastDroppedPacketsTotal *prometheus.CounterVec
-
-	MulticastPassedPacketsByType  *prometheus.CounterVec
-	MulticastDroppedPacketsByType *prometheus.CounterVec
+// trafficDescs describes metrics of one eBPF counter: total and by type broadcast, multicast by type, total multicast
+// and unknown unicast
+type trafficDescs struct {
+	broadcast       *prometheus.Desc
+	broadcastByType *prometheus.Desc
+	byType          *prometheus.Desc
+	total           *prometheus.Desc
+	unknownUnicast  *prometheus.Desc
+	value           func(info ebpfloader.TrafInfo) uint64
+}
 
-	TrafficBlockedByInterface *prometheus.GaugeVec
+// StormControlCollector makes metrics of eBPF counters and watcher state on every scrape by const metrics,
+// only event metrics are kept between scrapes
+type StormControlCollector struct {
+	statsLoader StatsLoader
+	stateLoader WatcherStateLoader
+	// windows in seconds of peak and average rates
+	rateWindows []int
+	// rates of top talkers are exported
+	topTalkers bool
+	log        *logger.Logger
+
+	PassedPackets  trafficDescs
+	DroppedPackets trafficDescs
+	PassedBytes    trafficDescs
+	DroppedBytes   trafficDescs
+
+	TrafficBlockedByInterface *prometheus.Desc
+
+	AttachedLinks *prometheus.Desc
+
+	BlockBackoffLevel *prometheus.Desc
+
+	// per second rates of passed traffic calculated by watcher
+	PassedPacketsRate        *prometheus.Desc
+	PassedPacketsRatePeak    *prometheus.Desc
+	PassedPacketsRateAverage *prometheus.Desc
+	PassedBytesRate          *prometheus.Desc
+	PassedBytesRatePeak      *prometheus.Desc
+	PassedBytesRateAverage   *prometheus.Desc
+
+	// broadcast and multicast rates of sources with highest rate, exported only if enabled
+	TopTalkerPacketsRate *prometheus.Desc
+	TopTalkerBytesRate   *prometheus.Desc
+
+	// event metrics are updated by watcher events and are not reset on collect
+	BlockEventsTotal          *prometheus.CounterVec
+	BlockDuration             *prometheus.HistogramVec
+	UnblockRecheckFailedTotal *prometheus.CounterVec
+	LastBlockTimestamp        *prometheus.GaugeVec
+
+	// indexes of interfaces with event metrics, metrics of detached interfaces are removed on collect
+	eventNetDevsMux sync.Mutex
+	eventNetDevs    map[int]struct{}
+}
 
-	AttachedLinks *prometheus.GaugeVec
+func newDesc(name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", name), help, labels, nil)
 }
 
-func findInterface(netDevList []net.Interface, index uint32) *net.Interface {
-	for _, netDev := range netDevList {
-		if netDev.Index == int(index) {
-			return &netDev
-		}
+func newTrafficDescs(kind, unit, help string, value func(info ebpfloader.TrafInfo) uint64) trafficDescs {
+	return trafficDescs{
+		broadcast: newDesc(
+			"broadcast_"+kind+"_"+unit,
+			"Counter "+kind+" broadcast "+unit+" by interface",
+			interfaceIndexLabel, interfaceNameLabel, vlanLabel,
+		),
+		broadcastByType: newDesc(
+			"broadcast_"+kind+"_"+unit+"_by_type",
+			help+" broadcast "+unit+" for interface by traffic type",
+			interfaceIndexLabel, interfaceNameLabel, vlanLabel, trafficTypeLabel,
+		),
+		byType: newDesc(
+			"multicast_"+kind+"_"+unit+"_by_type",
+			help+" multicast "+unit+" for interface by traffic type",
+			interfaceIndexLabel, interfaceNameLabel, vlanLabel, trafficTypeLabel,
+		),
+		total: newDesc(
+			"multicast_"+kind+"_"+unit+"_total",
+			"Total "+kind+" multicast "+unit+" for interface",
+			interfaceIndexLabel, interfaceNameLabel, vlanLabel,
+		),
+		unknownUnicast: newDesc(
+			"unknown_unicast_"+kind+"_"+unit,
+			"Counter "+kind+" unknown unicast "+unit+" by interface",
+			interfaceIndexLabel, interfaceNameLabel, vlanLabel,
+		),
+		value: value,
 	}
-
-	return nil
 }
 
-func newStormControlCollector(statsLoader StatsLoader) *StormControlCollector {
+func newStormControlCollector(statsLoader StatsLoader, stateLoader WatcherStateLoader, rateWindows []int) *StormControlCollector {
 	collector := StormControlCollector{
 		statsLoader: statsLoader,
+		stateLoader: stateLoader,
+		rateWindows: rateWindows,
 		log:         logger.GetLogger().With(slog.String(logger.Component, "prometheus-collector")),
 
-		BroadcastPassedPackets: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Namespace: metricsNamespace,
-				Name:      "broadcast_passed_packets",
-				Help:      "Counter passed broadcast packets by interface",
-			},
-			[]string{interfaceIndexLabel, interfaceNameLabel},
+		PassedPackets: newTrafficDescs("passed", "packets", "Passed", func(info ebpfloader.TrafInfo) uint64 {
+			return info.Passed
+		}),
+		DroppedPackets: newTrafficDescs("dropped", "packets", "Dropped", func(info ebpfloader.TrafInfo) uint64 {
+			return info.Dropped
+		}),
+		PassedBytes: newTrafficDescs("passed", "bytes", "Passed", func(info ebpfloader.TrafInfo) uint64 {
+			return info.PassedBytes
+		}),
+		DroppedBytes: newTrafficDescs("dropped", "bytes", "Dropped", func(info ebpfloader.TrafInfo) uint64 {
+			return info.DroppedBytes
+		}),
+		TrafficBlockedByInterface: newDesc(
+			"traffic_blocked_status",
+			"Status of blocked config for specific type of packets (0 unblocked, 1 blocked, 2 rate limited)",
+			interfaceIndexLabel, interfaceNameLabel, vlanLabel, trafficTypeLabel,
 		),
-		BroadcastDroppedPackets: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Namespace: metricsNamespace,
-				Name:      "broadcast_dropped_packets",
-				Help:      "Counter dropped broadcast packets by interface",
-			},
-			[]string{interfaceIndexLabel, interfaceNameLabel},
+		AttachedLinks: newDesc(
+			"list_attached_interfaces",
+			"List of attached interfaces",
+			interfaceIndexLabel, interfaceNameLabel, attachModeLabel,
 		),
-		MulticastPassedPacketsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Namespace: metricsNamespace,
-				Name:      "multicast_passed_packets_total",
-				Help:      "Total passed multicast packets for interface",
-			},
-			[]string{interfaceIndexLabel, interfaceNameLabel},
+		BlockBackoffLevel: newDesc(
+			"block_backoff_level",
+			"Current block duration backoff level for specific type of packets",
+			interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel,
 		),
-		MulticastDroppedPacketsTotal: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
-				Namespace: metricsNamespace,
-				Name:      "multicast_dropped_packets_total",
-				Help:      "Total dropped multicast packets for interface",
-			},
-			[]string{interfaceIndexLabel, interfaceNameLabel},
+		PassedPacketsRate: newDesc(
+			"passed_packets_rate",
+			"Passed packets per second of specific type of traffic in last second",
+			interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel,
+		),
+		PassedPacketsRatePeak: newDesc(
+			"passed_packets_rate_peak",
+			"Peak passed packets per second of specific type of traffic over window",
+			interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel, windowLabel,
+		),
+		PassedPacketsRateAverage: newDesc(
+			"passed_packets_rate_avg",
+			"Average passed packets per second of specific type of traffic over window",
+			interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel, windowLabel,
 		),
-		MulticastPassedPacketsByType: prometheus.NewCounterVec(
+		PassedBytesRate: newDesc(
+			"passed_bytes_rate",
+			"Passed bytes per second of specific type of traffic in last second",
+			interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel,
+		),
+		PassedBytesRatePeak: newDesc(
+			"passed_bytes_rate_peak",
+			"Peak passed bytes per second of specific type of traffic over window",
+			interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel, windowLabel,
+		),
+		PassedBytesRateAverage: newDesc(
+			"passed_bytes_rate_avg",
+			"Average passed bytes per second of specific type of traffic over window",
+			interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel, windowLabel,
+		),
+		TopTalkerPacketsRate: newDesc(
+			"top_talker_packets_rate",
+			"Broadcast and multicast packets per second received from source address in last second",
+			interfaceIndexLabel, interfaceNameLabel, macLabel,
+		),
+		TopTalkerBytesRate: newDesc(
+			"top_talker_bytes_rate",
+			"Broadcast and multicast bytes per second received from source address in last second",
+			interfaceIndexLabel, interfaceNameLabel, macLabel,
+		),
+		BlockEventsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: metricsNamespace,
-				Name:      "multicast_passed_packets_by_type",
-				Help:      "Passed multicast packets for interface by traffic type",
+				Name:      "block_events_total",
+				Help:      "Total block events for specific type of packets by interface and source of block",
 			},
-			[]string{interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel},
+			[]string{interfaceIndexLabel, interfaceNameLabel, vlanLabel, trafficTypeLabel, sourceLabel, dryRunLabel},
 		),
-		MulticastDroppedPacketsByType: prometheus.NewCounterVec(
-			prometheus.CounterOpts{
+		BlockDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
 				Namespace: metricsNamespace,
-				Name:      "multicast_dropped_packets_by_type",
-				Help:      "Dropped multicast packets for interface by traffic type",
+				Name:      "block_duration_seconds",
+				Help:      "Duration of traffic blocks for specific type of packets",
+				Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600},
 			},
-			[]string{interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel},
+			[]string{trafficTypeLabel, dryRunLabel},
 		),
-		TrafficBlockedByInterface: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
+		UnblockRecheckFailedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
 				Namespace: metricsNamespace,
-				Name:      "traffic_blocked_status",
-				Help:      "Status of blocked config for specific type of packets (0 unblocked, 1 blocked)",
+				Name:      "unblock_recheck_failed_total",
+				Help:      "Total unblock attempts which failed recheck of dropped traffic rate",
 			},
-			[]string{interfaceIndexLabel, interfaceNameLabel, trafficTypeLabel},
+			[]string{interfaceIndexLabel, interfaceNameLabel, vlanLabel, trafficTypeLabel, dryRunLabel},
 		),
-		AttachedLinks: prometheus.NewGaugeVec(
+		LastBlockTimestamp: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: metricsNamespace,
-				Name:      "list_attached_interfaces",
-				Help:      "List of attached interfaces",
+				Name:      "last_block_timestamp_seconds",
+				Help:      "Unix time of last block for specific type of packets",
 			},
-			[]string{interfaceIndexLabel, interfaceNameLabel},
+			[]string{interfaceIndexLabel, interfaceNameLabel, vlanLabel, trafficTypeLabel, dryRunLabel},
 		),
+		eventNetDevs: make(map[int]struct{}),
 	}
 
 	return &collector
@@ -130,201 +253,275 @@ func (s *StormControlCollector) Initialized() bool {
 }
 func (s *StormControlCollector) Name() string { return "storm-control-exporter" }
 
-func (s *StormControlCollector) collectorList() []prometheus.Collector {
-	return []prometheus.Collector{
-		s.BroadcastPassedPackets,
-		s.MulticastPassedPacketsByType,
-		s.MulticastPassedPacketsTotal,
-
-		s.BroadcastDroppedPackets,
-		s.MulticastDroppedPacketsByType,
-		s.MulticastDroppedPacketsTotal,
+func (s *StormControlCollector) trafficDescsList() []*trafficDescs {
+	return []*trafficDescs{&s.PassedPackets, &s.DroppedPackets, &s.PassedBytes, &s.DroppedBytes}
+}
 
+func (s *StormControlCollector) descList() []*prometheus.Desc {
+	result := []*prometheus.Desc{
 		s.TrafficBlockedByInterface,
 		s.AttachedLinks,
+
+		s.BlockBackoffLevel,
+
+		s.PassedPacketsRate,
+		s.PassedPacketsRatePeak,
+		s.PassedPacketsRateAverage,
+		s.PassedBytesRate,
+		s.PassedBytesRatePeak,
+		s.PassedBytesRateAverage,
+
+		s.TopTalkerPacketsRate,
+		s.TopTalkerBytesRate,
+	}
+	for _, descs := range s.trafficDescsList() {
+		result = append(result, descs.broadcast, descs.broadcastByType, descs.byType, descs.total, descs.unknownUnicast)
+	}
+
+	return result
+}
+
+func (s *StormControlCollector) eventCollectorList() []prometheus.Collector {
+	return []prometheus.Collector{
+		s.BlockEventsTotal,
+		s.BlockDuration,
+		s.UnblockRecheckFailedTotal,
+		s.LastBlockTimestamp,
+	}
+}
+
+// Notify updates event metrics by watcher event
+func (s *StormControlCollector) Notify(event events.Event) {
+	dryRun := strconv.FormatBool(event.DryRun)
+	vlan := ""
+	if event.VLAN != nil {
+		vlan = strconv.Itoa(int(*event.VLAN))
+	}
+	labels := prometheus.Labels{
+		interfaceIndexLabel: strconv.Itoa(event.Index),
+		interfaceNameLabel:  event.Interface,
+		vlanLabel:           vlan,
+		trafficTypeLabel:    event.TrafficType,
+		dryRunLabel:         dryRun,
+	}
+	switch event.Action {
+	case events.ActionBlock:
+		s.BlockEventsTotal.With(
+			prometheus.Labels{
+				interfaceIndexLabel: strconv.Itoa(event.Index),
+				interfaceNameLabel:  event.Interface,
+				vlanLabel:           vlan,
+				trafficTypeLabel:    event.TrafficType,
+				sourceLabel:         event.Source,
+				dryRunLabel:         dryRun,
+			},
+		).Inc()
+		s.LastBlockTimestamp.With(labels).Set(float64(event.Time.UnixNano()) / float64(time.Second))
+	case events.ActionUnblock:
+		// duration of block is unknown for blocks made before restart,
+		// duration of manual unblock is time of block suppression
+		if event.Source == events.SourceAuto && event.Duration > 0 {
+			s.BlockDuration.With(prometheus.Labels{trafficTypeLabel: event.TrafficType, dryRunLabel: dryRun}).Observe(event.Duration.Seconds())
+		}
+
+		return
+	case events.ActionRecheckFailed:
+		s.UnblockRecheckFailedTotal.With(labels).Inc()
+	default:
+		return
+	}
+	s.eventNetDevsMux.Lock()
+	s.eventNetDevs[event.Index] = struct{}{}
+	s.eventNetDevsMux.Unlock()
+}
+
+// removeDetachedEventMetrics removes event metrics of interfaces which are not attached anymore
+func (s *StormControlCollector) removeDetachedEventMetrics(stats ebpfloader.Statistic) {
+	s.eventNetDevsMux.Lock()
+	defer s.eventNetDevsMux.Unlock()
+	for index := range s.eventNetDevs {
+		if _, ok := stats.CounterStat[uint32(index)]; ok {
+			continue
+		}
+		labels := prometheus.Labels{interfaceIndexLabel: strconv.Itoa(index)}
+		s.BlockEventsTotal.DeletePartialMatch(labels)
+		s.UnblockRecheckFailedTotal.DeletePartialMatch(labels)
+		s.LastBlockTimestamp.DeletePartialMatch(labels)
+		delete(s.eventNetDevs, index)
 	}
 }
 
 func (s *StormControlCollector) Describe(ch chan<- *prometheus.Desc) {
-	for _, metric := range s.collectorList() {
+	for _, desc := range s.descList() {
+		ch <- desc
+	}
+	for _, metric := range s.eventCollectorList() {
 		metric.Describe(ch)
 	}
 }
 
-func (s *StormControlCollector) calcPassedStatsForNetDev(stats *ebpfloader.PacketCounter, netDev *net.Interface) {
-	s.BroadcastPassedPackets.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-		},
-	).Add(float64(stats.Broadcast.Passed))
-
-	s.MulticastPassedPacketsByType.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-			trafficTypeLabel:    ipv4MulticastType,
-		},
-	).Add(float64(stats.IPv4MCast.Passed))
-
-	s.MulticastPassedPacketsByType.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-			trafficTypeLabel:    ipv6MulticastType,
-		},
-	).Add(float64(stats.IPv6MCast.Passed))
-
-	s.MulticastPassedPacketsByType.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-			trafficTypeLabel:    otherMulticastType,
-		},
-	).Add(float64(stats.OtherMcast.Passed))
-
-	s.MulticastPassedPacketsTotal.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-		},
-	).Add(float64(stats.IPv4MCast.Passed + stats.IPv6MCast.Passed + stats.OtherMcast.Passed))
+// netDevNames returns names of interfaces by index from watcher cache,
+// interfaces are listed only if watcher is not set
+func (s *StormControlCollector) netDevNames() (map[int]string, error) {
+	if s.stateLoader != nil {
+		return s.stateLoader.NetDevNames(), nil
+	}
+	netDevList, err := listInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int]string, len(netDevList))
+	for _, netDev := range netDevList {
+		result[netDev.Index] = netDev.Name
+	}
+
+	return result, nil
+}
+
+// collect sends counters by type, totals include sub-classes of broadcast and multicast.
+// Unknown unicast is not included in broadcast and multicast totals.
+func (t *trafficDescs) collect(metricChan chan<- prometheus.Metric, stats *ebpfloader.PacketCounter, index, name, vlan string) {
+	broadcast := map[string]uint64{
+		broadcastType: t.value(stats.Broadcast),
+		arpType:       t.value(stats.ARP),
+		dhcpv4Type:    t.value(stats.DHCPv4),
+	}
+	multicast := map[string]uint64{
+		ipv4MulticastType:  t.value(stats.IPv4MCast),
+		ipv6MulticastType:  t.value(stats.IPv6MCast),
+		otherMulticastType: t.value(stats.OtherMcast),
+		ipv6NDType:         t.value(stats.IPv6ND),
+		mldType:            t.value(stats.MLD),
+		ipv6RAType:         t.value(stats.IPv6RA),
+	}
+	var broadcastTotal, multicastTotal uint64
+	for trafficType, value := range broadcast {
+		broadcastTotal += value
+		metricChan <- prometheus.MustNewConstMetric(t.broadcastByType, prometheus.CounterValue, float64(value), index, name, vlan, trafficType)
+	}
+	for trafficType, value := range multicast {
+		multicastTotal += value
+		metricChan <- prometheus.MustNewConstMetric(t.byType, prometheus.CounterValue, float64(value), index, name, vlan, trafficType)
+	}
+	metricChan <- prometheus.MustNewConstMetric(t.broadcast, prometheus.CounterValue, float64(broadcastTotal), index, name, vlan)
+	metricChan <- prometheus.MustNewConstMetric(t.total, prometheus.CounterValue, float64(multicastTotal), index, name, vlan)
+	metricChan <- prometheus.MustNewConstMetric(t.unknownUnicast, prometheus.CounterValue, float64(t.value(stats.UnknownUcast)), index, name, vlan)
 }
 
-func (s *StormControlCollector) calcDroppedStatsForNetDev(stats *ebpfloader.PacketCounter, netDev *net.Interface) {
-	s.BroadcastDroppedPackets.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-		},
-	).Add(float64(stats.Broadcast.Dropped))
-
-	s.MulticastDroppedPacketsByType.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-			trafficTypeLabel:    ipv4MulticastType,
-		},
-	).Add(float64(stats.IPv4MCast.Dropped))
-
-	s.MulticastDroppedPacketsByType.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-			trafficTypeLabel:    ipv6MulticastType,
-		},
-	).Add(float64(stats.IPv6MCast.Dropped))
-
-	s.MulticastDroppedPacketsByType.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-			trafficTypeLabel:    otherMulticastType,
-		},
-	).Add(float64(stats.OtherMcast.Dropped))
-
-	s.MulticastDroppedPacketsTotal.With(
-		prometheus.Labels{
-			interfaceIndexLabel: strconv.Itoa(netDev.Index),
-			interfaceNameLabel:  netDev.Name,
-		},
-	).Add(float64(stats.IPv4MCast.Dropped + stats.IPv6MCast.Dropped + stats.OtherMcast.Dropped))
+func (s *StormControlCollector) collectDropConfig(metricChan chan<- prometheus.Metric, dropConf ebpfloader.DropPKT, index, name, vlan string) {
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.Broadcast), index, name, vlan, broadcastType)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.IPv4MCast), index, name, vlan, ipv4MulticastType)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.IPv6MCast), index, name, vlan, ipv6MulticastType)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.Multicast), index, name, vlan, otherMulticastType)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.ARP), index, name, vlan, arpType)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.DHCPv4), index, name, vlan, dhcpv4Type)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.IPv6ND), index, name, vlan, ipv6NDType)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.MLD), index, name, vlan, mldType)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.IPv6RA), index, name, vlan, ipv6RAType)
+	metricChan <- prometheus.MustNewConstMetric(s.TrafficBlockedByInterface, prometheus.GaugeValue, float64(dropConf.UnknownUcast), index, name, vlan, unknownUnicastType)
 }
 
-func (s *StormControlCollector) collectStats(stats ebpfloader.Statistic, netDevList []net.Interface) {
-	for index, stats := range stats.CounterStat {
-		if netDev := findInterface(netDevList, index); netDev != nil {
-			s.calcPassedStatsForNetDev(&stats, netDev)
-			s.calcDroppedStatsForNetDev(&stats, netDev)
+// collectNetDevs sends eBPF statistic of attached interfaces with known names.
+// Counters of interface are sent with empty VLAN label, VLAN aware interfaces also have counters of every VLAN.
+// Status of blocks is sent for interface and every VLAN.
+func (s *StormControlCollector) collectNetDevs(metricChan chan<- prometheus.Metric, stats ebpfloader.Statistic, netDevNames map[int]string) {
+	vlanCounters := make(map[uint32]map[uint16]ebpfloader.PacketCounter)
+	for key, counter := range stats.VLANCounterStat {
+		if _, ok := vlanCounters[key.Ifindex]; !ok {
+			vlanCounters[key.Ifindex] = make(map[uint16]ebpfloader.PacketCounter)
+		}
+		vlanCounters[key.Ifindex][key.VLAN] = counter
+	}
+	for netDevIndex, counter := range stats.CounterStat {
+		name, ok := netDevNames[int(netDevIndex)]
+		if !ok {
+			continue
+		}
+		index := strconv.FormatUint(uint64(netDevIndex), 10)
+		for _, descs := range s.trafficDescsList() {
+			descs.collect(metricChan, &counter, index, name, "")
+			for vlan, vlanCounter := range vlanCounters[netDevIndex] {
+				descs.collect(metricChan, &vlanCounter, index, name, strconv.Itoa(int(vlan)))
+			}
+		}
+		attachMode, ok := stats.AttachModes[netDevIndex]
+		if !ok {
+			attachMode = unknownAttachMode
+		}
+		metricChan <- prometheus.MustNewConstMetric(s.AttachedLinks, prometheus.GaugeValue, 1, index, name, attachMode)
+		if dropConf, ok := stats.DropConf[netDevIndex]; ok {
+			s.collectDropConfig(metricChan, dropConf, index, name, "")
+		}
+	}
+	for key, dropConf := range stats.VLANDropConf {
+		if _, ok := stats.CounterStat[key.Ifindex]; !ok {
+			continue
+		}
+		if name, ok := netDevNames[int(key.Ifindex)]; ok {
+			s.collectDropConfig(metricChan, dropConf, strconv.FormatUint(uint64(key.Ifindex), 10), name, strconv.Itoa(int(key.VLAN)))
 		}
 	}
 }
 
-func (s *StormControlCollector) collectDropConfig(stats ebpfloader.Statistic, netDevList []net.Interface) {
-	for index, stats := range stats.DropConf {
-		if netDev := findInterface(netDevList, index); netDev != nil {
-			s.TrafficBlockedByInterface.With(
-				prometheus.Labels{
-					interfaceIndexLabel: strconv.Itoa(int(index)),
-					interfaceNameLabel:  netDev.Name,
-					trafficTypeLabel:    broadcastType,
-				},
-			).Set(float64(stats.Broadcast))
-
-			s.TrafficBlockedByInterface.With(
-				prometheus.Labels{
-					interfaceIndexLabel: strconv.Itoa(int(index)),
-					interfaceNameLabel:  netDev.Name,
-					trafficTypeLabel:    ipv4MulticastType,
-				},
-			).Set(float64(stats.IPv4MCast))
-
-			s.TrafficBlockedByInterface.With(
-				prometheus.Labels{
-					interfaceIndexLabel: strconv.Itoa(int(index)),
-					interfaceNameLabel:  netDev.Name,
-					trafficTypeLabel:    ipv6MulticastType,
-				},
-			).Set(float64(stats.IPv6MCast))
-
-			s.TrafficBlockedByInterface.With(
-				prometheus.Labels{
-					interfaceIndexLabel: strconv.Itoa(int(index)),
-					interfaceNameLabel:  netDev.Name,
-					trafficTypeLabel:    otherMulticastType,
-				},
-			).Set(float64(stats.Multicast))
+func (s *StormControlCollector) collectBackoffLevels(metricChan chan<- prometheus.Metric, netDevStates []watcher.NetDevState) {
+	for _, netDevState := range netDevStates {
+		index := strconv.Itoa(netDevState.Index)
+		for trafficType, level := range netDevState.BackoffLevels {
+			metricChan <- prometheus.MustNewConstMetric(s.BlockBackoffLevel, prometheus.GaugeValue, float64(level), index, netDevState.Name, trafficType)
 		}
 	}
 }
 
-func (s *StormControlCollector) collectAttachedInterfaces(stats ebpfloader.Statistic, netDevList []net.Interface) {
-	for index := range stats.CounterStat {
-		if netDev := findInterface(netDevList, index); netDev != nil {
-			s.AttachedLinks.With(
-				prometheus.Labels{
-					interfaceIndexLabel: strconv.Itoa(int(index)),
-					interfaceNameLabel:  netDev.Name,
-				},
-			).Set(1)
+func (s *StormControlCollector) collectRates(metricChan chan<- prometheus.Metric, netDevRates []watcher.NetDevRates) {
+	for _, netDevRate := range netDevRates {
+		index := strconv.Itoa(netDevRate.Index)
+		for trafficType, rate := range netDevRate.Latest {
+			metricChan <- prometheus.MustNewConstMetric(s.PassedPacketsRate, prometheus.GaugeValue, rate.Packets, index, netDevRate.Name, trafficType)
+			metricChan <- prometheus.MustNewConstMetric(s.PassedBytesRate, prometheus.GaugeValue, rate.Bytes, index, netDevRate.Name, trafficType)
+		}
+		for _, windowRates := range netDevRate.Windows {
+			window := strconv.Itoa(windowRates.Window) + "s"
+			for trafficType, peak := range windowRates.Peak {
+				average := windowRates.Average[trafficType]
+				labels := []string{index, netDevRate.Name, trafficType, window}
+				metricChan <- prometheus.MustNewConstMetric(s.PassedPacketsRatePeak, prometheus.GaugeValue, peak.Packets, labels...)
+				metricChan <- prometheus.MustNewConstMetric(s.PassedPacketsRateAverage, prometheus.GaugeValue, average.Packets, labels...)
+				metricChan <- prometheus.MustNewConstMetric(s.PassedBytesRatePeak, prometheus.GaugeValue, peak.Bytes, labels...)
+				metricChan <- prometheus.MustNewConstMetric(s.PassedBytesRateAverage, prometheus.GaugeValue, average.Bytes, labels...)
+			}
+		}
+		if !s.topTalkers {
+			continue
+		}
+		for _, talker := range netDevRate.TopTalkers {
+			metricChan <- prometheus.MustNewConstMetric(s.TopTalkerPacketsRate, prometheus.GaugeValue, float64(talker.Rate), index, netDevRate.Name, talker.MAC)
+			metricChan <- prometheus.MustNewConstMetric(s.TopTalkerBytesRate, prometheus.GaugeValue, float64(talker.RateBytes), index, netDevRate.Name, talker.MAC)
 		}
 	}
 }
 
 // Collect sends all the collected metrics to the provided Prometheus channel.
-// It requires the caller to handle synchronization.
 func (s *StormControlCollector) Collect(metricChan chan<- prometheus.Metric) {
-	// Reset current statistic.
-	s.BroadcastPassedPackets.Reset()
-	s.BroadcastDroppedPackets.Reset()
-
-	s.MulticastPassedPacketsByType.Reset()
-	s.MulticastPassedPacketsTotal.Reset()
-
-	s.MulticastDroppedPacketsByType.Reset()
-	s.MulticastDroppedPacketsTotal.Reset()
-
-	s.TrafficBlockedByInterface.Reset()
-	s.AttachedLinks.Reset()
-
 	stats, err := s.statsLoader.GetStatistic()
 	if err != nil {
 		s.log.Errorf("Error collect eBPF statistics: %s", err.Error())
 
 		return
 	}
-	netDevList, err := listInterfaces()
+	netDevNames, err := s.netDevNames()
 	if err != nil {
 		s.log.Errorf("Error get list of network devices: %s", err.Error())
 
 		return
 	}
 
-	s.collectStats(stats, netDevList)
-	s.collectDropConfig(stats, netDevList)
-	s.collectAttachedInterfaces(stats, netDevList)
-
-	for _, metric := range s.collectorList() {
+	s.collectNetDevs(metricChan, stats, netDevNames)
+	if s.stateLoader != nil {
+		s.collectBackoffLevels(metricChan, s.stateLoader.GetNetDevStates())
+		s.collectRates(metricChan, s.stateLoader.GetNetDevRates(s.rateWindows))
+	}
+	s.removeDetachedEventMetrics(stats)
+	for _, metric := range s.eventCollectorList() {
 		metric.Collect(metricChan)
 	}
 }
@@ -10,34 +10,52 @@ import (
 
 	"github.com/mythvcode/storm-control/internal/config"
 	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
 	"github.com/mythvcode/storm-control/internal/logger"
+	"github.com/mythvcode/storm-control/internal/watcher"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type APIServer struct {
-	server *http.Server
-	log    *logger.Logger
-	config config.Exporter
+	server    *http.Server
+	log       *logger.Logger
+	config    config.Exporter
+	collector *StormControlCollector
 }
 
 type StatsLoader interface {
 	GetStatistic() (ebpfloader.Statistic, error)
 }
 
-func New(cfg config.Exporter, statsLoader StatsLoader) (*APIServer, error) {
+type WatcherStateLoader interface {
+	GetNetDevStates() []watcher.NetDevState
+	GetNetDevRates(windows []int) []watcher.NetDevRates
+	// NetDevNames returns names of watched interfaces by index, result must not be modified
+	NetDevNames() map[int]string
+}
+
+// New creates exporter API server, stateLoader is optional.
+func New(cfg config.Exporter, statsLoader StatsLoader, stateLoader WatcherStateLoader) (*APIServer, error) {
 	apiServer := APIServer{
 		log:    logger.GetLogger().With(slog.String(logger.Component, "exporter-api-server")),
 		config: cfg,
 	}
-	collector := newStormControlCollector(statsLoader)
+	for _, window := range cfg.RateWindows {
+		if window < 1 || window > watcher.RateHistorySize {
+			return nil, fmt.Errorf("rate window %d is out of range 1-%d seconds", window, watcher.RateHistorySize)
+		}
+	}
+	collector := newStormControlCollector(statsLoader, stateLoader, cfg.RateWindows)
+	collector.topTalkers = cfg.EnableTopTalkersMetrics
 	if !collector.Initialized() {
 		return nil, fmt.Errorf("collector %s was not initialized", collector.Name())
 	}
 	if err := prometheus.Register(collector); err != nil {
 		return nil, err
 	}
+	apiServer.collector = collector
 
 	httpMux := http.NewServeMux()
 	timeout := time.Duration(cfg.RequestTimeout) * time.Second
@@ -62,6 +80,11 @@ func New(cfg config.Exporter, statsLoader StatsLoader) (*APIServer, error) {
 	return &apiServer, nil
 }
 
+// EventSink returns sink which updates block event metrics
+func (s *APIServer) EventSink() events.Sink {
+	return s.collector
+}
+
 func (s *APIServer) middlewareLogging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(respwr http.ResponseWriter, req *http.Request) {
 		s.log.With(
@@ -1,12 +1,19 @@
 package exporter
 
 import (
+	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
 	"github.com/mythvcode/storm-control/internal/exporter/mocks"
+	"github.com/mythvcode/storm-control/internal/watcher"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -15,7 +22,7 @@ func TestNewExporter(t *testing.T) {
 	mock := mocks.NewMockStatsLoader(t)
 	cfg, err := config.ReadConfig("")
 	require.NoError(t, err)
-	_, err = New(cfg.Exporter, mock)
+	_, err = New(cfg.Exporter, mock, nil)
 	require.NoError(t, err)
 }
 
@@ -26,7 +33,7 @@ func TestCollector(t *testing.T) {
 	mock := mocks.NewMockStatsLoader(t)
 	raw, stats := makeZeroTestValues(t)
 	mock.EXPECT().GetStatistic().Return(stats, nil).Once()
-	collector := newStormControlCollector(mock)
+	collector := newStormControlCollector(mock, nil, nil)
 
 	err := testutil.CollectAndCompare(collector, strings.NewReader(raw))
 	require.NoError(t, err)
@@ -44,7 +51,7 @@ func TestCollectorClearValues(t *testing.T) {
 	mock := mocks.NewMockStatsLoader(t)
 	raw, stats := makeTestValues(t)
 	mock.EXPECT().GetStatistic().Return(stats, nil).Once()
-	collector := newStormControlCollector(mock)
+	collector := newStormControlCollector(mock, nil, nil)
 
 	err := testutil.CollectAndCompare(collector, strings.NewReader(raw))
 	require.NoError(t, err)
@@ -54,3 +61,313 @@ func TestCollectorClearValues(t *testing.T) {
 	err = testutil.CollectAndCompare(collector, strings.NewReader(raw))
 	require.NoError(t, err)
 }
+
+func TestCollectorBackoffLevels(t *testing.T) {
+	statsMock := mocks.NewMockStatsLoader(t)
+	stateMock := mocks.NewMockWatcherStateLoader(t)
+	stateMock.EXPECT().NetDevNames().Return(map[int]string{5653: "tap72cdd785-3a"}).Once()
+	_, stats := makeZeroTestValues(t)
+	statsMock.EXPECT().GetStatistic().Return(stats, nil).Once()
+	stateMock.EXPECT().GetNetDevStates().Return([]watcher.NetDevState{
+		{
+			Index:         5653,
+			Name:          "tap72cdd785-3a",
+			BackoffLevels: map[string]int{broadcastType: 2, ipv4MulticastType: 0},
+		},
+	}).Once()
+	stateMock.EXPECT().GetNetDevRates([]int(nil)).Return(nil).Once()
+	collector := newStormControlCollector(statsMock, stateMock, nil)
+
+	err := testutil.CollectAndCompare(collector, strings.NewReader(collectorTestBackoffValues), "storm_control_block_backoff_level")
+	require.NoError(t, err)
+}
+
+func TestCollectorRates(t *testing.T) {
+	statsMock := mocks.NewMockStatsLoader(t)
+	stateMock := mocks.NewMockWatcherStateLoader(t)
+	stateMock.EXPECT().NetDevNames().Return(map[int]string{5653: "tap72cdd785-3a"}).Once()
+	_, stats := makeZeroTestValues(t)
+	statsMock.EXPECT().GetStatistic().Return(stats, nil).Once()
+	stateMock.EXPECT().GetNetDevStates().Return(nil).Once()
+	stateMock.EXPECT().GetNetDevRates([]int{10}).Return([]watcher.NetDevRates{
+		{
+			Index:  5653,
+			Name:   "tap72cdd785-3a",
+			Latest: map[string]watcher.TrafficRate{broadcastType: {Packets: 120, Bytes: 7680}},
+			Windows: []watcher.WindowRates{
+				{
+					Window:  10,
+					Peak:    map[string]watcher.TrafficRate{broadcastType: {Packets: 5000, Bytes: 320000}},
+					Average: map[string]watcher.TrafficRate{broadcastType: {Packets: 612.5, Bytes: 39200}},
+				},
+			},
+		},
+	}).Once()
+	collector := newStormControlCollector(statsMock, stateMock, []int{10})
+
+	err := testutil.CollectAndCompare(
+		collector,
+		strings.NewReader(collectorTestRateValues),
+		"storm_control_passed_packets_rate",
+		"storm_control_passed_packets_rate_peak",
+		"storm_control_passed_packets_rate_avg",
+		"storm_control_passed_bytes_rate",
+		"storm_control_passed_bytes_rate_peak",
+		"storm_control_passed_bytes_rate_avg",
+	)
+	require.NoError(t, err)
+}
+
+func TestCollectorTopTalkers(t *testing.T) {
+	statsMock := mocks.NewMockStatsLoader(t)
+	stateMock := mocks.NewMockWatcherStateLoader(t)
+	stateMock.EXPECT().NetDevNames().Return(map[int]string{5653: "tap72cdd785-3a"}).Twice()
+	_, stats := makeZeroTestValues(t)
+	statsMock.EXPECT().GetStatistic().Return(stats, nil).Twice()
+	stateMock.EXPECT().GetNetDevStates().Return(nil).Twice()
+	stateMock.EXPECT().GetNetDevRates([]int(nil)).Return([]watcher.NetDevRates{
+		{
+			Index: 5653,
+			Name:  "tap72cdd785-3a",
+			TopTalkers: []events.Talker{
+				{MAC: "fa:16:3e:00:00:02", Rate: 5000, RateBytes: 320000},
+				{MAC: "fa:16:3e:00:00:01", Rate: 12, RateBytes: 768},
+			},
+		},
+	}).Twice()
+	collector := newStormControlCollector(statsMock, stateMock, nil)
+	metrics := []string{"storm_control_top_talker_packets_rate", "storm_control_top_talker_bytes_rate"}
+
+	// metrics are not exported by default
+	require.Zero(t, testutil.CollectAndCount(collector, metrics...))
+	collector.topTalkers = true
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(collectorTestTopTalkersValues), metrics...))
+}
+
+func TestNewExporterRateWindows(t *testing.T) {
+	cfg, err := config.ReadConfig("")
+	require.NoError(t, err)
+	cfg.Exporter.RateWindows = []int{10, watcher.RateHistorySize + 1}
+	_, err = New(cfg.Exporter, mocks.NewMockStatsLoader(t), nil)
+	require.Error(t, err)
+}
+
+func TestCollectorEvents(t *testing.T) {
+	listInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{{Index: 5653, Name: "tap72cdd785-3a"}}, nil
+	}
+	eventMetrics := []string{
+		"storm_control_block_events_total",
+		"storm_control_block_duration_seconds",
+		"storm_control_unblock_recheck_failed_total",
+		"storm_control_last_block_timestamp_seconds",
+	}
+	mock := mocks.NewMockStatsLoader(t)
+	_, stats := makeZeroTestValues(t)
+	mock.EXPECT().GetStatistic().Return(stats, nil).Once()
+	collector := newStormControlCollector(mock, nil, nil)
+
+	event := events.Event{
+		Time:        time.Unix(1735725600, 0),
+		Source:      events.SourceAuto,
+		Interface:   "tap72cdd785-3a",
+		Index:       5653,
+		TrafficType: broadcastType,
+	}
+	for _, action := range []string{events.ActionBlock, events.ActionRecheckFailed, events.ActionBlock} {
+		event.Action = action
+		collector.Notify(event)
+	}
+	event.Action = events.ActionUnblock
+	event.Duration = 90 * time.Second
+	collector.Notify(event)
+	// unblock without known block time is not observed
+	event.Duration = 0
+	collector.Notify(event)
+	// manual unblock is not observed
+	event.Source = events.SourceManual
+	event.Duration = time.Hour
+	collector.Notify(event)
+	event.Duration = 0
+	event.Action = events.ActionBlock
+	event.Source = events.SourceManual
+	event.TrafficType = ipv4MulticastType
+	collector.Notify(event)
+	// decisions of dry run are counted separately
+	event.Source = events.SourceAuto
+	event.DryRun = true
+	collector.Notify(event)
+
+	err := testutil.CollectAndCompare(collector, strings.NewReader(collectorTestEventValues), eventMetrics...)
+	require.NoError(t, err)
+
+	// event metrics are kept between collects and removed with interface
+	mock.EXPECT().GetStatistic().Return(ebpfloader.Statistic{}, nil).Once()
+	err = testutil.CollectAndCompare(collector, strings.NewReader(collectorTestDetachedEventValues), "storm_control_block_duration_seconds")
+	require.NoError(t, err)
+	mock.EXPECT().GetStatistic().Return(ebpfloader.Statistic{}, nil).Once()
+	require.Equal(t, 1, testutil.CollectAndCount(collector, eventMetrics...))
+}
+
+func TestCollectorVLAN(t *testing.T) {
+	listInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{{Index: 5653, Name: "bond0"}}, nil
+	}
+	mock := mocks.NewMockStatsLoader(t)
+	mock.EXPECT().GetStatistic().Return(ebpfloader.Statistic{
+		CounterStat: ebpfloader.CounterStat{5653: {Broadcast: ebpfloader.TrafInfo{Passed: 30}}},
+		DropConf:    ebpfloader.DropConf{5653: {}},
+		VLANCounterStat: ebpfloader.VLANCounterStat{
+			{Ifindex: 5653}:           {Broadcast: ebpfloader.TrafInfo{Passed: 10}},
+			{Ifindex: 5653, VLAN: 20}: {Broadcast: ebpfloader.TrafInfo{Passed: 20}},
+		},
+		VLANDropConf: ebpfloader.VLANDropConf{{Ifindex: 5653, VLAN: 20}: {Broadcast: ebpfloader.ActionDrop}},
+	}, nil).Twice()
+	collector := newStormControlCollector(mock, nil, nil)
+	vlan := uint16(20)
+	collector.Notify(events.Event{
+		Time:        time.Unix(1735725600, 0),
+		Action:      events.ActionBlock,
+		Source:      events.SourceAuto,
+		Interface:   "bond0",
+		Index:       5653,
+		VLAN:        &vlan,
+		TrafficType: broadcastType,
+	})
+
+	// counters of VLAN aware interface are sent for interface and by VLAN
+	err := testutil.CollectAndCompare(collector, strings.NewReader(`
+# HELP storm_control_block_events_total Total block events for specific type of packets by interface and source of block
+# TYPE storm_control_block_events_total counter
+storm_control_block_events_total{dry_run="false",interface_index="5653",interface_name="bond0",source="auto",traffic_type="broadcast",vlan="20"} 1
+# HELP storm_control_broadcast_passed_packets Counter passed broadcast packets by interface
+# TYPE storm_control_broadcast_passed_packets counter
+storm_control_broadcast_passed_packets{interface_index="5653",interface_name="bond0",vlan=""} 30
+storm_control_broadcast_passed_packets{interface_index="5653",interface_name="bond0",vlan="0"} 10
+storm_control_broadcast_passed_packets{interface_index="5653",interface_name="bond0",vlan="20"} 20
+`), "storm_control_broadcast_passed_packets", "storm_control_block_events_total")
+	require.NoError(t, err)
+	// status of interface and VLAN blocks
+	require.Equal(t, 20, testutil.CollectAndCount(collector, "storm_control_traffic_blocked_status"))
+}
+
+const benchmarkNetDevCount = 2000
+
+func makeBenchmarkCollector(b *testing.B) (*StormControlCollector, ebpfloader.Statistic, []net.Interface) {
+	b.Helper()
+	stats := ebpfloader.Statistic{
+		CounterStat: make(ebpfloader.CounterStat, benchmarkNetDevCount),
+		DropConf:    make(ebpfloader.DropConf, benchmarkNetDevCount),
+		AttachModes: make(ebpfloader.AttachModes, benchmarkNetDevCount),
+	}
+	names := make(map[int]string, benchmarkNetDevCount)
+	netDevList := make([]net.Interface, 0, benchmarkNetDevCount)
+	for index := range uint32(benchmarkNetDevCount) {
+		stats.CounterStat[index] = ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: uint64(index)}}
+		stats.DropConf[index] = ebpfloader.DropPKT{}
+		stats.AttachModes[index] = ebpfloader.AttachModeNative
+		names[int(index)] = fmt.Sprintf("tap%08d-00", index)
+		netDevList = append(netDevList, net.Interface{Index: int(index), Name: names[int(index)]})
+	}
+	statsMock := mocks.NewMockStatsLoader(b)
+	stateMock := mocks.NewMockWatcherStateLoader(b)
+	statsMock.EXPECT().GetStatistic().Return(stats, nil)
+	stateMock.EXPECT().NetDevNames().Return(names)
+	stateMock.EXPECT().GetNetDevStates().Return(nil)
+	stateMock.EXPECT().GetNetDevRates([]int(nil)).Return(nil)
+
+	return newStormControlCollector(statsMock, stateMock, nil), stats, netDevList
+}
+
+func benchmarkMetricChan() (chan prometheus.Metric, func()) {
+	metricChan := make(chan prometheus.Metric, 1024)
+	done := make(chan struct{})
+	go func() {
+		for range metricChan {
+		}
+		close(done)
+	}()
+
+	return metricChan, func() {
+		close(metricChan)
+		<-done
+	}
+}
+
+func BenchmarkCollector(b *testing.B) {
+	collector, _, _ := makeBenchmarkCollector(b)
+	metricChan, stop := benchmarkMetricChan()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		collector.Collect(metricChan)
+	}
+	stop()
+}
+
+// vectorSeries is series of metric vector set by baseline benchmark
+type vectorSeries struct {
+	vec    *prometheus.GaugeVec
+	labels prometheus.Labels
+	value  float64
+}
+
+// BenchmarkCollectorVectors is baseline of BenchmarkCollector, it sends the same series
+// by resetting metric vectors and searching interfaces in list of interfaces on every collect
+func BenchmarkCollectorVectors(b *testing.B) {
+	collector, stats, netDevList := makeBenchmarkCollector(b)
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(b, registry.Register(collector))
+	families, err := registry.Gather()
+	require.NoError(b, err)
+	vecs := make([]*prometheus.GaugeVec, 0, len(families))
+	series := make(map[uint32][]vectorSeries, benchmarkNetDevCount)
+	for _, family := range families {
+		labelNames := make([]string, 0, len(family.GetMetric()[0].GetLabel()))
+		for _, label := range family.GetMetric()[0].GetLabel() {
+			labelNames = append(labelNames, label.GetName())
+		}
+		vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: family.GetName(), Help: family.GetHelp()}, labelNames)
+		vecs = append(vecs, vec)
+		for _, metric := range family.GetMetric() {
+			labels := make(prometheus.Labels, len(labelNames))
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			index, err := strconv.ParseUint(labels[interfaceIndexLabel], 10, 32)
+			require.NoError(b, err)
+			value := metric.GetGauge().GetValue() + metric.GetCounter().GetValue()
+			series[uint32(index)] = append(series[uint32(index)], vectorSeries{vec: vec, labels: labels, value: value})
+		}
+	}
+	findInterface := func(index uint32) *net.Interface {
+		for _, netDev := range netDevList {
+			if netDev.Index == int(index) {
+				return &netDev
+			}
+		}
+
+		return nil
+	}
+	metricChan, stop := benchmarkMetricChan()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, vec := range vecs {
+			vec.Reset()
+		}
+		// interfaces were searched by counters, drop configs and attach modes
+		for index := range stats.CounterStat {
+			for range 3 {
+				findInterface(index)
+			}
+			for _, vecSeries := range series[index] {
+				vecSeries.vec.With(vecSeries.labels).Set(vecSeries.value)
+			}
+		}
+		for _, vec := range vecs {
+			vec.Collect(metricChan)
+		}
+	}
+	stop()
+}
@@ -7,71 +7,222 @@ import (
 )
 
 const collectorTestZeroValues = `
+# HELP storm_control_broadcast_dropped_bytes Counter dropped broadcast bytes by interface
+# TYPE storm_control_broadcast_dropped_bytes counter
+storm_control_broadcast_dropped_bytes{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_broadcast_dropped_bytes_by_type Dropped broadcast bytes for interface by traffic type
+# TYPE storm_control_broadcast_dropped_bytes_by_type counter
+storm_control_broadcast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 0
+storm_control_broadcast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 0
+storm_control_broadcast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 0
 # HELP storm_control_broadcast_dropped_packets Counter dropped broadcast packets by interface
 # TYPE storm_control_broadcast_dropped_packets counter
-storm_control_broadcast_dropped_packets{interface_index="5653",interface_name="tap72cdd785-3a"} 0
+storm_control_broadcast_dropped_packets{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_broadcast_dropped_packets_by_type Dropped broadcast packets for interface by traffic type
+# TYPE storm_control_broadcast_dropped_packets_by_type counter
+storm_control_broadcast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 0
+storm_control_broadcast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 0
+storm_control_broadcast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 0
+# HELP storm_control_broadcast_passed_bytes Counter passed broadcast bytes by interface
+# TYPE storm_control_broadcast_passed_bytes counter
+storm_control_broadcast_passed_bytes{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_broadcast_passed_bytes_by_type Passed broadcast bytes for interface by traffic type
+# TYPE storm_control_broadcast_passed_bytes_by_type counter
+storm_control_broadcast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 0
+storm_control_broadcast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 0
+storm_control_broadcast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 0
 # HELP storm_control_broadcast_passed_packets Counter passed broadcast packets by interface
 # TYPE storm_control_broadcast_passed_packets counter
-storm_control_broadcast_passed_packets{interface_index="5653",interface_name="tap72cdd785-3a"} 0
+storm_control_broadcast_passed_packets{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_broadcast_passed_packets_by_type Passed broadcast packets for interface by traffic type
+# TYPE storm_control_broadcast_passed_packets_by_type counter
+storm_control_broadcast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 0
+storm_control_broadcast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 0
+storm_control_broadcast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 0
 # HELP storm_control_list_attached_interfaces List of attached interfaces
 # TYPE storm_control_list_attached_interfaces gauge
-storm_control_list_attached_interfaces{interface_index="5653",interface_name="tap72cdd785-3a"} 1
+storm_control_list_attached_interfaces{attach_mode="unknown",interface_index="5653",interface_name="tap72cdd785-3a"} 1
+# HELP storm_control_multicast_dropped_bytes_by_type Dropped multicast bytes for interface by traffic type
+# TYPE storm_control_multicast_dropped_bytes_by_type counter
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 0
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 0
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 0
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 0
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 0
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 0
+# HELP storm_control_multicast_dropped_bytes_total Total dropped multicast bytes for interface
+# TYPE storm_control_multicast_dropped_bytes_total counter
+storm_control_multicast_dropped_bytes_total{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
 # HELP storm_control_multicast_dropped_packets_by_type Dropped multicast packets for interface by traffic type
 # TYPE storm_control_multicast_dropped_packets_by_type counter
-storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast"} 0
-storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast"} 0
-storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast"} 0
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 0
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 0
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 0
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 0
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 0
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 0
 # HELP storm_control_multicast_dropped_packets_total Total dropped multicast packets for interface
 # TYPE storm_control_multicast_dropped_packets_total counter
-storm_control_multicast_dropped_packets_total{interface_index="5653",interface_name="tap72cdd785-3a"} 0
+storm_control_multicast_dropped_packets_total{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_multicast_passed_bytes_by_type Passed multicast bytes for interface by traffic type
+# TYPE storm_control_multicast_passed_bytes_by_type counter
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 0
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 0
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 0
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 0
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 0
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 0
+# HELP storm_control_multicast_passed_bytes_total Total passed multicast bytes for interface
+# TYPE storm_control_multicast_passed_bytes_total counter
+storm_control_multicast_passed_bytes_total{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
 # HELP storm_control_multicast_passed_packets_by_type Passed multicast packets for interface by traffic type
 # TYPE storm_control_multicast_passed_packets_by_type counter
-storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast"} 0
-storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast"} 0
-storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast"} 0
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 0
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 0
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 0
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 0
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 0
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 0
 # HELP storm_control_multicast_passed_packets_total Total passed multicast packets for interface
 # TYPE storm_control_multicast_passed_packets_total counter
-storm_control_multicast_passed_packets_total{interface_index="5653",interface_name="tap72cdd785-3a"} 0
-# HELP storm_control_traffic_blocked_status Status of blocked config for specific type of packets (0 unblocked, 1 blocked)
+storm_control_multicast_passed_packets_total{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_traffic_blocked_status Status of blocked config for specific type of packets (0 unblocked, 1 blocked, 2 rate limited)
 # TYPE storm_control_traffic_blocked_status gauge
-storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast"} 0
-storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast"} 0
-storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast"} 0
-storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast"} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="unknown_unicast",vlan=""} 0
+# HELP storm_control_unknown_unicast_dropped_bytes Counter dropped unknown unicast bytes by interface
+# TYPE storm_control_unknown_unicast_dropped_bytes counter
+storm_control_unknown_unicast_dropped_bytes{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_unknown_unicast_dropped_packets Counter dropped unknown unicast packets by interface
+# TYPE storm_control_unknown_unicast_dropped_packets counter
+storm_control_unknown_unicast_dropped_packets{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_unknown_unicast_passed_bytes Counter passed unknown unicast bytes by interface
+# TYPE storm_control_unknown_unicast_passed_bytes counter
+storm_control_unknown_unicast_passed_bytes{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
+# HELP storm_control_unknown_unicast_passed_packets Counter passed unknown unicast packets by interface
+# TYPE storm_control_unknown_unicast_passed_packets counter
+storm_control_unknown_unicast_passed_packets{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 0
 `
 
 const collectorTestValues = `
+# HELP storm_control_broadcast_dropped_bytes Counter dropped broadcast bytes by interface
+# TYPE storm_control_broadcast_dropped_bytes counter
+storm_control_broadcast_dropped_bytes{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 16800
+# HELP storm_control_broadcast_dropped_bytes_by_type Dropped broadcast bytes for interface by traffic type
+# TYPE storm_control_broadcast_dropped_bytes_by_type counter
+storm_control_broadcast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 0
+storm_control_broadcast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 3200
+storm_control_broadcast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 13600
 # HELP storm_control_broadcast_dropped_packets Counter dropped broadcast packets by interface
 # TYPE storm_control_broadcast_dropped_packets counter
-storm_control_broadcast_dropped_packets{interface_index="5653",interface_name="tap72cdd785-3a"} 50
+storm_control_broadcast_dropped_packets{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 90
+# HELP storm_control_broadcast_dropped_packets_by_type Dropped broadcast packets for interface by traffic type
+# TYPE storm_control_broadcast_dropped_packets_by_type counter
+storm_control_broadcast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 0
+storm_control_broadcast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 50
+storm_control_broadcast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 40
+# HELP storm_control_broadcast_passed_bytes Counter passed broadcast bytes by interface
+# TYPE storm_control_broadcast_passed_bytes counter
+storm_control_broadcast_passed_bytes{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 9380
+# HELP storm_control_broadcast_passed_bytes_by_type Passed broadcast bytes for interface by traffic type
+# TYPE storm_control_broadcast_passed_bytes_by_type counter
+storm_control_broadcast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 1280
+storm_control_broadcast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 6400
+storm_control_broadcast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 1700
 # HELP storm_control_broadcast_passed_packets Counter passed broadcast packets by interface
 # TYPE storm_control_broadcast_passed_packets counter
-storm_control_broadcast_passed_packets{interface_index="5653",interface_name="tap72cdd785-3a"} 100
+storm_control_broadcast_passed_packets{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 125
+# HELP storm_control_broadcast_passed_packets_by_type Passed broadcast packets for interface by traffic type
+# TYPE storm_control_broadcast_passed_packets_by_type counter
+storm_control_broadcast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 20
+storm_control_broadcast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 100
+storm_control_broadcast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 5
 # HELP storm_control_list_attached_interfaces List of attached interfaces
 # TYPE storm_control_list_attached_interfaces gauge
-storm_control_list_attached_interfaces{interface_index="5653",interface_name="tap72cdd785-3a"} 1
+storm_control_list_attached_interfaces{attach_mode="native",interface_index="5653",interface_name="tap72cdd785-3a"} 1
+# HELP storm_control_multicast_dropped_bytes_by_type Dropped multicast bytes for interface by traffic type
+# TYPE storm_control_multicast_dropped_bytes_by_type counter
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 150
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 91500
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 0
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 240
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 0
+storm_control_multicast_dropped_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 5300
+# HELP storm_control_multicast_dropped_bytes_total Total dropped multicast bytes for interface
+# TYPE storm_control_multicast_dropped_bytes_total counter
+storm_control_multicast_dropped_bytes_total{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 97190
 # HELP storm_control_multicast_dropped_packets_by_type Dropped multicast packets for interface by traffic type
 # TYPE storm_control_multicast_dropped_packets_by_type counter
-storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast"} 1
-storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast"} 61
-storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast"} 53
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 1
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 61
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 0
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 2
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 0
+storm_control_multicast_dropped_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 53
 # HELP storm_control_multicast_dropped_packets_total Total dropped multicast packets for interface
 # TYPE storm_control_multicast_dropped_packets_total counter
-storm_control_multicast_dropped_packets_total{interface_index="5653",interface_name="tap72cdd785-3a"} 115
+storm_control_multicast_dropped_packets_total{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 117
+# HELP storm_control_multicast_passed_bytes_by_type Passed multicast bytes for interface by traffic type
+# TYPE storm_control_multicast_passed_bytes_by_type counter
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 1500
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 90000
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 2580
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 120
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 360
+storm_control_multicast_passed_bytes_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 5500
+# HELP storm_control_multicast_passed_bytes_total Total passed multicast bytes for interface
+# TYPE storm_control_multicast_passed_bytes_total counter
+storm_control_multicast_passed_bytes_total{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 100060
 # HELP storm_control_multicast_passed_packets_by_type Passed multicast packets for interface by traffic type
 # TYPE storm_control_multicast_passed_packets_by_type counter
-storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast"} 10
-storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast"} 60
-storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast"} 55
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 10
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 60
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 30
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 1
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 4
+storm_control_multicast_passed_packets_by_type{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 55
 # HELP storm_control_multicast_passed_packets_total Total passed multicast packets for interface
 # TYPE storm_control_multicast_passed_packets_total counter
-storm_control_multicast_passed_packets_total{interface_index="5653",interface_name="tap72cdd785-3a"} 125
-# HELP storm_control_traffic_blocked_status Status of blocked config for specific type of packets (0 unblocked, 1 blocked)
+storm_control_multicast_passed_packets_total{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 160
+# HELP storm_control_traffic_blocked_status Status of blocked config for specific type of packets (0 unblocked, 1 blocked, 2 rate limited)
 # TYPE storm_control_traffic_blocked_status gauge
-storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast"} 1
-storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast"} 0
-storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast"} 1
-storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast"} 1
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="arp",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 1
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="dhcpv4",vlan=""} 1
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_multicast",vlan=""} 1
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_nd",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv6_ra",vlan=""} 2
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="mld",vlan=""} 0
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="other_multicast",vlan=""} 1
+storm_control_traffic_blocked_status{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="unknown_unicast",vlan=""} 2
+# HELP storm_control_unknown_unicast_dropped_bytes Counter dropped unknown unicast bytes by interface
+# TYPE storm_control_unknown_unicast_dropped_bytes counter
+storm_control_unknown_unicast_dropped_bytes{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 300
+# HELP storm_control_unknown_unicast_dropped_packets Counter dropped unknown unicast packets by interface
+# TYPE storm_control_unknown_unicast_dropped_packets counter
+storm_control_unknown_unicast_dropped_packets{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 3
+# HELP storm_control_unknown_unicast_passed_bytes Counter passed unknown unicast bytes by interface
+# TYPE storm_control_unknown_unicast_passed_bytes counter
+storm_control_unknown_unicast_passed_bytes{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 700
+# HELP storm_control_unknown_unicast_passed_packets Counter passed unknown unicast packets by interface
+# TYPE storm_control_unknown_unicast_passed_packets counter
+storm_control_unknown_unicast_passed_packets{interface_index="5653",interface_name="tap72cdd785-3a",vlan=""} 7
+`
+
+const collectorTestBackoffValues = `
+# HELP storm_control_block_backoff_level Current block duration backoff level for specific type of packets
+# TYPE storm_control_block_backoff_level gauge
+storm_control_block_backoff_level{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast"} 2
+storm_control_block_backoff_level{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast"} 0
 `
 
 func makeZeroTestValues(t *testing.T) (string, ebpfloader.Statistic) {
@@ -93,31 +244,157 @@ func makeTestValues(t *testing.T) (string, ebpfloader.Statistic) {
 	result.CounterStat = ebpfloader.CounterStat{
 		5653: ebpfloader.PacketCounter{
 			Broadcast: ebpfloader.TrafInfo{
-				Passed:  100,
-				Dropped: 50,
+				Passed:       100,
+				Dropped:      50,
+				PassedBytes:  6400,
+				DroppedBytes: 3200,
 			},
 			IPv4MCast: ebpfloader.TrafInfo{
-				Passed:  10,
-				Dropped: 1,
+				Passed:       10,
+				Dropped:      1,
+				PassedBytes:  1500,
+				DroppedBytes: 150,
 			},
 			IPv6MCast: ebpfloader.TrafInfo{
-				Passed:  60,
-				Dropped: 61,
+				Passed:       60,
+				Dropped:      61,
+				PassedBytes:  90000,
+				DroppedBytes: 91500,
 			},
 			OtherMcast: ebpfloader.TrafInfo{
-				Passed:  55,
-				Dropped: 53,
+				Passed:       55,
+				Dropped:      53,
+				PassedBytes:  5500,
+				DroppedBytes: 5300,
+			},
+			ARP: ebpfloader.TrafInfo{
+				Passed:      20,
+				PassedBytes: 1280,
+			},
+			DHCPv4: ebpfloader.TrafInfo{
+				Passed:       5,
+				Dropped:      40,
+				PassedBytes:  1700,
+				DroppedBytes: 13600,
+			},
+			IPv6ND: ebpfloader.TrafInfo{
+				Passed:      30,
+				PassedBytes: 2580,
+			},
+			MLD: ebpfloader.TrafInfo{
+				Passed:      4,
+				PassedBytes: 360,
+			},
+			IPv6RA: ebpfloader.TrafInfo{
+				Passed:       1,
+				Dropped:      2,
+				PassedBytes:  120,
+				DroppedBytes: 240,
+			},
+			UnknownUcast: ebpfloader.TrafInfo{
+				Passed:       7,
+				Dropped:      3,
+				PassedBytes:  700,
+				DroppedBytes: 300,
 			},
 		},
 	}
 	result.DropConf = ebpfloader.DropConf{
 		5653: ebpfloader.DropPKT{
-			Broadcast: 1,
-			IPv4MCast: 0,
-			IPv6MCast: 1,
-			Multicast: 1,
+			Broadcast:    1,
+			IPv4MCast:    0,
+			IPv6MCast:    1,
+			Multicast:    1,
+			DHCPv4:       1,
+			IPv6RA:       2,
+			UnknownUcast: 2,
 		},
 	}
+	result.AttachModes = ebpfloader.AttachModes{
+		5653: ebpfloader.AttachModeNative,
+	}
 
 	return collectorTestValues, result
 }
+
+const collectorTestEventValues = `
+# HELP storm_control_block_duration_seconds Duration of traffic blocks for specific type of packets
+# TYPE storm_control_block_duration_seconds histogram
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="1"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="5"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="10"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="30"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="60"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="120"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="300"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="600"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="1800"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="3600"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="+Inf"} 1
+storm_control_block_duration_seconds_sum{dry_run="false",traffic_type="broadcast"} 90
+storm_control_block_duration_seconds_count{dry_run="false",traffic_type="broadcast"} 1
+# HELP storm_control_block_events_total Total block events for specific type of packets by interface and source of block
+# TYPE storm_control_block_events_total counter
+storm_control_block_events_total{dry_run="false",interface_index="5653",interface_name="tap72cdd785-3a",source="auto",traffic_type="broadcast",vlan=""} 2
+storm_control_block_events_total{dry_run="false",interface_index="5653",interface_name="tap72cdd785-3a",source="manual",traffic_type="ipv4_multicast",vlan=""} 1
+storm_control_block_events_total{dry_run="true",interface_index="5653",interface_name="tap72cdd785-3a",source="auto",traffic_type="ipv4_multicast",vlan=""} 1
+# HELP storm_control_last_block_timestamp_seconds Unix time of last block for specific type of packets
+# TYPE storm_control_last_block_timestamp_seconds gauge
+storm_control_last_block_timestamp_seconds{dry_run="false",interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 1.7357256e+09
+storm_control_last_block_timestamp_seconds{dry_run="false",interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 1.7357256e+09
+storm_control_last_block_timestamp_seconds{dry_run="true",interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="ipv4_multicast",vlan=""} 1.7357256e+09
+# HELP storm_control_unblock_recheck_failed_total Total unblock attempts which failed recheck of dropped traffic rate
+# TYPE storm_control_unblock_recheck_failed_total counter
+storm_control_unblock_recheck_failed_total{dry_run="false",interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",vlan=""} 1
+`
+
+// collectorTestDetachedEventValues contains event metrics after interface is detached, histogram is kept
+const collectorTestDetachedEventValues = `
+# HELP storm_control_block_duration_seconds Duration of traffic blocks for specific type of packets
+# TYPE storm_control_block_duration_seconds histogram
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="1"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="5"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="10"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="30"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="60"} 0
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="120"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="300"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="600"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="1800"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="3600"} 1
+storm_control_block_duration_seconds_bucket{dry_run="false",traffic_type="broadcast",le="+Inf"} 1
+storm_control_block_duration_seconds_sum{dry_run="false",traffic_type="broadcast"} 90
+storm_control_block_duration_seconds_count{dry_run="false",traffic_type="broadcast"} 1
+`
+
+const collectorTestRateValues = `
+# HELP storm_control_passed_bytes_rate Passed bytes per second of specific type of traffic in last second
+# TYPE storm_control_passed_bytes_rate gauge
+storm_control_passed_bytes_rate{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast"} 7680
+# HELP storm_control_passed_bytes_rate_avg Average passed bytes per second of specific type of traffic over window
+# TYPE storm_control_passed_bytes_rate_avg gauge
+storm_control_passed_bytes_rate_avg{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",window="10s"} 39200
+# HELP storm_control_passed_bytes_rate_peak Peak passed bytes per second of specific type of traffic over window
+# TYPE storm_control_passed_bytes_rate_peak gauge
+storm_control_passed_bytes_rate_peak{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",window="10s"} 320000
+# HELP storm_control_passed_packets_rate Passed packets per second of specific type of traffic in last second
+# TYPE storm_control_passed_packets_rate gauge
+storm_control_passed_packets_rate{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast"} 120
+# HELP storm_control_passed_packets_rate_avg Average passed packets per second of specific type of traffic over window
+# TYPE storm_control_passed_packets_rate_avg gauge
+storm_control_passed_packets_rate_avg{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",window="10s"} 612.5
+# HELP storm_control_passed_packets_rate_peak Peak passed packets per second of specific type of traffic over window
+# TYPE storm_control_passed_packets_rate_peak gauge
+storm_control_passed_packets_rate_peak{interface_index="5653",interface_name="tap72cdd785-3a",traffic_type="broadcast",window="10s"} 5000
+`
+
+const collectorTestTopTalkersValues = `
+# HELP storm_control_top_talker_bytes_rate Broadcast and multicast bytes per second received from source address in last second
+# TYPE storm_control_top_talker_bytes_rate gauge
+storm_control_top_talker_bytes_rate{interface_index="5653",interface_name="tap72cdd785-3a",mac="fa:16:3e:00:00:01"} 768
+storm_control_top_talker_bytes_rate{interface_index="5653",interface_name="tap72cdd785-3a",mac="fa:16:3e:00:00:02"} 320000
+# HELP storm_control_top_talker_packets_rate Broadcast and multicast packets per second received from source address in last second
+# TYPE storm_control_top_talker_packets_rate gauge
+storm_control_top_talker_packets_rate{interface_index="5653",interface_name="tap72cdd785-3a",mac="fa:16:3e:00:00:01"} 12
+storm_control_top_talker_packets_rate{interface_index="5653",interface_name="tap72cdd785-3a",mac="fa:16:3e:00:00:02"} 5000
+`
@@ -0,0 +1,177 @@
+// Code generated by mockery v2.52.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	watcher "github.com/mythvcode/storm-control/internal/watcher"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockWatcherStateLoader is an autogenerated mock type for the WatcherStateLoader type
+type MockWatcherStateLoader struct {
+	mock.Mock
+}
+
+type MockWatcherStateLoader_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockWatcherStateLoader) EXPECT() *MockWatcherStateLoader_Expecter {
+	return &MockWatcherStateLoader_Expecter{mock: &_m.Mock}
+}
+
+// GetNetDevRates provides a mock function with given fields: windows
+func (_m *MockWatcherStateLoader) GetNetDevRates(windows []int) []watcher.NetDevRates {
+	ret := _m.Called(windows)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNetDevRates")
+	}
+
+	var r0 []watcher.NetDevRates
+	if rf, ok := ret.Get(0).(func([]int) []watcher.NetDevRates); ok {
+		r0 = rf(windows)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]watcher.NetDevRates)
+		}
+	}
+
+	return r0
+}
+
+// MockWatcherStateLoader_GetNetDevRates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNetDevRates'
+type MockWatcherStateLoader_GetNetDevRates_Call struct {
+	*mock.Call
+}
+
+// GetNetDevRates is a helper method to define mock.On call
+//   - windows []int
+func (_e *MockWatcherStateLoader_Expecter) GetNetDevRates(windows interface{}) *MockWatcherStateLoader_GetNetDevRates_Call {
+	return &MockWatcherStateLoader_GetNetDevRates_Call{Call: _e.mock.On("GetNetDevRates", windows)}
+}
+
+func (_c *MockWatcherStateLoader_GetNetDevRates_Call) Run(run func(windows []int)) *MockWatcherStateLoader_GetNetDevRates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]int))
+	})
+	return _c
+}
+
+func (_c *MockWatcherStateLoader_GetNetDevRates_Call) Return(_a0 []watcher.NetDevRates) *MockWatcherStateLoader_GetNetDevRates_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockWatcherStateLoader_GetNetDevRates_Call) RunAndReturn(run func([]int) []watcher.NetDevRates) *MockWatcherStateLoader_GetNetDevRates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNetDevStates provides a mock function with no fields
+func (_m *MockWatcherStateLoader) GetNetDevStates() []watcher.NetDevState {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNetDevStates")
+	}
+
+	var r0 []watcher.NetDevState
+	if rf, ok := ret.Get(0).(func() []watcher.NetDevState); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]watcher.NetDevState)
+		}
+	}
+
+	return r0
+}
+
+// MockWatcherStateLoader_GetNetDevStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNetDevStates'
+type MockWatcherStateLoader_GetNetDevStates_Call struct {
+	*mock.Call
+}
+
+// GetNetDevStates is a helper method to define mock.On call
+func (_e *MockWatcherStateLoader_Expecter) GetNetDevStates() *MockWatcherStateLoader_GetNetDevStates_Call {
+	return &MockWatcherStateLoader_GetNetDevStates_Call{Call: _e.mock.On("GetNetDevStates")}
+}
+
+func (_c *MockWatcherStateLoader_GetNetDevStates_Call) Run(run func()) *MockWatcherStateLoader_GetNetDevStates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockWatcherStateLoader_GetNetDevStates_Call) Return(_a0 []watcher.NetDevState) *MockWatcherStateLoader_GetNetDevStates_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockWatcherStateLoader_GetNetDevStates_Call) RunAndReturn(run func() []watcher.NetDevState) *MockWatcherStateLoader_GetNetDevStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NetDevNames provides a mock function with no fields
+func (_m *MockWatcherStateLoader) NetDevNames() map[int]string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for NetDevNames")
+	}
+
+	var r0 map[int]string
+	if rf, ok := ret.Get(0).(func() map[int]string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int]string)
+		}
+	}
+
+	return r0
+}
+
+// MockWatcherStateLoader_NetDevNames_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NetDevNames'
+type MockWatcherStateLoader_NetDevNames_Call struct {
+	*mock.Call
+}
+
+// NetDevNames is a helper method to define mock.On call
+func (_e *MockWatcherStateLoader_Expecter) NetDevNames() *MockWatcherStateLoader_NetDevNames_Call {
+	return &MockWatcherStateLoader_NetDevNames_Call{Call: _e.mock.On("NetDevNames")}
+}
+
+func (_c *MockWatcherStateLoader_NetDevNames_Call) Run(run func()) *MockWatcherStateLoader_NetDevNames_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockWatcherStateLoader_NetDevNames_Call) Return(_a0 map[int]string) *MockWatcherStateLoader_NetDevNames_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockWatcherStateLoader_NetDevNames_Call) RunAndReturn(run func() map[int]string) *MockWatcherStateLoader_NetDevNames_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockWatcherStateLoader creates a new instance of MockWatcherStateLoader. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockWatcherStateLoader(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockWatcherStateLoader {
+	mock := &MockWatcherStateLoader{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"log/slog"
+	"slices"
+)
+
+type field struct {
+	key   string
+	value string
+}
+
+// handlerAttrs keeps attributes of handlers which write flat key value fields,
+// group names are joined with attribute keys by "_"
+type handlerAttrs struct {
+	fields []field
+	prefix string
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "_" + key
+}
+
+func appendAttr(result []field, prefix string, attr slog.Attr) []field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return result
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		// attributes of group without name are inlined
+		if attr.Key != "" {
+			prefix = joinKey(prefix, attr.Key)
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			result = appendAttr(result, prefix, groupAttr)
+		}
+
+		return result
+	}
+
+	return append(result, field{key: joinKey(prefix, attr.Key), value: attr.Value.String()})
+}
+
+func (h handlerAttrs) withAttrs(attrs []slog.Attr) handlerAttrs {
+	result := handlerAttrs{fields: slices.Clip(h.fields), prefix: h.prefix}
+	for _, attr := range attrs {
+		result.fields = appendAttr(result.fields, h.prefix, attr)
+	}
+
+	return result
+}
+
+func (h handlerAttrs) withGroup(name string) handlerAttrs {
+	if name == "" {
+		return h
+	}
+
+	return handlerAttrs{fields: h.fields, prefix: joinKey(h.prefix, name)}
+}
+
+// recordFields returns handler attributes followed by attributes of record
+func (h handlerAttrs) recordFields(record slog.Record) []field {
+	result := slices.Clone(h.fields)
+	record.Attrs(func(attr slog.Attr) bool {
+		result = appendAttr(result, h.prefix, attr)
+
+		return true
+	})
+
+	return result
+}
+
+// severity returns syslog severity of level, it is used as journald priority as well
+func severity(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	}
+
+	return 7
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	journaldIdentifier   = "storm-control"
+	journaldMaxFieldName = 64
+)
+
+// journaldHandler sends records to systemd journal by native protocol,
+// attributes are sent as fields with upper case names, e.g. COMPONENT and INTERFACE
+type journaldHandler struct {
+	conn  net.Conn
+	level slog.Leveler
+	attrs handlerAttrs
+}
+
+func newJournaldHandler(socketPath string, level slog.Leveler) (*journaldHandler, error) {
+	conn, err := net.Dial("unixgram", socketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &journaldHandler{conn: conn, level: level}, nil
+}
+
+// journaldFieldName converts attribute key to journal field name,
+// only upper case letters, digits and underscore are allowed and name must not start with underscore or digit
+func journaldFieldName(key string) string {
+	name := strings.Map(func(char rune) rune {
+		switch {
+		case char >= 'a' && char <= 'z':
+			return char - 'a' + 'A'
+		case char >= 'A' && char <= 'Z', char >= '0' && char <= '9':
+			return char
+		}
+
+		return '_'
+	}, key)
+	name = strings.TrimLeft(name, "_0123456789")
+
+	return name[:min(len(name), journaldMaxFieldName)]
+}
+
+func writeJournaldField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", name, value)
+
+		return
+	}
+	// values with new lines are written with length in binary form
+	buf.WriteString(name)
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value))) //nolint:errcheck
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+func (h *journaldHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *journaldHandler) Handle(_ context.Context, record slog.Record) error {
+	var buf bytes.Buffer
+	writeJournaldField(&buf, "MESSAGE", record.Message)
+	writeJournaldField(&buf, "PRIORITY", strconv.Itoa(severity(record.Level)))
+	writeJournaldField(&buf, "SYSLOG_IDENTIFIER", journaldIdentifier)
+	for _, attr := range h.attrs.recordFields(record) {
+		if name := journaldFieldName(attr.key); name != "" {
+			writeJournaldField(&buf, name, attr.value)
+		}
+	}
+	_, err := h.conn.Write(buf.Bytes())
+
+	return err
+}
+
+func (h *journaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &journaldHandler{conn: h.conn, level: h.level, attrs: h.attrs.withAttrs(attrs)}
+}
+
+func (h *journaldHandler) WithGroup(name string) slog.Handler {
+	return &journaldHandler{conn: h.conn, level: h.level, attrs: h.attrs.withGroup(name)}
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournaldFieldName(t *testing.T) {
+	tCases := []struct {
+		key      string
+		expected string
+	}{
+		{key: "interface", expected: "INTERFACE"},
+		{key: "dry_run", expected: "DRY_RUN"},
+		{key: "dev.name-1", expected: "DEV_NAME_1"},
+		{key: "_private", expected: "PRIVATE"},
+		{key: "1st_key", expected: "ST_KEY"},
+		{key: "__", expected: ""},
+		{key: "имя", expected: ""},
+		{key: strings.Repeat("k", 70), expected: strings.Repeat("K", journaldMaxFieldName)},
+	}
+	for _, tCase := range tCases {
+		require.Equal(t, tCase.expected, journaldFieldName(tCase.key), tCase.key)
+	}
+}
+
+func TestJournaldHandler(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenPacket("unixgram", socketPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	handler, err := newJournaldHandler(socketPath, slog.LevelInfo)
+	require.NoError(t, err)
+
+	record := slog.NewRecord(time.Now(), slog.LevelWarn, "blocked", 0)
+	record.AddAttrs(slog.String("name", "tap1"), slog.String("error", "first\nsecond"))
+	// attributes with invalid names are skipped
+	require.NoError(t, handler.WithAttrs([]slog.Attr{slog.String(Component, "watcher"), slog.Int("_", 1)}).WithGroup("dev").Handle(context.Background(), record))
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	// values with new lines are written with little endian length
+	expected := "MESSAGE=blocked\nPRIORITY=4\nSYSLOG_IDENTIFIER=storm-control\nCOMPONENT=watcher\nDEV_NAME=tap1\n" +
+		"DEV_ERROR\n\x0c\x00\x00\x00\x00\x00\x00\x00first\nsecond\n"
+	require.Equal(t, expected, string(buf[:n]))
+	require.False(t, handler.Enabled(context.Background(), slog.LevelDebug))
+}
@@ -6,16 +6,27 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/mythvcode/storm-control/internal/config"
 	slogmulti "github.com/samber/slog-multi"
 )
 
-const Component = "component"
+// common attribute keys
+const (
+	Component = "component"
+	Interface = "interface"
+	DryRun    = "dry_run"
+	VLAN      = "vlan"
+)
+
+// level of default logger, can be changed in runtime
+var logLevel = new(slog.LevelVar)
 
 type Logger struct {
 	logger *slog.Logger
 }
 
-func marshalLevel(level string) (slog.Level, error) {
+// ParseLevel parses log level name, empty level means debug
+func ParseLevel(level string) (slog.Level, error) {
 	if level == "" {
 		return slog.LevelDebug, nil
 	}
@@ -25,25 +36,42 @@ func marshalLevel(level string) (slog.Level, error) {
 	return l, err
 }
 
-func Init(logFile string, level string) error {
-	parsedLevel, err := marshalLevel(level)
+// Init configures default logger, JSON logs are written to file or stdout
+// and optionally to systemd journal and syslog
+func Init(cfg config.LoggerConfig) error {
+	parsedLevel, err := ParseLevel(cfg.Level)
 	if err != nil {
 		return err
 	}
+	logLevel.Set(parsedLevel)
 
-	slogHandlers := make([]slog.Handler, 0, 2)
+	slogHandlers := make([]slog.Handler, 0, 3)
 	var jsonHandler *slog.JSONHandler
-	if logFile != "" {
-		logFile, err := os.OpenFile(filepath.Clean(logFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if cfg.File != "" {
+		logFile, err := os.OpenFile(filepath.Clean(cfg.File), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
 		if err != nil {
 			return fmt.Errorf("failed to initialize log file %w", err)
 		}
-		jsonHandler = slog.NewJSONHandler(logFile, &slog.HandlerOptions{Level: parsedLevel})
+		jsonHandler = slog.NewJSONHandler(logFile, &slog.HandlerOptions{Level: logLevel})
 	} else {
-		jsonHandler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: parsedLevel})
+		jsonHandler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})
 	}
 
 	slogHandlers = append(slogHandlers, jsonHandler)
+	if cfg.Journald.Enable {
+		journaldHandler, err := newJournaldHandler(cfg.Journald.SocketPath, logLevel)
+		if err != nil {
+			return fmt.Errorf("failed to initialize journald logging %w", err)
+		}
+		slogHandlers = append(slogHandlers, journaldHandler)
+	}
+	if cfg.Syslog.Enable {
+		syslogHandler, err := newSyslogHandler(cfg.Syslog, logLevel)
+		if err != nil {
+			return fmt.Errorf("failed to initialize syslog logging %w", err)
+		}
+		slogHandlers = append(slogHandlers, syslogHandler)
+	}
 
 	logger := slog.New(slogmulti.Fanout(slogHandlers...))
 
@@ -52,6 +80,11 @@ func Init(logFile string, level string) error {
 	return nil
 }
 
+// SetLevel changes level of loggers which use handler created by Init
+func SetLevel(level slog.Level) {
+	logLevel.Set(level)
+}
+
 func Default() *Logger {
 	return &Logger{
 		slog.New(slog.NewJSONHandler(os.Stdout,
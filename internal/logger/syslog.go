@@ -0,0 +1,248 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/config"
+)
+
+const (
+	syslogNilValue      = "-"
+	syslogTimeFormat    = "2006-01-02T15:04:05.000000Z07:00"
+	syslogMaxParamName  = 32
+	syslogStructuredID  = "storm@32473"
+	syslogDefaultAppTag = "storm-control"
+	syslogDialTimeout   = 5 * time.Second
+	syslogWriteTimeout  = 5 * time.Second
+	syslogQueueSize     = 1000
+)
+
+var errSyslogQueueFull = errors.New("syslog queue is full, message is dropped")
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// syslogWriter sends messages to syslog server from queue and reconnects after write error,
+// logging is not blocked by slow or unavailable server, messages are dropped if queue is full
+type syslogWriter struct {
+	network string
+	address string
+	// messages of stream connections are framed by octet counting (RFC 6587)
+	stream bool
+	queue  chan []byte
+	conn   net.Conn
+}
+
+func newSyslogWriter(network, address string) (*syslogWriter, error) {
+	writer := &syslogWriter{network: network, address: address, queue: make(chan []byte, syslogQueueSize)}
+	switch network {
+	case "unixgram", "udp":
+	case "unix", "tcp":
+		writer.stream = true
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %s", network)
+	}
+	// first connection is made on start to detect invalid address
+	if err := writer.connect(); err != nil {
+		return nil, err
+	}
+	go writer.run()
+
+	return writer, nil
+}
+
+func (w *syslogWriter) connect() error {
+	conn, err := net.DialTimeout(w.network, w.address, syslogDialTimeout)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+
+	return nil
+}
+
+func (w *syslogWriter) writeConn(msg []byte) error {
+	if w.conn == nil {
+		if err := w.connect(); err != nil {
+			return err
+		}
+	}
+	if w.stream {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	err := w.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+	if err == nil {
+		_, err = w.conn.Write(msg)
+	}
+	if err != nil {
+		w.conn.Close()
+		w.conn = nil
+
+		return err
+	}
+
+	return nil
+}
+
+// write sends message, message is sent again with new connection if connection is broken
+func (w *syslogWriter) write(msg []byte) error {
+	if err := w.writeConn(msg); err != nil {
+		return w.writeConn(msg)
+	}
+
+	return nil
+}
+
+// send queues message, message is dropped if queue is full
+func (w *syslogWriter) send(msg []byte) error {
+	select {
+	case w.queue <- msg:
+		return nil
+	default:
+		return errSyslogQueueFull
+	}
+}
+
+// run writes queued messages one by one, messages which are not sent are dropped
+func (w *syslogWriter) run() {
+	for msg := range w.queue {
+		w.write(msg) //nolint:errcheck
+	}
+}
+
+// syslogHandler writes records in RFC 5424 format, attributes are sent as structured data
+type syslogHandler struct {
+	writer   *syslogWriter
+	facility int
+	hostname string
+	tag      string
+	pid      string
+	level    slog.Leveler
+	attrs    handlerAttrs
+}
+
+func newSyslogHandler(cfg config.SyslogConfig, level slog.Leveler) (*syslogHandler, error) {
+	facility, ok := syslogFacilities[cfg.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %s", cfg.Facility)
+	}
+	writer, err := newSyslogWriter(cfg.Network, cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = syslogNilValue
+	}
+	tag := cfg.Tag
+	if tag == "" {
+		tag = syslogDefaultAppTag
+	}
+
+	return &syslogHandler{
+		writer:   writer,
+		facility: facility,
+		hostname: hostname,
+		tag:      tag,
+		pid:      strconv.Itoa(os.Getpid()),
+		level:    level,
+	}, nil
+}
+
+// syslogParamName removes characters which are not allowed in structured data parameter name
+func syslogParamName(key string) string {
+	name := strings.Map(func(char rune) rune {
+		if char <= ' ' || char >= 127 || char == '=' || char == ']' || char == '"' {
+			return -1
+		}
+
+		return char
+	}, key)
+
+	return name[:min(len(name), syslogMaxParamName)]
+}
+
+var syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func (h *syslogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *syslogHandler) Handle(_ context.Context, record slog.Record) error {
+	var buf bytes.Buffer
+	timestamp := syslogNilValue
+	if !record.Time.IsZero() {
+		timestamp = record.Time.Format(syslogTimeFormat)
+	}
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s %s ",
+		h.facility*8+severity(record.Level),
+		timestamp,
+		h.hostname,
+		h.tag,
+		h.pid,
+		syslogNilValue,
+	)
+	structured := false
+	for _, attr := range h.attrs.recordFields(record) {
+		name := syslogParamName(attr.key)
+		if name == "" {
+			continue
+		}
+		if !structured {
+			buf.WriteString("[" + syslogStructuredID)
+			structured = true
+		}
+		fmt.Fprintf(&buf, ` %s="%s"`, name, syslogParamEscaper.Replace(attr.value))
+	}
+	if structured {
+		buf.WriteString("]")
+	} else {
+		buf.WriteString(syslogNilValue)
+	}
+	buf.WriteString(" " + record.Message)
+
+	return h.writer.send(buf.Bytes())
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	result := *h
+	result.attrs = h.attrs.withAttrs(attrs)
+
+	return &result
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	result := *h
+	result.attrs = h.attrs.withGroup(name)
+
+	return &result
+}
@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecordTime = time.Date(2025, 1, 2, 3, 4, 5, 600000000, time.UTC)
+
+// listenTestSyslog starts local syslog server and returns its address and function which reads one message,
+// message of stream connection is returned with octet count
+func listenTestSyslog(t *testing.T, network string) (string, func() string) {
+	t.Helper()
+	address := "127.0.0.1:0"
+	if strings.HasPrefix(network, "unix") {
+		address = filepath.Join(t.TempDir(), "log.sock")
+	}
+	if network == "udp" || network == "unixgram" {
+		conn, err := net.ListenPacket(network, address)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		return conn.LocalAddr().String(), func() string {
+			buf := make([]byte, 4096)
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+			n, _, err := conn.ReadFrom(buf)
+			require.NoError(t, err)
+
+			return string(buf[:n])
+		}
+	}
+	listener, err := net.Listen(network, address)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	var reader *bufio.Reader
+
+	return listener.Addr().String(), func() string {
+		if reader == nil {
+			conn, err := listener.Accept()
+			require.NoError(t, err)
+			t.Cleanup(func() { conn.Close() })
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+			reader = bufio.NewReader(conn)
+		}
+		count, err := reader.ReadString(' ')
+		require.NoError(t, err)
+		size, err := strconv.Atoi(strings.TrimSuffix(count, " "))
+		require.NoError(t, err)
+		msg := make([]byte, size)
+		_, err = io.ReadFull(reader, msg)
+		require.NoError(t, err)
+
+		return count + string(msg)
+	}
+}
+
+func makeTestSyslogHandler(t *testing.T, network, address string) *syslogHandler {
+	t.Helper()
+	handler, err := newSyslogHandler(config.SyslogConfig{Network: network, Address: address, Facility: "daemon"}, slog.LevelInfo)
+	require.NoError(t, err)
+	handler.hostname = "host"
+	handler.pid = "100"
+
+	return handler
+}
+
+func TestSyslogParamName(t *testing.T) {
+	tCases := []struct {
+		key      string
+		expected string
+	}{
+		{key: "interface", expected: "interface"},
+		{key: "dev_name", expected: "dev_name"},
+		{key: `a b=c]d"e`, expected: "abcde"},
+		{key: "tab\tnew\nline", expected: "tabnewline"},
+		{key: "имя", expected: ""},
+		{key: "=]\"", expected: ""},
+		{key: strings.Repeat("k", 40), expected: strings.Repeat("k", syslogMaxParamName)},
+	}
+	for _, tCase := range tCases {
+		require.Equal(t, tCase.expected, syslogParamName(tCase.key), tCase.key)
+	}
+}
+
+func TestSyslogParamEscaper(t *testing.T) {
+	tCases := []struct {
+		value    string
+		expected string
+	}{
+		{value: "tap1", expected: "tap1"},
+		{value: `say "hi"`, expected: `say \"hi\"`},
+		{value: `C:\dir`, expected: `C:\\dir`},
+		{value: "[a]", expected: `[a\]`},
+		{value: `\"]`, expected: `\\\"\]`},
+	}
+	for _, tCase := range tCases {
+		require.Equal(t, tCase.expected, syslogParamEscaper.Replace(tCase.value), tCase.value)
+	}
+}
+
+func TestSyslogHandlerFormat(t *testing.T) {
+	address, read := listenTestSyslog(t, "unixgram")
+	handler := makeTestSyslogHandler(t, "unixgram", address)
+	tCases := []struct {
+		handler  slog.Handler
+		record   slog.Record
+		attrs    []slog.Attr
+		expected string
+	}{
+		{
+			handler:  handler,
+			record:   slog.NewRecord(testRecordTime, slog.LevelInfo, "started", 0),
+			expected: "<30>1 2025-01-02T03:04:05.600000Z host storm-control 100 - - started",
+		},
+		{
+			// record without time has nil timestamp
+			handler:  handler,
+			record:   slog.NewRecord(time.Time{}, slog.LevelDebug, "debug", 0),
+			expected: "<31>1 - host storm-control 100 - - debug",
+		},
+		{
+			handler:  handler.WithAttrs([]slog.Attr{slog.String(Component, "watcher")}).WithGroup("dev"),
+			record:   slog.NewRecord(testRecordTime, slog.LevelWarn, "blocked", 0),
+			attrs:    []slog.Attr{slog.String("name", `tap"1]`)},
+			expected: `<28>1 2025-01-02T03:04:05.600000Z host storm-control 100 - [storm@32473 component="watcher" dev_name="tap\"1\]"] blocked`,
+		},
+		{
+			handler: handler,
+			record:  slog.NewRecord(testRecordTime, slog.LevelError, "failed", 0),
+			// attributes with invalid names are skipped
+			attrs:    []slog.Attr{slog.Bool(DryRun, true), slog.Int("=", 5)},
+			expected: `<27>1 2025-01-02T03:04:05.600000Z host storm-control 100 - [storm@32473 dry_run="true"] failed`,
+		},
+	}
+	for _, tCase := range tCases {
+		tCase.record.AddAttrs(tCase.attrs...)
+		require.NoError(t, tCase.handler.Handle(context.Background(), tCase.record))
+		require.Equal(t, tCase.expected, read())
+	}
+}
+
+func TestSyslogHandlerNetworks(t *testing.T) {
+	msg := "<30>1 2025-01-02T03:04:05.600000Z host storm-control 100 - - started"
+	tCases := []struct {
+		network  string
+		expected string
+	}{
+		{network: "udp", expected: msg},
+		{network: "unixgram", expected: msg},
+		// messages of stream connections are framed by octet counting
+		{network: "tcp", expected: strconv.Itoa(len(msg)) + " " + msg},
+		{network: "unix", expected: strconv.Itoa(len(msg)) + " " + msg},
+	}
+	for _, tCase := range tCases {
+		address, read := listenTestSyslog(t, tCase.network)
+		handler := makeTestSyslogHandler(t, tCase.network, address)
+		for range 2 {
+			require.NoError(t, handler.Handle(context.Background(), slog.NewRecord(testRecordTime, slog.LevelInfo, "started", 0)))
+			require.Equal(t, tCase.expected, read(), tCase.network)
+		}
+	}
+}
+
+func TestNewSyslogHandlerError(t *testing.T) {
+	_, err := newSyslogHandler(config.SyslogConfig{Network: "udp", Address: "127.0.0.1:514", Facility: "unknown"}, slog.LevelInfo)
+	require.EqualError(t, err, "unknown syslog facility unknown")
+	_, err = newSyslogHandler(config.SyslogConfig{Network: "ip", Address: "127.0.0.1", Facility: "daemon"}, slog.LevelInfo)
+	require.EqualError(t, err, "unsupported syslog network ip")
+	_, err = newSyslogHandler(config.SyslogConfig{Network: "unixgram", Address: filepath.Join(t.TempDir(), "log.sock"), Facility: "daemon"}, slog.LevelInfo)
+	require.Error(t, err)
+}
+
+func TestSyslogWriterQueueFull(t *testing.T) {
+	// writer is not started, queued messages are not sent
+	writer := &syslogWriter{queue: make(chan []byte, 1)}
+	require.NoError(t, writer.send([]byte("first")))
+	require.ErrorIs(t, writer.send([]byte("second")), errSyslogQueueFull)
+	require.Equal(t, []byte("first"), <-writer.queue)
+}
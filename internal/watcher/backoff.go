@@ -0,0 +1,132 @@
+package watcher
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/config"
+)
+
+const maxBackoffLevel = 32
+
+type backoffConfig struct {
+	enabled    bool
+	multiplier float64
+	maxDelay   time.Duration
+	// quiet period after unblock which decreases backoff level by one
+	decayAfter time.Duration
+}
+
+type backoffState struct {
+	level     int
+	lastEvent time.Time
+}
+
+// blockBackoff tracks repeated blocks of traffic types on interface
+// and increases block duration for repeat offenders
+type blockBackoff struct {
+	config backoffConfig
+	mux    sync.Mutex
+	states map[int]*backoffState
+	now    func() time.Time
+}
+
+func makeBackoffConfig(cfg config.BackoffConfig) backoffConfig {
+	result := backoffConfig{
+		enabled:    cfg.Enabled,
+		multiplier: cfg.Multiplier,
+		maxDelay:   time.Duration(cfg.MaxDelay) * time.Second,
+		decayAfter: time.Duration(cfg.DecayAfter) * time.Second,
+	}
+	if result.multiplier < 1 {
+		result.multiplier = 1
+	}
+
+	return result
+}
+
+func newBlockBackoff(cfg backoffConfig) *blockBackoff {
+	return &blockBackoff{
+		config: cfg,
+		states: make(map[int]*backoffState),
+		now:    time.Now,
+	}
+}
+
+// setConfig changes backoff settings, current levels are kept
+func (b *blockBackoff) setConfig(cfg backoffConfig) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.config = cfg
+}
+
+func (b *blockBackoff) getConfig() backoffConfig {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	return b.config
+}
+
+// decayedLevel returns level decreased by number of quiet periods since last event
+func (b *blockBackoff) decayedLevel(state *backoffState, now time.Time) int {
+	if b.config.decayAfter <= 0 {
+		return state.level
+	}
+	decay := int(now.Sub(state.lastEvent) / b.config.decayAfter)
+
+	return max(state.level-decay, 0)
+}
+
+// blockDelay returns block duration for new block of traffic type and increases backoff level
+func (b *blockBackoff) blockDelay(trafType int, baseDelay time.Duration) time.Duration {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if !b.config.enabled {
+		return baseDelay
+	}
+
+	now := b.now()
+	state, ok := b.states[trafType]
+	if !ok {
+		state = &backoffState{}
+		b.states[trafType] = state
+	}
+	level := b.decayedLevel(state, now)
+	delay := time.Duration(math.MaxInt64)
+	// conversion of float value out of int64 range is undefined, delay is clamped before conversion
+	if scaled := float64(baseDelay) * math.Pow(b.config.multiplier, float64(level)); scaled < float64(math.MaxInt64) {
+		delay = time.Duration(scaled)
+	}
+	if b.config.maxDelay > 0 && delay > b.config.maxDelay {
+		delay = max(b.config.maxDelay, baseDelay)
+	}
+	state.level = min(level+1, maxBackoffLevel)
+	state.lastEvent = now
+
+	return delay
+}
+
+// unblocked starts quiet period of traffic type
+func (b *blockBackoff) unblocked(trafType int) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if !b.config.enabled {
+		return
+	}
+	if state, ok := b.states[trafType]; ok {
+		state.lastEvent = b.now()
+	}
+}
+
+// level returns current backoff level of traffic type
+func (b *blockBackoff) level(trafType int) int {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	state, ok := b.states[trafType]
+	if !ok {
+		return 0
+	}
+
+	return b.decayedLevel(state, b.now())
+}
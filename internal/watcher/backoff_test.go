@@ -0,0 +1,78 @@
+package watcher
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestBackoff(t *testing.T, cfg backoffConfig) (*blockBackoff, *time.Time) {
+	t.Helper()
+	now := time.Unix(1000, 0)
+	backoff := newBlockBackoff(cfg)
+	backoff.now = func() time.Time { return now }
+
+	return backoff, &now
+}
+
+func TestBackoffDisabled(t *testing.T) {
+	backoff, _ := makeTestBackoff(t, backoffConfig{multiplier: 2, maxDelay: time.Minute})
+	for range 3 {
+		require.Equal(t, 10*time.Second, backoff.blockDelay(broadcastType, 10*time.Second))
+	}
+	require.Equal(t, 0, backoff.level(broadcastType))
+}
+
+func TestBackoffGrowth(t *testing.T) {
+	backoff, _ := makeTestBackoff(t, backoffConfig{enabled: true, multiplier: 2, maxDelay: time.Minute})
+	require.Equal(t, 10*time.Second, backoff.blockDelay(broadcastType, 10*time.Second))
+	require.Equal(t, 20*time.Second, backoff.blockDelay(broadcastType, 10*time.Second))
+	require.Equal(t, 40*time.Second, backoff.blockDelay(broadcastType, 10*time.Second))
+	require.Equal(t, time.Minute, backoff.blockDelay(broadcastType, 10*time.Second))
+	require.Equal(t, 4, backoff.level(broadcastType))
+	// each traffic type has own level
+	require.Equal(t, 10*time.Second, backoff.blockDelay(ipv4McastType, 10*time.Second))
+	require.Equal(t, 1, backoff.level(ipv4McastType))
+}
+
+func TestBackoffMaxLevel(t *testing.T) {
+	backoff, _ := makeTestBackoff(t, backoffConfig{enabled: true, multiplier: 10, maxDelay: time.Hour})
+	for range maxBackoffLevel * 2 {
+		require.LessOrEqual(t, backoff.blockDelay(broadcastType, time.Second), time.Hour)
+	}
+	require.Equal(t, maxBackoffLevel, backoff.level(broadcastType))
+}
+
+func TestBackoffOverflow(t *testing.T) {
+	// delay without max delay is clamped to max duration instead of overflow
+	backoff, _ := makeTestBackoff(t, backoffConfig{enabled: true, multiplier: 10})
+	prevDelay := time.Duration(0)
+	for range maxBackoffLevel * 2 {
+		delay := backoff.blockDelay(broadcastType, time.Hour)
+		require.GreaterOrEqual(t, delay, prevDelay)
+		prevDelay = delay
+	}
+	require.Equal(t, time.Duration(math.MaxInt64), prevDelay)
+}
+
+func TestBackoffDecay(t *testing.T) {
+	backoff, now := makeTestBackoff(t, backoffConfig{enabled: true, multiplier: 2, maxDelay: time.Hour, decayAfter: time.Minute})
+	for range 3 {
+		backoff.blockDelay(broadcastType, 10*time.Second)
+	}
+	*now = now.Add(time.Hour)
+	backoff.unblocked(broadcastType)
+	require.Equal(t, 3, backoff.level(broadcastType))
+
+	// quiet period decreases level by one
+	*now = now.Add(time.Minute + time.Second)
+	require.Equal(t, 2, backoff.level(broadcastType))
+	require.Equal(t, 40*time.Second, backoff.blockDelay(broadcastType, 10*time.Second))
+	backoff.unblocked(broadcastType)
+
+	*now = now.Add(10 * time.Minute)
+	require.Equal(t, 0, backoff.level(broadcastType))
+	require.Equal(t, 10*time.Second, backoff.blockDelay(broadcastType, 10*time.Second))
+}
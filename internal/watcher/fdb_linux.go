@@ -0,0 +1,117 @@
+package watcher
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"slices"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// fdbRequest is dump request of bridge forwarding databases,
+// kernel rejects dump request with short header sent by syscall.NetlinkRIB
+type fdbRequest struct {
+	header unix.NlMsghdr
+	ndMsg  unix.NdMsg
+}
+
+// readBridgeFDB dumps forwarding databases of all Linux bridges
+func readBridgeFDB() ([]fdbEntry, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, fmt.Errorf("create netlink socket: %w", err)
+	}
+	defer unix.Close(fd)
+	req := fdbRequest{
+		header: unix.NlMsghdr{
+			Len:   uint32(unsafe.Sizeof(fdbRequest{})),
+			Type:  unix.RTM_GETNEIGH,
+			Flags: unix.NLM_F_REQUEST | unix.NLM_F_DUMP,
+			Seq:   1,
+		},
+		ndMsg: unix.NdMsg{Family: unix.AF_BRIDGE},
+	}
+	reqBytes := (*[unsafe.Sizeof(fdbRequest{})]byte)(unsafe.Pointer(&req))[:] //nolint:gosec
+	if err := unix.Sendto(fd, reqBytes, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("send bridge fdb request: %w", err)
+	}
+	var result []fdbEntry
+	buf := make([]byte, netlinkReadBufferSize)
+	for {
+		readBytes, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("read bridge fdb: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:readBytes])
+		if err != nil {
+			return nil, fmt.Errorf("parse bridge fdb: %w", err)
+		}
+		for _, msg := range msgs {
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE, syscall.NLMSG_ERROR:
+				return result, netlinkError(&msg)
+			}
+			if entry, ok := parseFDBMessage(&msg); ok {
+				result = append(result, entry)
+			}
+		}
+	}
+}
+
+// netlinkError returns error of done or error message, message with zero code is not error
+func netlinkError(msg *syscall.NetlinkMessage) error {
+	if len(msg.Data) < 4 {
+		return nil
+	}
+	if code := int32(binary.NativeEndian.Uint32(msg.Data)); code < 0 { //nolint:gosec
+		return fmt.Errorf("dump bridge fdb: %w", syscall.Errno(-code))
+	}
+
+	return nil
+}
+
+// parseFDBMessage returns entry of bridge forwarding database,
+// entries without Ethernet address or without bridge are skipped
+func parseFDBMessage(msg *syscall.NetlinkMessage) (fdbEntry, bool) {
+	if msg.Header.Type != syscall.RTM_NEWNEIGH || len(msg.Data) < unix.SizeofNdMsg {
+		return fdbEntry{}, false
+	}
+	ndMsg := (*unix.NdMsg)(unsafe.Pointer(&msg.Data[0])) //nolint:gosec
+	if ndMsg.Family != syscall.AF_BRIDGE {
+		return fdbEntry{}, false
+	}
+	entry := fdbEntry{
+		index:     int(ndMsg.Ifindex),
+		permanent: ndMsg.State&unix.NUD_PERMANENT != 0,
+	}
+	// neighbour attributes are not parsed by syscall.ParseNetlinkRouteAttr
+	attrs := msg.Data[unix.SizeofNdMsg:]
+	for len(attrs) >= syscall.SizeofRtAttr {
+		attr := (*syscall.RtAttr)(unsafe.Pointer(&attrs[0])) //nolint:gosec
+		attrLen := int(attr.Len)
+		if attrLen < syscall.SizeofRtAttr || attrLen > len(attrs) {
+			return fdbEntry{}, false
+		}
+		value := attrs[syscall.SizeofRtAttr:attrLen]
+		switch attr.Type {
+		case unix.NDA_LLADDR:
+			if len(value) == macLen {
+				entry.addr = net.HardwareAddr(slices.Clone(value))
+			}
+		case unix.NDA_MASTER:
+			if len(value) == 4 {
+				entry.master = int(binary.NativeEndian.Uint32(value))
+			}
+		}
+		// attributes are aligned to 4 bytes
+		attrs = attrs[min((attrLen+syscall.RTA_ALIGNTO-1)&^(syscall.RTA_ALIGNTO-1), len(attrs)):]
+	}
+	if entry.addr == nil || entry.master == 0 {
+		return fdbEntry{}, false
+	}
+
+	return entry, true
+}
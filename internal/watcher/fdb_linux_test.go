@@ -0,0 +1,80 @@
+package watcher
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func appendRouteAttr(data []byte, attrType uint16, value []byte) []byte {
+	attr := syscall.RtAttr{Len: uint16(syscall.SizeofRtAttr + len(value)), Type: attrType}
+	data = append(data, (*[syscall.SizeofRtAttr]byte)(unsafe.Pointer(&attr))[:]...)
+	data = append(data, value...)
+	// attributes are aligned to 4 bytes
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+
+	return data
+}
+
+func makeFDBMessage(t *testing.T, index int32, state uint16, addr net.HardwareAddr, master uint32) syscall.NetlinkMessage {
+	t.Helper()
+	ndMsg := unix.NdMsg{Family: syscall.AF_BRIDGE, Ifindex: index, State: state}
+	data := append([]byte{}, (*[unix.SizeofNdMsg]byte)(unsafe.Pointer(&ndMsg))[:]...)
+	data = appendRouteAttr(data, unix.NDA_LLADDR, addr)
+	// VLAN attribute is not used
+	data = appendRouteAttr(data, unix.NDA_VLAN, []byte{10, 0})
+	if master != 0 {
+		data = appendRouteAttr(data, unix.NDA_MASTER, binary.NativeEndian.AppendUint32(nil, master))
+	}
+
+	return syscall.NetlinkMessage{
+		Header: syscall.NlMsghdr{Type: syscall.RTM_NEWNEIGH, Len: uint32(syscall.NLMSG_HDRLEN + len(data))},
+		Data:   data,
+	}
+}
+
+func TestParseFDBMessage(t *testing.T) {
+	guestMAC := net.HardwareAddr{0xfa, 0x16, 0x3e, 0x00, 0x00, 0x01}
+	msg := makeFDBMessage(t, 15, unix.NUD_REACHABLE, guestMAC, 3)
+	entry, ok := parseFDBMessage(&msg)
+	require.True(t, ok)
+	require.Equal(t, fdbEntry{index: 15, master: 3, addr: guestMAC}, entry)
+
+	msg = makeFDBMessage(t, 15, unix.NUD_PERMANENT, guestMAC, 3)
+	entry, ok = parseFDBMessage(&msg)
+	require.True(t, ok)
+	require.True(t, entry.permanent)
+
+	// entry of device without bridge
+	msg = makeFDBMessage(t, 15, unix.NUD_PERMANENT, guestMAC, 0)
+	_, ok = parseFDBMessage(&msg)
+	require.False(t, ok)
+
+	// address which is not Ethernet address
+	msg = makeFDBMessage(t, 15, unix.NUD_REACHABLE, net.HardwareAddr{0xfa, 0x16}, 3)
+	_, ok = parseFDBMessage(&msg)
+	require.False(t, ok)
+
+	// neighbour of other family
+	msg = makeFDBMessage(t, 15, unix.NUD_REACHABLE, guestMAC, 3)
+	msg.Data[0] = syscall.AF_INET
+	_, ok = parseFDBMessage(&msg)
+	require.False(t, ok)
+
+	// truncated attribute
+	msg = makeFDBMessage(t, 15, unix.NUD_REACHABLE, guestMAC, 3)
+	msg.Data = msg.Data[:len(msg.Data)-2]
+	_, ok = parseFDBMessage(&msg)
+	require.False(t, ok)
+
+	msg = syscall.NetlinkMessage{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWNEIGH}, Data: []byte{1, 2}}
+	_, ok = parseFDBMessage(&msg)
+	require.False(t, ok)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package watcher
+
+import "errors"
+
+func readBridgeFDB() ([]fdbEntry, error) {
+	return nil, errors.New("bridge fdb is not supported on this platform")
+}
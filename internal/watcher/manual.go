@@ -0,0 +1,373 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
+)
+
+// manual override actions
+const (
+	// traffic is dropped until override expires
+	ManualBlock = "block"
+	// traffic is passed and automatic block is suppressed until override expires
+	ManualUnblock = "unblock"
+	// traffic is passed and never blocked until exempt is removed
+	ManualExempt = "exempt"
+)
+
+// allTrafficTypes is traffic type name which selects all types of traffic
+const allTrafficTypes = "all"
+
+var (
+	ErrNetDevNotFound     = errors.New("interface is not watched")
+	ErrNetDevNotExist     = errors.New("interface does not exist")
+	ErrUnknownTrafficType = errors.New("unknown traffic type")
+	ErrWatcherStopped     = errors.New("watcher is stopped")
+	ErrDryRun             = errors.New("traffic is not blocked in dry run")
+)
+
+// attachRequest is manual attach or detach of interface applied by watcher goroutine
+type attachRequest struct {
+	netDevName string
+	attach     bool
+	done       chan error
+}
+
+// manualOverride is operator action which takes precedence over automatic block decisions
+type manualOverride struct {
+	action string
+	// zero time means override without expiration
+	until time.Time
+}
+
+// OverrideState describes manual override of traffic type
+type OverrideState struct {
+	Action string     `json:"action"`
+	Until  *time.Time `json:"until,omitempty"`
+}
+
+// parseTrafficTypes returns traffic types by name, empty name or "all" selects all types
+func parseTrafficTypes(name string) ([]int, error) {
+	if name == "" || name == allTrafficTypes {
+		return trafficTypes, nil
+	}
+	trafType, ok := trafficTypeNames[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownTrafficType, name)
+	}
+
+	return []int{trafType}, nil
+}
+
+func (n *netDevWatcher) overridden(trafType int) bool {
+	_, ok := n.getOverride(trafType)
+
+	return ok
+}
+
+// getOverride returns manual override of traffic type, VLAN watchers follow overrides of interface
+func (n *netDevWatcher) getOverride(trafType int) (manualOverride, bool) {
+	if n.parent != nil {
+		return n.parent.getOverride(trafType)
+	}
+	n.manualMux.Lock()
+	defer n.manualMux.Unlock()
+	override, ok := n.manual[trafType]
+
+	return override, ok
+}
+
+func (n *netDevWatcher) overrideStates() map[string]OverrideState {
+	n.manualMux.Lock()
+	defer n.manualMux.Unlock()
+	result := make(map[string]OverrideState, len(n.manual))
+	for trafType, override := range n.manual {
+		state := OverrideState{Action: override.action}
+		if !override.until.IsZero() {
+			until := override.until
+			state.Until = &until
+		}
+		result[trafficTypeName(trafType)] = state
+	}
+
+	return result
+}
+
+// setOverride applies manual action to traffic type, override with duration expires
+// and traffic type is returned to automatic control by scheduler
+func (n *netDevWatcher) setOverride(trafType int, action string, duration time.Duration) error {
+	override := manualOverride{action: action}
+	if duration > 0 {
+		override.until = time.Now().Add(duration)
+	}
+	n.manualMux.Lock()
+	n.manual[trafType] = override
+	n.manualMux.Unlock()
+	n.log.Infof("Manual %s of %s traffic dev: %s, duration %s", action, trafficTypeName(trafType), n.devInfo(), duration)
+	if err := n.applyManualState(trafType); err != nil {
+		return err
+	}
+	event := events.Event{Action: events.ActionUnblock, Source: events.SourceManual, Duration: duration}
+	if action == ManualBlock {
+		event.Action = events.ActionBlock
+	}
+	n.publish(trafType, event)
+
+	return nil
+}
+
+// clearOverride returns traffic type to automatic control
+func (n *netDevWatcher) clearOverride(trafType int, action string) error {
+	n.manualMux.Lock()
+	override, ok := n.manual[trafType]
+	if !ok || override.action != action {
+		n.manualMux.Unlock()
+
+		return nil
+	}
+	delete(n.manual, trafType)
+	n.manualMux.Unlock()
+	n.log.Infof("Manual %s of %s traffic removed dev: %s", action, trafficTypeName(trafType), n.devInfo())
+
+	return n.applyManualState(trafType)
+}
+
+// expireOverrides returns traffic types with expired overrides to automatic control
+func (n *netDevWatcher) expireOverrides(now time.Time) {
+	n.manualMux.Lock()
+	var expired map[int]manualOverride
+	for trafType, override := range n.manual {
+		if override.until.IsZero() || now.Before(override.until) {
+			continue
+		}
+		if expired == nil {
+			expired = make(map[int]manualOverride)
+		}
+		expired[trafType] = override
+		delete(n.manual, trafType)
+	}
+	n.manualMux.Unlock()
+	for trafType, override := range expired {
+		n.log.Infof("Manual %s of %s traffic expired dev: %s", override.action, trafficTypeName(trafType), n.devInfo())
+		if err := n.applyManualState(trafType); err != nil {
+			n.log.Errorf("Error restore traffic state on interface %s: %s", n.devInfo(), err.Error())
+
+			continue
+		}
+		if override.action == ManualBlock {
+			n.publish(trafType, events.Event{Action: events.ActionUnblock, Source: events.SourceManual})
+		}
+	}
+}
+
+// applyManualState writes state of traffic type to drop map of interface and all its VLANs,
+// otherwise VLAN blocks would drop traffic which is manually unblocked on interface.
+func (n *netDevWatcher) applyManualState(trafType int) error {
+	errs := []error{n.applyDropState(trafType)}
+	for _, vlanWatcher := range n.vlanWatchers() {
+		errs = append(errs, vlanWatcher.applyDropState(trafType))
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyDropState writes state of traffic type to drop map.
+// Traffic without override is passed, in rate limit mode kernel rate limit is restored.
+func (n *netDevWatcher) applyDropState(trafType int) error {
+	policy := n.getPolicy()
+	if policy.blockEnabled && policy.blockMode == rateLimitMode {
+		return n.applyRateLimits()
+	}
+	if override, ok := n.getOverride(trafType); ok && override.action == ManualBlock {
+		return n.updateDropMap(makeBlockConfig(trafType))
+	}
+
+	return n.updateDropMap(makeUnblockConfig(trafType))
+}
+
+// rateLimit returns kernel action for traffic type in rate limit mode with respect to manual override
+func (n *netDevWatcher) rateLimit(trafType int, limit trafficLimit) (uint8, ebpfloader.RateLimit) {
+	override, ok := n.getOverride(trafType)
+	if !ok {
+		// traffic of VLAN aware interface is limited by VLAN
+		if n.vlanAware() {
+			return ebpfloader.ActionPass, ebpfloader.RateLimit{}
+		}
+
+		return limit.rateLimit()
+	}
+	if override.action == ManualBlock {
+		return ebpfloader.ActionDrop, ebpfloader.RateLimit{}
+	}
+
+	return ebpfloader.ActionPass, ebpfloader.RateLimit{}
+}
+
+// manualBlock drops traffic until override expires, drop config is never changed in dry run
+func (n *netDevWatcher) manualBlock(trafType int, duration time.Duration) error {
+	if n.getPolicy().dryRun {
+		return fmt.Errorf("%w: %s", ErrDryRun, n.netDevName)
+	}
+
+	return n.setOverride(trafType, ManualBlock, duration)
+}
+
+// manualUnblock unblocks traffic and suppresses automatic block,
+// if duration is not set block delay of traffic type is used
+func (n *netDevWatcher) manualUnblock(trafType int, duration time.Duration) error {
+	if duration <= 0 {
+		limits := n.getLimits()
+		duration = limits.get(trafType).dropDelay
+	}
+
+	return n.setOverride(trafType, ManualUnblock, duration)
+}
+
+func (n *netDevWatcher) manualExempt(trafType int, exempt bool) error {
+	if !exempt {
+		return n.clearOverride(trafType, ManualExempt)
+	}
+
+	return n.setOverride(trafType, ManualExempt, 0)
+}
+
+func makeBlockConfig(trafType int) updateDropConfig {
+	result := updateDropConfig{}
+	result.set(trafType, blockAction)
+
+	return result
+}
+
+func (w *Watcher) findNetDevWatcher(netDevName string) (*netDevWatcher, error) {
+	w.devMux.RLock()
+	defer w.devMux.RUnlock()
+	for _, devWatcher := range w.devWatcherMap {
+		if devWatcher.netDevName == netDevName {
+			return devWatcher, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNetDevNotFound, netDevName)
+}
+
+func (w *Watcher) applyManual(netDevName, trafTypeName string, apply func(*netDevWatcher, int) error) error {
+	trafTypes, err := parseTrafficTypes(trafTypeName)
+	if err != nil {
+		return err
+	}
+	devWatcher, err := w.findNetDevWatcher(netDevName)
+	if err != nil {
+		return err
+	}
+	for _, trafType := range trafTypes {
+		if err := apply(devWatcher, trafType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Block drops traffic of interface regardless of thresholds.
+// Block with zero duration lasts until Unblock is called.
+// Empty traffic type or "all" selects all types of traffic.
+func (w *Watcher) Block(netDevName, trafType string, duration time.Duration) error {
+	return w.applyManual(netDevName, trafType, func(devWatcher *netDevWatcher, trafType int) error {
+		return devWatcher.manualBlock(trafType, duration)
+	})
+}
+
+// Unblock passes traffic of interface and suppresses automatic block for duration,
+// if duration is zero block delay of traffic type is used
+func (w *Watcher) Unblock(netDevName, trafType string, duration time.Duration) error {
+	return w.applyManual(netDevName, trafType, func(devWatcher *netDevWatcher, trafType int) error {
+		return devWatcher.manualUnblock(trafType, duration)
+	})
+}
+
+// Exempt excludes traffic of interface from blocking until exempt is removed
+func (w *Watcher) Exempt(netDevName, trafType string, exempt bool) error {
+	return w.applyManual(netDevName, trafType, func(devWatcher *netDevWatcher, trafType int) error {
+		return devWatcher.manualExempt(trafType, exempt)
+	})
+}
+
+// applyAttach attaches or detaches interface and keeps decision
+// regardless of device list and regexp until program restart
+func (w *Watcher) applyAttach(req attachRequest) error {
+	if !req.attach {
+		w.devMux.Lock()
+		w.attachOverrides[req.netDevName] = false
+		w.devMux.Unlock()
+		for _, devWatcher := range w.devWatcherMap {
+			if devWatcher.netDevName == req.netDevName {
+				w.log.Infof("Manual detach of interface %s", devWatcher.devInfo())
+				w.detachNetDev(devWatcher)
+			}
+		}
+
+		return nil
+	}
+	netDevs, err := listInterfaces()
+	if err != nil {
+		return err
+	}
+	for _, netDev := range netDevs {
+		if netDev.Name != req.netDevName {
+			continue
+		}
+		w.devMux.Lock()
+		w.attachOverrides[req.netDevName] = true
+		w.devMux.Unlock()
+		w.log.Infof("Manual attach of interface %s (%d)", netDev.Name, netDev.Index)
+		w.attachNetDev(netDev.Index, netDev.Name)
+		if _, ok := w.devWatcherMap[netDev.Index]; !ok {
+			return fmt.Errorf("error attach program to interface %s", req.netDevName)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrNetDevNotExist, req.netDevName)
+}
+
+func (w *Watcher) sendAttach(netDevName string, attach bool) error {
+	req := attachRequest{netDevName: netDevName, attach: attach, done: make(chan error, 1)}
+	select {
+	case w.attachChan <- req:
+	case <-w.closed:
+		return ErrWatcherStopped
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-w.closed:
+		return ErrWatcherStopped
+	}
+}
+
+// Attach attaches program to interface even if it does not match device list or regexp
+func (w *Watcher) Attach(netDevName string) error {
+	return w.sendAttach(netDevName, true)
+}
+
+// Detach detaches program from interface, interface is not attached by resync anymore
+func (w *Watcher) Detach(netDevName string) error {
+	return w.sendAttach(netDevName, false)
+}
+
+// GetAttachOverrides returns manually attached (true) and detached (false) interfaces
+func (w *Watcher) GetAttachOverrides() map[string]bool {
+	w.devMux.RLock()
+	defer w.devMux.RUnlock()
+	result := make(map[string]bool, len(w.attachOverrides))
+	for name, attach := range w.attachOverrides {
+		result[name] = attach
+	}
+
+	return result
+}
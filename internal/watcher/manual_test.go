@@ -0,0 +1,202 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
+	"github.com/mythvcode/storm-control/internal/watcher/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrafficTypes(t *testing.T) {
+	allTypes := []int{broadcastType, ipv4McastType, ipv6McastType, otherType, arpType, dhcpv4Type, ndType, mldType, raType, unknownUcastType}
+	trafTypes, err := parseTrafficTypes("")
+	require.NoError(t, err)
+	require.Equal(t, allTypes, trafTypes)
+	trafTypes, err = parseTrafficTypes("all")
+	require.NoError(t, err)
+	require.Equal(t, allTypes, trafTypes)
+	trafTypes, err = parseTrafficTypes("ipv6_multicast")
+	require.NoError(t, err)
+	require.Equal(t, []int{ipv6McastType}, trafTypes)
+	trafTypes, err = parseTrafficTypes("dhcpv4")
+	require.NoError(t, err)
+	require.Equal(t, []int{dhcpv4Type}, trafTypes)
+	_, err = parseTrafficTypes("unicast")
+	require.ErrorIs(t, err, ErrUnknownTrafficType)
+}
+
+func TestManualBlockExpire(t *testing.T) {
+	ebpfMock := mocks.NewMockeBPFProg(t)
+	watcher := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, 0), testUnblockCheck, backoffConfig{}, ebpfMock)
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{Broadcast: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{Broadcast: ebpfloader.ActionDrop}, nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{}).Return(nil).Once()
+	eventsMock := mocks.NewMockeventPublisher(t)
+	watcher.events = eventsMock
+	eventsMock.EXPECT().Publish(events.Event{
+		Action:      events.ActionBlock,
+		Source:      events.SourceManual,
+		Interface:   "test_name",
+		Index:       1,
+		TrafficType: "broadcast",
+		Duration:    20 * time.Millisecond,
+	}).Once()
+	eventsMock.EXPECT().Publish(events.Event{
+		Action:      events.ActionUnblock,
+		Source:      events.SourceManual,
+		Interface:   "test_name",
+		Index:       1,
+		TrafficType: "broadcast",
+	}).Once()
+	require.NoError(t, watcher.manualBlock(broadcastType, 20*time.Millisecond))
+	require.Equal(t, ManualBlock, watcher.state().Overrides["broadcast"].Action)
+	require.NotNil(t, watcher.state().Overrides["broadcast"].Until)
+	watcher.expireOverrides(time.Now())
+	require.True(t, watcher.overridden(broadcastType))
+
+	// traffic is unblocked and unblock event is sent after expiration
+	watcher.expireOverrides(time.Now().Add(time.Second))
+	require.False(t, watcher.overridden(broadcastType))
+}
+
+func TestManualOverrideSkipsCalculation(t *testing.T) {
+	ebpfMock := mocks.NewMockeBPFProg(t)
+	watcher := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, 0), testUnblockCheck, backoffConfig{}, ebpfMock)
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil)
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{}).Return(nil)
+	require.NoError(t, watcher.manualExempt(broadcastType, true))
+	blockConf := watcher.calculateBlocks(&ebpfloader.PacketCounter{}, &ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 100},
+		IPv4MCast: ebpfloader.TrafInfo{Passed: 100},
+	})
+	require.Equal(t, updateDropConfig{ipv4: blockAction}, blockConf)
+	require.Nil(t, watcher.state().Overrides["broadcast"].Until)
+
+	require.NoError(t, watcher.manualExempt(broadcastType, false))
+	require.False(t, watcher.overridden(broadcastType))
+	// exempt false does not remove other overrides
+	watcher.manual[ipv4McastType] = manualOverride{action: ManualBlock}
+	require.NoError(t, watcher.manualExempt(ipv4McastType, false))
+	require.True(t, watcher.overridden(ipv4McastType))
+}
+
+func TestManualRateLimitMode(t *testing.T) {
+	ebpfMock := mocks.NewMockeBPFProg(t)
+	watcher := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, 0), testUnblockCheck, backoffConfig{}, ebpfMock)
+	watcher.policy = netDevPolicy{blockEnabled: true, blockMode: rateLimitMode}
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil)
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{
+		Broadcast:        ebpfloader.ActionRateLimit,
+		IPv4MCast:        ebpfloader.ActionDrop,
+		IPv6MCast:        ebpfloader.ActionRateLimit,
+		Multicast:        ebpfloader.ActionPass,
+		ARP:              ebpfloader.ActionRateLimit,
+		DHCPv4:           ebpfloader.ActionRateLimit,
+		IPv6ND:           ebpfloader.ActionRateLimit,
+		MLD:              ebpfloader.ActionRateLimit,
+		IPv6RA:           ebpfloader.ActionPass,
+		UnknownUcast:     ebpfloader.ActionRateLimit,
+		BroadcastRate:    ebpfloader.RateLimit{Rate: 10},
+		IPv6MCastRate:    ebpfloader.RateLimit{Rate: 10},
+		ARPRate:          ebpfloader.RateLimit{Rate: 10},
+		DHCPv4Rate:       ebpfloader.RateLimit{Rate: 10},
+		IPv6NDRate:       ebpfloader.RateLimit{Rate: 10},
+		MLDRate:          ebpfloader.RateLimit{Rate: 10},
+		UnknownUcastRate: ebpfloader.RateLimit{Rate: 10},
+	}).Return(nil).Once()
+	watcher.manual[otherType] = manualOverride{action: ManualExempt}
+	watcher.manual[raType] = manualOverride{action: ManualExempt}
+	require.NoError(t, watcher.manualBlock(ipv4McastType, 0))
+}
+
+func TestManualUnblockDefaultDuration(t *testing.T) {
+	ebpfMock := mocks.NewMockeBPFProg(t)
+	watcher := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, time.Hour), testUnblockCheck, backoffConfig{}, ebpfMock)
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{IPv6MCast: ebpfloader.ActionDrop}, nil)
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{}).Return(nil)
+	require.NoError(t, watcher.manualUnblock(ipv6McastType, 0))
+	override, ok := watcher.getOverride(ipv6McastType)
+	require.True(t, ok)
+	require.Equal(t, ManualUnblock, override.action)
+	require.WithinDuration(t, time.Now().Add(time.Hour), override.until, time.Minute)
+	watcher.stop()
+}
+
+func TestCheckUnblockOverridden(t *testing.T) {
+	watcher := createWatcher(t)
+	watcher.startBlock(broadcastType, nil, time.Now())
+	watcher.manual[broadcastType] = manualOverride{action: ManualBlock}
+	// manually blocked traffic is not unblocked and automatic block is dropped
+	require.Equal(t, updateDropConfig{}, watcher.checkUnblock(&ebpfloader.PacketCounter{}, time.Now()))
+	require.Empty(t, watcher.sched.blocks)
+}
+
+func TestWatcherManualActions(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	watcher.devWatcherMap[1] = watcher.makeNetDevWatcher(1, "tap1", makeDefaultPolicy(watcher.config))
+	require.ErrorIs(t, watcher.Block("tap2", "", 0), ErrNetDevNotFound)
+	require.ErrorIs(t, watcher.Block("tap1", "unicast", 0), ErrUnknownTrafficType)
+
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil)
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{Broadcast: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{IPv4MCast: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{IPv6MCast: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{Multicast: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{ARP: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{DHCPv4: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{IPv6ND: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{MLD: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{IPv6RA: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{UnknownUcast: ebpfloader.ActionDrop}).Return(nil).Once()
+	require.NoError(t, watcher.Block("tap1", "all", 0))
+	require.Len(t, watcher.GetNetDevStates()[0].Overrides, len(trafficTypes))
+
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{}).Return(nil).Once()
+	require.NoError(t, watcher.Exempt("tap1", "broadcast", true))
+	require.Equal(t, ManualExempt, watcher.GetNetDevStates()[0].Overrides["broadcast"].Action)
+}
+
+func TestManualBlockDryRun(t *testing.T) {
+	watcher, _ := makeTestWatcher(t)
+	policy := makeDefaultPolicy(watcher.config)
+	policy.dryRun = true
+	watcher.devWatcherMap[1] = watcher.makeNetDevWatcher(1, "tap1", policy)
+	// drop config is not changed and override is not set
+	require.ErrorIs(t, watcher.Block("tap1", "broadcast", time.Hour), ErrDryRun)
+	require.Empty(t, watcher.GetNetDevStates()[0].Overrides)
+
+	// automatic block is suppressed in dry run as well
+	require.NoError(t, watcher.Unblock("tap1", "broadcast", time.Hour))
+	require.Equal(t, ManualUnblock, watcher.GetNetDevStates()[0].Overrides["broadcast"].Action)
+}
+
+func TestManualAttachDetach(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	watcher.config.StaticDevList = []string{"tap1"}
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	watcher.resync()
+	require.Len(t, watcher.devWatcherMap, 1)
+
+	// manually attached interface is kept by resync
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	require.NoError(t, watcher.applyAttach(attachRequest{netDevName: "tap5", attach: true}))
+	require.ErrorIs(t, watcher.applyAttach(attachRequest{netDevName: "tap2", attach: true}), ErrNetDevNotExist)
+	// manually detached interface is not attached by resync
+	ebpfMock.EXPECT().DetachXDP(1).Return(nil)
+	require.NoError(t, watcher.applyAttach(attachRequest{netDevName: "tap1", attach: false}))
+	watcher.resync()
+	require.Len(t, watcher.devWatcherMap, 1)
+	require.Contains(t, watcher.devWatcherMap, 5)
+	require.Equal(t, map[string]bool{"tap1": false, "tap5": true}, watcher.GetAttachOverrides())
+}
+
+func TestAttachStoppedWatcher(t *testing.T) {
+	watcher, _ := makeTestWatcher(t)
+	close(watcher.closed)
+	require.ErrorIs(t, watcher.Attach("tap1"), ErrWatcherStopped)
+	require.ErrorIs(t, watcher.Detach("tap1"), ErrWatcherStopped)
+}
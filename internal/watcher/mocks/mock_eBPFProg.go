@@ -20,22 +20,32 @@ func (_m *MockeBPFProg) EXPECT() *MockeBPFProg_Expecter {
 	return &MockeBPFProg_Expecter{mock: &_m.Mock}
 }
 
-// AttachXDP provides a mock function with given fields: devIndex
-func (_m *MockeBPFProg) AttachXDP(devIndex int) error {
-	ret := _m.Called(devIndex)
+// AttachXDP provides a mock function with given fields: devIndex, mode
+func (_m *MockeBPFProg) AttachXDP(devIndex int, mode string) (string, error) {
+	ret := _m.Called(devIndex, mode)
 
 	if len(ret) == 0 {
 		panic("no return value specified for AttachXDP")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(int) error); ok {
-		r0 = rf(devIndex)
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, string) (string, error)); ok {
+		return rf(devIndex, mode)
+	}
+	if rf, ok := ret.Get(0).(func(int, string) string); ok {
+		r0 = rf(devIndex, mode)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(string)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(int, string) error); ok {
+		r1 = rf(devIndex, mode)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // MockeBPFProg_AttachXDP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AttachXDP'
@@ -45,23 +55,71 @@ type MockeBPFProg_AttachXDP_Call struct {
 
 // AttachXDP is a helper method to define mock.On call
 //   - devIndex int
-func (_e *MockeBPFProg_Expecter) AttachXDP(devIndex interface{}) *MockeBPFProg_AttachXDP_Call {
-	return &MockeBPFProg_AttachXDP_Call{Call: _e.mock.On("AttachXDP", devIndex)}
+//   - mode string
+func (_e *MockeBPFProg_Expecter) AttachXDP(devIndex interface{}, mode interface{}) *MockeBPFProg_AttachXDP_Call {
+	return &MockeBPFProg_AttachXDP_Call{Call: _e.mock.On("AttachXDP", devIndex, mode)}
 }
 
-func (_c *MockeBPFProg_AttachXDP_Call) Run(run func(devIndex int)) *MockeBPFProg_AttachXDP_Call {
+func (_c *MockeBPFProg_AttachXDP_Call) Run(run func(devIndex int, mode string)) *MockeBPFProg_AttachXDP_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(int))
+		run(args[0].(int), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockeBPFProg_AttachXDP_Call) Return(_a0 error) *MockeBPFProg_AttachXDP_Call {
+func (_c *MockeBPFProg_AttachXDP_Call) Return(_a0 string, _a1 error) *MockeBPFProg_AttachXDP_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockeBPFProg_AttachXDP_Call) RunAndReturn(run func(int, string) (string, error)) *MockeBPFProg_AttachXDP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AttachedNetDevs provides a mock function with no fields
+func (_m *MockeBPFProg) AttachedNetDevs() []int {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for AttachedNetDevs")
+	}
+
+	var r0 []int
+	if rf, ok := ret.Get(0).(func() []int); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	return r0
+}
+
+// MockeBPFProg_AttachedNetDevs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AttachedNetDevs'
+type MockeBPFProg_AttachedNetDevs_Call struct {
+	*mock.Call
+}
+
+// AttachedNetDevs is a helper method to define mock.On call
+func (_e *MockeBPFProg_Expecter) AttachedNetDevs() *MockeBPFProg_AttachedNetDevs_Call {
+	return &MockeBPFProg_AttachedNetDevs_Call{Call: _e.mock.On("AttachedNetDevs")}
+}
+
+func (_c *MockeBPFProg_AttachedNetDevs_Call) Run(run func()) *MockeBPFProg_AttachedNetDevs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_AttachedNetDevs_Call) Return(_a0 []int) *MockeBPFProg_AttachedNetDevs_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockeBPFProg_AttachXDP_Call) RunAndReturn(run func(int) error) *MockeBPFProg_AttachXDP_Call {
+func (_c *MockeBPFProg_AttachedNetDevs_Call) RunAndReturn(run func() []int) *MockeBPFProg_AttachedNetDevs_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -177,6 +235,63 @@ func (_c *MockeBPFProg_ForceDetachXDP_Call) RunAndReturn(run func(int)) *MockeBP
 	return _c
 }
 
+// GetCounterStat provides a mock function with no fields
+func (_m *MockeBPFProg) GetCounterStat() (ebpfloader.CounterStat, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCounterStat")
+	}
+
+	var r0 ebpfloader.CounterStat
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (ebpfloader.CounterStat, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() ebpfloader.CounterStat); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(ebpfloader.CounterStat)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockeBPFProg_GetCounterStat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCounterStat'
+type MockeBPFProg_GetCounterStat_Call struct {
+	*mock.Call
+}
+
+// GetCounterStat is a helper method to define mock.On call
+func (_e *MockeBPFProg_Expecter) GetCounterStat() *MockeBPFProg_GetCounterStat_Call {
+	return &MockeBPFProg_GetCounterStat_Call{Call: _e.mock.On("GetCounterStat")}
+}
+
+func (_c *MockeBPFProg_GetCounterStat_Call) Run(run func()) *MockeBPFProg_GetCounterStat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_GetCounterStat_Call) Return(_a0 ebpfloader.CounterStat, _a1 error) *MockeBPFProg_GetCounterStat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockeBPFProg_GetCounterStat_Call) RunAndReturn(run func() (ebpfloader.CounterStat, error)) *MockeBPFProg_GetCounterStat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetDevDropCfg provides a mock function with given fields: devIndex
 func (_m *MockeBPFProg) GetDevDropCfg(devIndex int) (ebpfloader.DropPKT, error) {
 	ret := _m.Called(devIndex)
@@ -233,27 +348,141 @@ func (_c *MockeBPFProg_GetDevDropCfg_Call) RunAndReturn(run func(int) (ebpfloade
 	return _c
 }
 
-// GetDevStat provides a mock function with given fields: devIndex
-func (_m *MockeBPFProg) GetDevStat(devIndex int) (ebpfloader.PacketCounter, error) {
-	ret := _m.Called(devIndex)
+// GetTalkerStat provides a mock function with no fields
+func (_m *MockeBPFProg) GetTalkerStat() (ebpfloader.TalkerStat, error) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetDevStat")
+		panic("no return value specified for GetTalkerStat")
 	}
 
-	var r0 ebpfloader.PacketCounter
+	var r0 ebpfloader.TalkerStat
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int) (ebpfloader.PacketCounter, error)); ok {
-		return rf(devIndex)
+	if rf, ok := ret.Get(0).(func() (ebpfloader.TalkerStat, error)); ok {
+		return rf()
 	}
-	if rf, ok := ret.Get(0).(func(int) ebpfloader.PacketCounter); ok {
-		r0 = rf(devIndex)
+	if rf, ok := ret.Get(0).(func() ebpfloader.TalkerStat); ok {
+		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(ebpfloader.PacketCounter)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(ebpfloader.TalkerStat)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int) error); ok {
-		r1 = rf(devIndex)
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockeBPFProg_GetTalkerStat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetTalkerStat'
+type MockeBPFProg_GetTalkerStat_Call struct {
+	*mock.Call
+}
+
+// GetTalkerStat is a helper method to define mock.On call
+func (_e *MockeBPFProg_Expecter) GetTalkerStat() *MockeBPFProg_GetTalkerStat_Call {
+	return &MockeBPFProg_GetTalkerStat_Call{Call: _e.mock.On("GetTalkerStat")}
+}
+
+func (_c *MockeBPFProg_GetTalkerStat_Call) Run(run func()) *MockeBPFProg_GetTalkerStat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_GetTalkerStat_Call) Return(_a0 ebpfloader.TalkerStat, _a1 error) *MockeBPFProg_GetTalkerStat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockeBPFProg_GetTalkerStat_Call) RunAndReturn(run func() (ebpfloader.TalkerStat, error)) *MockeBPFProg_GetTalkerStat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVLANCounterStat provides a mock function with no fields
+func (_m *MockeBPFProg) GetVLANCounterStat() (ebpfloader.VLANCounterStat, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVLANCounterStat")
+	}
+
+	var r0 ebpfloader.VLANCounterStat
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (ebpfloader.VLANCounterStat, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() ebpfloader.VLANCounterStat); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(ebpfloader.VLANCounterStat)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockeBPFProg_GetVLANCounterStat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVLANCounterStat'
+type MockeBPFProg_GetVLANCounterStat_Call struct {
+	*mock.Call
+}
+
+// GetVLANCounterStat is a helper method to define mock.On call
+func (_e *MockeBPFProg_Expecter) GetVLANCounterStat() *MockeBPFProg_GetVLANCounterStat_Call {
+	return &MockeBPFProg_GetVLANCounterStat_Call{Call: _e.mock.On("GetVLANCounterStat")}
+}
+
+func (_c *MockeBPFProg_GetVLANCounterStat_Call) Run(run func()) *MockeBPFProg_GetVLANCounterStat_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_GetVLANCounterStat_Call) Return(_a0 ebpfloader.VLANCounterStat, _a1 error) *MockeBPFProg_GetVLANCounterStat_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockeBPFProg_GetVLANCounterStat_Call) RunAndReturn(run func() (ebpfloader.VLANCounterStat, error)) *MockeBPFProg_GetVLANCounterStat_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetVLANDropCfg provides a mock function with given fields: devIndex, vlan
+func (_m *MockeBPFProg) GetVLANDropCfg(devIndex int, vlan uint16) (ebpfloader.DropPKT, error) {
+	ret := _m.Called(devIndex, vlan)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVLANDropCfg")
+	}
+
+	var r0 ebpfloader.DropPKT
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, uint16) (ebpfloader.DropPKT, error)); ok {
+		return rf(devIndex, vlan)
+	}
+	if rf, ok := ret.Get(0).(func(int, uint16) ebpfloader.DropPKT); ok {
+		r0 = rf(devIndex, vlan)
+	} else {
+		r0 = ret.Get(0).(ebpfloader.DropPKT)
+	}
+
+	if rf, ok := ret.Get(1).(func(int, uint16) error); ok {
+		r1 = rf(devIndex, vlan)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -261,30 +490,31 @@ func (_m *MockeBPFProg) GetDevStat(devIndex int) (ebpfloader.PacketCounter, erro
 	return r0, r1
 }
 
-// MockeBPFProg_GetDevStat_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetDevStat'
-type MockeBPFProg_GetDevStat_Call struct {
+// MockeBPFProg_GetVLANDropCfg_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetVLANDropCfg'
+type MockeBPFProg_GetVLANDropCfg_Call struct {
 	*mock.Call
 }
 
-// GetDevStat is a helper method to define mock.On call
+// GetVLANDropCfg is a helper method to define mock.On call
 //   - devIndex int
-func (_e *MockeBPFProg_Expecter) GetDevStat(devIndex interface{}) *MockeBPFProg_GetDevStat_Call {
-	return &MockeBPFProg_GetDevStat_Call{Call: _e.mock.On("GetDevStat", devIndex)}
+//   - vlan uint16
+func (_e *MockeBPFProg_Expecter) GetVLANDropCfg(devIndex interface{}, vlan interface{}) *MockeBPFProg_GetVLANDropCfg_Call {
+	return &MockeBPFProg_GetVLANDropCfg_Call{Call: _e.mock.On("GetVLANDropCfg", devIndex, vlan)}
 }
 
-func (_c *MockeBPFProg_GetDevStat_Call) Run(run func(devIndex int)) *MockeBPFProg_GetDevStat_Call {
+func (_c *MockeBPFProg_GetVLANDropCfg_Call) Run(run func(devIndex int, vlan uint16)) *MockeBPFProg_GetVLANDropCfg_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(int))
+		run(args[0].(int), args[1].(uint16))
 	})
 	return _c
 }
 
-func (_c *MockeBPFProg_GetDevStat_Call) Return(_a0 ebpfloader.PacketCounter, _a1 error) *MockeBPFProg_GetDevStat_Call {
+func (_c *MockeBPFProg_GetVLANDropCfg_Call) Return(_a0 ebpfloader.DropPKT, _a1 error) *MockeBPFProg_GetVLANDropCfg_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockeBPFProg_GetDevStat_Call) RunAndReturn(run func(int) (ebpfloader.PacketCounter, error)) *MockeBPFProg_GetDevStat_Call {
+func (_c *MockeBPFProg_GetVLANDropCfg_Call) RunAndReturn(run func(int, uint16) (ebpfloader.DropPKT, error)) *MockeBPFProg_GetVLANDropCfg_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -336,6 +566,238 @@ func (_c *MockeBPFProg_UpdateDevDropCfg_Call) RunAndReturn(run func(int, ebpfloa
 	return _c
 }
 
+// UpdateDevDropCfgs provides a mock function with given fields: cfgs
+func (_m *MockeBPFProg) UpdateDevDropCfgs(cfgs ebpfloader.DropConf) error {
+	ret := _m.Called(cfgs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDevDropCfgs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(ebpfloader.DropConf) error); ok {
+		r0 = rf(cfgs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockeBPFProg_UpdateDevDropCfgs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateDevDropCfgs'
+type MockeBPFProg_UpdateDevDropCfgs_Call struct {
+	*mock.Call
+}
+
+// UpdateDevDropCfgs is a helper method to define mock.On call
+//   - cfgs ebpfloader.DropConf
+func (_e *MockeBPFProg_Expecter) UpdateDevDropCfgs(cfgs interface{}) *MockeBPFProg_UpdateDevDropCfgs_Call {
+	return &MockeBPFProg_UpdateDevDropCfgs_Call{Call: _e.mock.On("UpdateDevDropCfgs", cfgs)}
+}
+
+func (_c *MockeBPFProg_UpdateDevDropCfgs_Call) Run(run func(cfgs ebpfloader.DropConf)) *MockeBPFProg_UpdateDevDropCfgs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(ebpfloader.DropConf))
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateDevDropCfgs_Call) Return(_a0 error) *MockeBPFProg_UpdateDevDropCfgs_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateDevDropCfgs_Call) RunAndReturn(run func(ebpfloader.DropConf) error) *MockeBPFProg_UpdateDevDropCfgs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateKnownMACs provides a mock function with given fields: known
+func (_m *MockeBPFProg) UpdateKnownMACs(known ebpfloader.KnownMACs) error {
+	ret := _m.Called(known)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateKnownMACs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(ebpfloader.KnownMACs) error); ok {
+		r0 = rf(known)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockeBPFProg_UpdateKnownMACs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateKnownMACs'
+type MockeBPFProg_UpdateKnownMACs_Call struct {
+	*mock.Call
+}
+
+// UpdateKnownMACs is a helper method to define mock.On call
+//   - known ebpfloader.KnownMACs
+func (_e *MockeBPFProg_Expecter) UpdateKnownMACs(known interface{}) *MockeBPFProg_UpdateKnownMACs_Call {
+	return &MockeBPFProg_UpdateKnownMACs_Call{Call: _e.mock.On("UpdateKnownMACs", known)}
+}
+
+func (_c *MockeBPFProg_UpdateKnownMACs_Call) Run(run func(known ebpfloader.KnownMACs)) *MockeBPFProg_UpdateKnownMACs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(ebpfloader.KnownMACs))
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateKnownMACs_Call) Return(_a0 error) *MockeBPFProg_UpdateKnownMACs_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateKnownMACs_Call) RunAndReturn(run func(ebpfloader.KnownMACs) error) *MockeBPFProg_UpdateKnownMACs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateTalkerNetDevs provides a mock function with given fields: netDevs
+func (_m *MockeBPFProg) UpdateTalkerNetDevs(netDevs []int) error {
+	ret := _m.Called(netDevs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTalkerNetDevs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]int) error); ok {
+		r0 = rf(netDevs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockeBPFProg_UpdateTalkerNetDevs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateTalkerNetDevs'
+type MockeBPFProg_UpdateTalkerNetDevs_Call struct {
+	*mock.Call
+}
+
+// UpdateTalkerNetDevs is a helper method to define mock.On call
+//   - netDevs []int
+func (_e *MockeBPFProg_Expecter) UpdateTalkerNetDevs(netDevs interface{}) *MockeBPFProg_UpdateTalkerNetDevs_Call {
+	return &MockeBPFProg_UpdateTalkerNetDevs_Call{Call: _e.mock.On("UpdateTalkerNetDevs", netDevs)}
+}
+
+func (_c *MockeBPFProg_UpdateTalkerNetDevs_Call) Run(run func(netDevs []int)) *MockeBPFProg_UpdateTalkerNetDevs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]int))
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateTalkerNetDevs_Call) Return(_a0 error) *MockeBPFProg_UpdateTalkerNetDevs_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateTalkerNetDevs_Call) RunAndReturn(run func([]int) error) *MockeBPFProg_UpdateTalkerNetDevs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateVLANDropCfg provides a mock function with given fields: devIndex, vlan, cfg
+func (_m *MockeBPFProg) UpdateVLANDropCfg(devIndex int, vlan uint16, cfg ebpfloader.DropPKT) error {
+	ret := _m.Called(devIndex, vlan, cfg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateVLANDropCfg")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, uint16, ebpfloader.DropPKT) error); ok {
+		r0 = rf(devIndex, vlan, cfg)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockeBPFProg_UpdateVLANDropCfg_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateVLANDropCfg'
+type MockeBPFProg_UpdateVLANDropCfg_Call struct {
+	*mock.Call
+}
+
+// UpdateVLANDropCfg is a helper method to define mock.On call
+//   - devIndex int
+//   - vlan uint16
+//   - cfg ebpfloader.DropPKT
+func (_e *MockeBPFProg_Expecter) UpdateVLANDropCfg(devIndex interface{}, vlan interface{}, cfg interface{}) *MockeBPFProg_UpdateVLANDropCfg_Call {
+	return &MockeBPFProg_UpdateVLANDropCfg_Call{Call: _e.mock.On("UpdateVLANDropCfg", devIndex, vlan, cfg)}
+}
+
+func (_c *MockeBPFProg_UpdateVLANDropCfg_Call) Run(run func(devIndex int, vlan uint16, cfg ebpfloader.DropPKT)) *MockeBPFProg_UpdateVLANDropCfg_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(uint16), args[2].(ebpfloader.DropPKT))
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateVLANDropCfg_Call) Return(_a0 error) *MockeBPFProg_UpdateVLANDropCfg_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateVLANDropCfg_Call) RunAndReturn(run func(int, uint16, ebpfloader.DropPKT) error) *MockeBPFProg_UpdateVLANDropCfg_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateVLANNetDevs provides a mock function with given fields: netDevs
+func (_m *MockeBPFProg) UpdateVLANNetDevs(netDevs []int) error {
+	ret := _m.Called(netDevs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateVLANNetDevs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]int) error); ok {
+		r0 = rf(netDevs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockeBPFProg_UpdateVLANNetDevs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateVLANNetDevs'
+type MockeBPFProg_UpdateVLANNetDevs_Call struct {
+	*mock.Call
+}
+
+// UpdateVLANNetDevs is a helper method to define mock.On call
+//   - netDevs []int
+func (_e *MockeBPFProg_Expecter) UpdateVLANNetDevs(netDevs interface{}) *MockeBPFProg_UpdateVLANNetDevs_Call {
+	return &MockeBPFProg_UpdateVLANNetDevs_Call{Call: _e.mock.On("UpdateVLANNetDevs", netDevs)}
+}
+
+func (_c *MockeBPFProg_UpdateVLANNetDevs_Call) Run(run func(netDevs []int)) *MockeBPFProg_UpdateVLANNetDevs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]int))
+	})
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateVLANNetDevs_Call) Return(_a0 error) *MockeBPFProg_UpdateVLANNetDevs_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockeBPFProg_UpdateVLANNetDevs_Call) RunAndReturn(run func([]int) error) *MockeBPFProg_UpdateVLANNetDevs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockeBPFProg creates a new instance of MockeBPFProg. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockeBPFProg(t interface {
@@ -0,0 +1,68 @@
+// Code generated by mockery v2.52.3. DO NOT EDIT.
+
+package mocks
+
+import (
+	events "github.com/mythvcode/storm-control/internal/events"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockeventPublisher is an autogenerated mock type for the eventPublisher type
+type MockeventPublisher struct {
+	mock.Mock
+}
+
+type MockeventPublisher_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockeventPublisher) EXPECT() *MockeventPublisher_Expecter {
+	return &MockeventPublisher_Expecter{mock: &_m.Mock}
+}
+
+// Publish provides a mock function with given fields: event
+func (_m *MockeventPublisher) Publish(event events.Event) {
+	_m.Called(event)
+}
+
+// MockeventPublisher_Publish_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Publish'
+type MockeventPublisher_Publish_Call struct {
+	*mock.Call
+}
+
+// Publish is a helper method to define mock.On call
+//   - event events.Event
+func (_e *MockeventPublisher_Expecter) Publish(event interface{}) *MockeventPublisher_Publish_Call {
+	return &MockeventPublisher_Publish_Call{Call: _e.mock.On("Publish", event)}
+}
+
+func (_c *MockeventPublisher_Publish_Call) Run(run func(event events.Event)) *MockeventPublisher_Publish_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(events.Event))
+	})
+	return _c
+}
+
+func (_c *MockeventPublisher_Publish_Call) Return() *MockeventPublisher_Publish_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockeventPublisher_Publish_Call) RunAndReturn(run func(events.Event)) *MockeventPublisher_Publish_Call {
+	_c.Run(run)
+	return _c
+}
+
+// NewMockeventPublisher creates a new instance of MockeventPublisher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockeventPublisher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockeventPublisher {
+	mock := &MockeventPublisher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,22 +1,33 @@
 package watcher
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
 	"github.com/mythvcode/storm-control/internal/logger"
 )
 
+// traffic types in order of ebpfloader.TrafficTypes
 const (
 	_ = iota
 	broadcastType
 	ipv4McastType
 	ipv6McastType
 	otherType
+	// sub-classes of broadcast
+	arpType
+	dhcpv4Type
+	// sub-classes of IPv6 multicast
+	ndType
+	mldType
+	raType
+	// unicast with not expected source or unknown destination address
+	unknownUcastType
 )
 
 const (
@@ -24,25 +35,204 @@ const (
 	blockAction   = 2
 )
 
+// trafficTypes are all types of traffic in order of evaluation
+var trafficTypes = []int{
+	broadcastType,
+	ipv4McastType,
+	ipv6McastType,
+	otherType,
+	arpType,
+	dhcpv4Type,
+	ndType,
+	mldType,
+	raType,
+	unknownUcastType,
+}
+
 type netDevWatcher struct {
-	netDevIndex      int
-	netDevName       string
+	netDevIndex int
+	netDevName  string
+	// VLAN of watcher created for VLAN of VLAN aware interface, nil for interface watcher
+	vlan *uint16
+	// interface watcher of VLAN watcher, manual overrides of interface apply to all its VLANs
+	parent *netDevWatcher
+	// policy, limits and unblockCheck can be changed by config reload
+	settingsMux  sync.RWMutex
+	policy       netDevPolicy
+	limits       trafficLimits
+	unblockCheck unblockCheck
+	backoff      *blockBackoff
+	ebpfProg     eBPFProg
+	// events are not sent if publisher is not set
+	events     eventPublisher
+	dropMapMux sync.Mutex
+	// drop map of stopped watcher is not changed, protected by dropMapMux
+	stopped bool
+	// manual overrides by traffic type, take precedence over automatic decisions
+	manualMux sync.Mutex
+	manual    map[int]manualOverride
+	// per second traffic differences for rate statistic
+	history *rateHistory
+	// block decisions state, accessed only by scheduler
+	sched schedState
+	// watchers of VLANs of VLAN aware interface, created by scheduler for every seen VLAN
+	vlansMux sync.Mutex
+	vlans    map[uint16]*netDevWatcher
+	// sources with highest rate of last second, set by scheduler if top talkers are tracked
+	talkersMux sync.Mutex
+	talkers    []events.Talker
+	log        *logger.Logger
+}
+
+// schedState is state of interface evaluated by scheduler on every counters read
+type schedState struct {
+	// false until first counters of interface are read
+	started   bool
+	prevStats ebpfloader.PacketCounter
+	// traffic per second of last evaluation
+	observed ebpfloader.PacketCounter
+	// automatically blocked traffic types
+	blocks map[int]*blockState
+}
+
+// blockState is automatic block of traffic type
+type blockState struct {
+	// block time of resumed traffic is unknown
+	blockedAt time.Time
+	// time of next unblock check, first check is made after block delay
+	checkAt time.Time
+	// counters at beginning of unblock check window, zero window start before first check
+	windowStats  ebpfloader.PacketCounter
+	windowStart  time.Time
+	quietWindows int
+}
+
+// unblock process settings
+type unblockCheck struct {
+	// interval of dropped packets rate check
+	interval time.Duration
+	// number of consecutive intervals with dropped packets rate below unblock threshold
+	quietWindows int
+}
+
+type trafficLimit struct {
 	blockThreshold   uint64
 	unblockThreshold uint64
-	dropDelay        time.Duration
-	stopChan         chan struct{}
-	ebpfProg         eBPFProg
-	dropMapMux       sync.Mutex
+	// thresholds in bytes per second, 0 disables bytes check
+	blockThresholdBytes   uint64
+	unblockThresholdBytes uint64
+	dropDelay             time.Duration
+	// token bucket size for rate limit mode, if 0 equal to block threshold
+	burst uint64
+	// exempt traffic is never blocked
+	exempt bool
+}
+
+// block thresholds and delays for each type of traffic
+type trafficLimits struct {
+	broadcast    trafficLimit
+	ipv4Mcast    trafficLimit
+	ipv6Mcast    trafficLimit
+	other        trafficLimit
+	arp          trafficLimit
+	dhcpv4       trafficLimit
+	nd           trafficLimit
+	mld          trafficLimit
+	ra           trafficLimit
+	unknownUcast trafficLimit
+}
+
+func newTrafficLimit(blockThreshold, unblockThreshold uint64, dropDelay time.Duration) trafficLimit {
+	return trafficLimit{
+		blockThreshold:   blockThreshold,
+		unblockThreshold: unblockThreshold,
+		dropDelay:        dropDelay,
+	}
+}
+
+// same limits for all types of traffic, unblock threshold is equal to block threshold
+func newUniformTrafficLimits(blockThreshold uint64, dropDelay time.Duration) trafficLimits {
+	limit := newTrafficLimit(blockThreshold, blockThreshold, dropDelay)
+
+	return trafficLimits{
+		broadcast:    limit,
+		ipv4Mcast:    limit,
+		ipv6Mcast:    limit,
+		other:        limit,
+		arp:          limit,
+		dhcpv4:       limit,
+		nd:           limit,
+		mld:          limit,
+		ra:           limit,
+		unknownUcast: limit,
+	}
+}
+
+func (t *trafficLimits) limit(trafType int) *trafficLimit {
+	switch trafType {
+	case broadcastType:
+		return &t.broadcast
+	case ipv4McastType:
+		return &t.ipv4Mcast
+	case ipv6McastType:
+		return &t.ipv6Mcast
+	case otherType:
+		return &t.other
+	case arpType:
+		return &t.arp
+	case dhcpv4Type:
+		return &t.dhcpv4
+	case ndType:
+		return &t.nd
+	case mldType:
+		return &t.mld
+	case raType:
+		return &t.ra
+	case unknownUcastType:
+		return &t.unknownUcast
+	}
+
+	return nil
+}
+
+func (t *trafficLimits) get(trafType int) trafficLimit {
+	if limit := t.limit(trafType); limit != nil {
+		return *limit
+	}
+
+	return trafficLimit{}
+}
 
-	dropState dropStateConfig
-	log       *logger.Logger
+func (t *trafficLimits) setExempt(trafType int) {
+	if limit := t.limit(trafType); limit != nil {
+		limit.exempt = true
+	}
 }
 
-type dropStateConfig struct {
-	brDropped        atomic.Bool
-	ipv4McastDropped atomic.Bool
-	ipv6McastDropped atomic.Bool
-	other            atomic.Bool
+func (t *trafficLimit) rateLimit() (uint8, ebpfloader.RateLimit) {
+	if t.exempt {
+		return ebpfloader.ActionPass, ebpfloader.RateLimit{}
+	}
+
+	return ebpfloader.ActionRateLimit, ebpfloader.RateLimit{Rate: t.blockThreshold, Burst: t.burst}
+}
+
+// exceeded checks passed traffic against packets and bytes block thresholds
+func (t *trafficLimit) exceeded(delta ebpfloader.TrafInfo) bool {
+	if t.exempt {
+		return false
+	}
+
+	return delta.Passed > t.blockThreshold || (t.blockThresholdBytes != 0 && delta.PassedBytes > t.blockThresholdBytes)
+}
+
+// quiet checks that dropped traffic rate is below packets and bytes unblock thresholds
+func (t *trafficLimit) quiet(delta ebpfloader.TrafInfo, window time.Duration) bool {
+	if float64(delta.Dropped)/window.Seconds() >= float64(t.unblockThreshold) {
+		return false
+	}
+
+	return t.blockThresholdBytes == 0 || float64(delta.DroppedBytes)/window.Seconds() < float64(t.unblockThresholdBytes)
 }
 
 type updateDropConfig struct {
@@ -50,6 +240,12 @@ type updateDropConfig struct {
 	ipv4  uint8
 	ipv6  uint8
 	other uint8
+	arp   uint8
+	dhcp  uint8
+	nd    uint8
+	mld   uint8
+	ra    uint8
+	ucast uint8
 }
 
 // in ebpf kernel module 0 is pass 1 is block
@@ -62,10 +258,57 @@ func getEBPFAction(configValue uint8) uint8 {
 }
 
 func (u *updateDropConfig) isEmpty() bool {
-	return u.br == 0 &&
-		u.ipv4 == 0 &&
-		u.ipv6 == 0 &&
-		u.other == 0
+	return *u == updateDropConfig{}
+}
+
+func (u *updateDropConfig) action(trafType int) *uint8 {
+	switch trafType {
+	case broadcastType:
+		return &u.br
+	case ipv4McastType:
+		return &u.ipv4
+	case ipv6McastType:
+		return &u.ipv6
+	case otherType:
+		return &u.other
+	case arpType:
+		return &u.arp
+	case dhcpv4Type:
+		return &u.dhcp
+	case ndType:
+		return &u.nd
+	case mldType:
+		return &u.mld
+	case raType:
+		return &u.ra
+	case unknownUcastType:
+		return &u.ucast
+	}
+
+	return nil
+}
+
+func (u *updateDropConfig) set(trafType int, action uint8) {
+	if ref := u.action(trafType); ref != nil {
+		*ref = action
+	}
+}
+
+func (u *updateDropConfig) get(trafType int) uint8 {
+	if ref := u.action(trafType); ref != nil {
+		return *ref
+	}
+
+	return 0
+}
+
+// apply changes actions of drop config, types of traffic without action are kept
+func (u *updateDropConfig) apply(cfg *ebpfloader.DropPKT) {
+	for _, trafType := range trafficTypes {
+		if action := u.get(trafType); action != 0 {
+			*trafficFields(trafType).Action(cfg) = getEBPFAction(action)
+		}
+	}
 }
 
 // Creates Interface watcher instance.
@@ -73,24 +316,75 @@ func (u *updateDropConfig) isEmpty() bool {
 func newNetDevWatcher(
 	netDev int,
 	netDevName string,
-	blockThreshold uint64,
-	dropDelay time.Duration,
+	limits trafficLimits,
+	unblockCheck unblockCheck,
+	backoff backoffConfig,
 	ebpfProg eBPFProg,
 ) *netDevWatcher {
 	return &netDevWatcher{
-		netDevIndex:      netDev,
-		netDevName:       netDevName,
-		blockThreshold:   blockThreshold,
-		unblockThreshold: blockThreshold * 3,
-		dropDelay:        dropDelay,
-		stopChan:         make(chan struct{}),
-		ebpfProg:         ebpfProg,
-		log:              logger.GetLogger().With(slog.String(logger.Component, "NetDevWatcher")),
+		netDevIndex:  netDev,
+		netDevName:   netDevName,
+		limits:       limits,
+		unblockCheck: unblockCheck,
+		backoff:      newBlockBackoff(backoff),
+		ebpfProg:     ebpfProg,
+		manual:       make(map[int]manualOverride),
+		history:      newRateHistory(RateHistorySize),
+		sched:        schedState{blocks: make(map[int]*blockState)},
+		vlans:        make(map[uint16]*netDevWatcher),
+		log:          logger.GetLogger().With(slog.String(logger.Component, "NetDevWatcher"), slog.String(logger.Interface, netDevName)),
 	}
 }
 
+func (n *netDevWatcher) getLimits() trafficLimits {
+	n.settingsMux.RLock()
+	defer n.settingsMux.RUnlock()
+
+	return n.limits
+}
+
+func (n *netDevWatcher) getUnblockCheck() unblockCheck {
+	n.settingsMux.RLock()
+	defer n.settingsMux.RUnlock()
+
+	return n.unblockCheck
+}
+
+func (n *netDevWatcher) getPolicy() netDevPolicy {
+	n.settingsMux.RLock()
+	defer n.settingsMux.RUnlock()
+
+	return n.policy
+}
+
+// updateSettings applies new limits to running watcher, already blocked traffic
+// is unblocked according to new settings
+func (n *netDevWatcher) updateSettings(policy netDevPolicy, unblockCheck unblockCheck, backoff backoffConfig) error {
+	n.settingsMux.Lock()
+	n.policy = policy
+	n.limits = policy.limits
+	n.unblockCheck = unblockCheck
+	n.settingsMux.Unlock()
+	n.backoff.setConfig(backoff)
+	var err error
+	if policy.blockEnabled && policy.blockMode == rateLimitMode {
+		err = n.applyRateLimits()
+	}
+	for _, vlanWatcher := range n.vlanWatchers() {
+		err = errors.Join(err, vlanWatcher.updateSettings(policy, unblockCheck, backoff))
+	}
+
+	return err
+}
+
+// stop prevents drop map changes by scheduler, interface can be detached after stop
 func (n *netDevWatcher) stop() {
-	close(n.stopChan)
+	n.dropMapMux.Lock()
+	n.stopped = true
+	n.dropMapMux.Unlock()
+	for _, vlanWatcher := range n.vlanWatchers() {
+		vlanWatcher.stop()
+	}
 }
 
 func (n *netDevWatcher) index() int {
@@ -98,246 +392,310 @@ func (n *netDevWatcher) index() int {
 }
 
 func (n *netDevWatcher) devInfo() string {
-	return fmt.Sprintf("%s (%d)", n.netDevName, n.netDevIndex)
-}
+	if n.vlan != nil {
+		return fmt.Sprintf("%s (%d) vlan %d", n.netDevName, n.netDevIndex, *n.vlan)
+	}
 
-func (n *netDevWatcher) getStats() (ebpfloader.PacketCounter, error) {
-	return n.ebpfProg.GetDevStat(n.index())
+	return fmt.Sprintf("%s (%d)", n.netDevName, n.netDevIndex)
 }
 
-func (n *netDevWatcher) startUnblockWatcher(update updateDropConfig) {
-	if update.br != 0 {
-		go n.watchUnblock(broadcastType)
-	}
-	if update.ipv4 != 0 {
-		go n.watchUnblock(ipv4McastType)
+func (n *netDevWatcher) state() NetDevState {
+	policy := n.getPolicy()
+	result := NetDevState{
+		Index:         n.netDevIndex,
+		Name:          n.netDevName,
+		Policy:        policy.name,
+		BlockMode:     policy.blockMode,
+		DryRun:        policy.dryRun,
+		BackoffLevels: make(map[string]int, len(trafficTypeNames)),
+		Overrides:     n.overrideStates(),
+		TopTalkers:    n.getTopTalkers(),
 	}
-	if update.ipv6 != 0 {
-		go n.watchUnblock(ipv6McastType)
+	for name, trafType := range trafficTypeNames {
+		result.BackoffLevels[name] = n.backoff.level(trafType)
 	}
-	if update.other != 0 {
-		go n.watchUnblock(otherType)
+
+	return result
+}
+
+// publish sends event of traffic type with interface name and index
+func (n *netDevWatcher) publish(trafType int, event events.Event) {
+	if n.events == nil {
+		return
 	}
+	event.Interface = n.netDevName
+	event.Index = n.netDevIndex
+	event.TrafficType = trafficTypeName(trafType)
+	event.VLAN = n.vlan
+	event.DryRun = n.getPolicy().dryRun
+	n.events.Publish(event)
 }
 
-func (n *netDevWatcher) acquireBlockState(trafType int) bool {
-	switch trafType {
-	case broadcastType:
-		if n.dropState.brDropped.CompareAndSwap(false, true) {
-			return true
-		}
+// resumeBlocks starts unblock process for traffic blocked by previous instance of program
+func (n *netDevWatcher) resumeBlocks(now time.Time) {
+	dropCfg, err := n.getDropCfg()
+	if err != nil {
+		n.log.Errorf("Error get drop config for interface %s: %s", n.devInfo(), err.Error())
 
-	case ipv4McastType:
-		if n.dropState.ipv4McastDropped.CompareAndSwap(false, true) {
-			return true
+		return
+	}
+	resumed := updateDropConfig{}
+	for _, trafType := range trafficTypes {
+		if *trafficFields(trafType).Action(&dropCfg) == ebpfloader.ActionDrop {
+			resumed.set(trafType, blockAction)
 		}
+	}
+	if resumed.isEmpty() {
+		return
+	}
+	if n.getPolicy().dryRun {
+		n.log.Warningf("Traffic of interface %s is blocked by previous instance and is not unblocked in dry run", n.devInfo())
 
-	case ipv6McastType:
-		if n.dropState.ipv6McastDropped.CompareAndSwap(false, true) {
-			return true
-		}
-	case otherType:
-		if n.dropState.other.CompareAndSwap(false, true) {
-			return true
+		return
+	}
+	n.log.Infof("Resume blocked traffic state for interface %s", n.devInfo())
+	for _, trafType := range trafficTypes {
+		if resumed.get(trafType) == blockAction {
+			n.startBlock(trafType, nil, now)
 		}
 	}
+}
 
-	return false
+func makeUnblockConfig(trafType int) updateDropConfig {
+	result := updateDropConfig{}
+	result.set(trafType, unblockAction)
+
+	return result
 }
 
-func (n *netDevWatcher) releaseBlockState(trafType int) {
-	switch trafType {
-	case broadcastType:
-		n.dropState.brDropped.Store(false)
+// isQuiet checks that per second rate of dropped packets and bytes during window is below unblock thresholds
+func (n *netDevWatcher) isQuiet(prevStats, curStats *ebpfloader.PacketCounter, trafType int, window time.Duration) bool {
+	if window <= 0 {
+		return false
+	}
+	limits := n.getLimits()
+	limit := limits.get(trafType)
+	counters := trafficFields(trafType).Counters
+	delta := counters(curStats).Sub(*counters(prevStats))
+	// traffic is not dropped in dry run, passed traffic would be dropped
+	if n.getPolicy().dryRun {
+		delta.Dropped, delta.DroppedBytes = delta.Passed, delta.PassedBytes
+	}
 
-	case ipv4McastType:
-		n.dropState.ipv4McastDropped.Store(false)
+	return limit.quiet(delta, window)
+}
 
-	case ipv6McastType:
-		n.dropState.ipv6McastDropped.Store(false)
-	case otherType:
-		n.dropState.other.Store(false)
+// startBlock starts unblock process of blocked traffic after block delay,
+// observed is traffic per second which caused block, nil for resumed blocks.
+// Block event is sent for new block with observed traffic, resumed block is not reported again.
+func (n *netDevWatcher) startBlock(trafType int, observed *ebpfloader.TrafInfo, now time.Time) {
+	limits := n.getLimits()
+	blockDelay := n.backoff.blockDelay(trafType, limits.get(trafType).dropDelay)
+	n.log.Debugf("Block %s traffic dev: %s for %s", trafficTypeName(trafType), n.devInfo(), blockDelay)
+	block := &blockState{checkAt: now.Add(blockDelay)}
+	n.sched.blocks[trafType] = block
+	if observed == nil {
+		return
 	}
+	block.blockedAt = now
+	limit := limits.get(trafType)
+	n.publish(trafType, events.Event{
+		Action:         events.ActionBlock,
+		Source:         events.SourceAuto,
+		Rate:           observed.Passed,
+		RateBytes:      observed.PassedBytes,
+		Threshold:      limit.blockThreshold,
+		ThresholdBytes: limit.blockThresholdBytes,
+		TopTalkers:     n.getTopTalkers(),
+		Duration:       blockDelay,
+	})
 }
 
-func (n *netDevWatcher) checkAndUnblock(prevStats, curState *ebpfloader.PacketCounter, trafType int) (bool, error) { //nolint
-	switch trafType {
-	case broadcastType:
-		if (curState.Broadcast.Dropped - prevStats.Broadcast.Dropped) < n.unblockThreshold {
-			if err := n.updateDropMap(updateDropConfig{br: unblockAction}); err != nil {
-				return false, err
-			}
-			n.log.Debugf("Unblock broadcast traffic dev: %s", n.devInfo())
+// finishBlock completes unblock process after traffic is unblocked in drop map
+func (n *netDevWatcher) finishBlock(trafType int, now time.Time) {
+	block, ok := n.sched.blocks[trafType]
+	if !ok {
+		return
+	}
+	delete(n.sched.blocks, trafType)
+	n.backoff.unblocked(trafType)
+	n.log.Debugf("Unblock %s traffic dev: %s", trafficTypeName(trafType), n.devInfo())
+	event := events.Event{Action: events.ActionUnblock, Source: events.SourceAuto}
+	if !block.blockedAt.IsZero() {
+		event.Duration = now.Sub(block.blockedAt)
+	}
+	n.publish(trafType, event)
+}
 
-			return true, nil
+// checkUnblock calculates dropped traffic of blocked types every recheck interval after block delay
+// and returns unblock actions for traffic after configured number of consecutive quiet intervals
+func (n *netDevWatcher) checkUnblock(stats *ebpfloader.PacketCounter, now time.Time) updateDropConfig {
+	result := updateDropConfig{}
+	check := n.getUnblockCheck()
+	for _, trafType := range trafficTypes {
+		block, ok := n.sched.blocks[trafType]
+		if !ok {
+			continue
 		}
+		// manually controlled traffic is not unblocked automatically
+		if n.overridden(trafType) {
+			delete(n.sched.blocks, trafType)
 
-	case ipv4McastType:
-		if (curState.IPv4MCast.Dropped - prevStats.IPv4MCast.Dropped) < n.unblockThreshold {
-			if err := n.updateDropMap(updateDropConfig{ipv4: unblockAction}); err != nil {
-				return false, err
-			}
-			n.log.Debugf("Unblock IPv4 Multicast traffic dev: %s", n.devInfo())
+			continue
+		}
+		if now.Before(block.checkAt) {
+			continue
+		}
+		block.checkAt = now.Add(check.interval)
+		if block.windowStart.IsZero() {
+			block.windowStats, block.windowStart = *stats, now
 
-			return true, nil
+			continue
+		}
+		if n.isQuiet(&block.windowStats, stats, trafType, now.Sub(block.windowStart)) {
+			block.quietWindows++
+		} else {
+			block.quietWindows = 0
+			n.publish(trafType, events.Event{Action: events.ActionRecheckFailed, Source: events.SourceAuto})
 		}
+		block.windowStats, block.windowStart = *stats, now
+		if block.quietWindows >= check.quietWindows {
+			result.set(trafType, unblockAction)
+		}
+	}
 
-	case ipv6McastType:
-		if (curState.IPv6MCast.Dropped - prevStats.IPv6MCast.Dropped) < n.unblockThreshold {
-			if err := n.updateDropMap(updateDropConfig{ipv6: unblockAction}); err != nil {
-				return false, err
-			}
-			n.log.Debugf("Unblock IPv6 Multicast traffic dev: %s", n.devInfo())
+	return result
+}
 
-			return true, nil
+// autoBlock checks that block decisions are made by watcher, in rate limit mode kernel program drops traffic by itself.
+// In dry run decisions are always made, but not applied. Decisions of VLAN aware interface are made by VLAN.
+func (n *netDevWatcher) autoBlock() bool {
+	if n.vlanAware() {
+		return false
+	}
+	policy := n.getPolicy()
+
+	return policy.dryRun || (policy.blockEnabled && policy.blockMode != rateLimitMode)
+}
+
+// evaluate makes block and unblock decisions by counters read at now,
+// returned changes are written to drop map by scheduler and confirmed by commit
+func (n *netDevWatcher) evaluate(stats ebpfloader.PacketCounter, now time.Time) updateDropConfig {
+	n.expireOverrides(now)
+	// counters of resumed program are not zero
+	if !n.sched.started {
+		n.sched.started = true
+		n.sched.prevStats = stats
+		if n.autoBlock() {
+			n.resumeBlocks(now)
 		}
-	case otherType:
-		if (curState.OtherMcast.Dropped - prevStats.OtherMcast.Dropped) < n.unblockThreshold {
-			if err := n.updateDropMap(updateDropConfig{other: unblockAction}); err != nil {
-				return false, err
-			}
-			n.log.Debugf("Unblock other Multicast traffic dev: %s", n.devInfo())
 
-			return true, nil
+		return updateDropConfig{}
+	}
+	// counters are read every second, difference is traffic per second
+	n.sched.observed = stats.Sub(n.sched.prevStats)
+	n.history.add(n.sched.observed)
+	result := updateDropConfig{}
+	if n.autoBlock() {
+		result = n.calculateBlocks(&n.sched.prevStats, &stats)
+		unblock := n.checkUnblock(&stats, now)
+		for _, trafType := range trafficTypes {
+			if action := unblock.get(trafType); action != 0 {
+				result.set(trafType, action)
+			}
 		}
 	}
+	n.sched.prevStats = stats
 
-	return false, nil
+	return result
 }
 
-// async function for drop packet calculation for specific type of traffic
-// calculates statistic every 3 seconds and make unblock decisions
-func (n *netDevWatcher) watchUnblock(trafType int) {
-	if !n.acquireBlockState(trafType) {
-		return
-	}
-	defer n.releaseBlockState(trafType)
+// commit confirms decisions of evaluate after drop map update,
+// decisions are discarded on update error and made again by next evaluation
+func (n *netDevWatcher) commit(update updateDropConfig, now time.Time, err error) {
+	if err != nil {
+		n.log.Errorf("Error update drop config on interface %s: caused %s", n.devInfo(), err.Error())
 
-	timer := time.NewTimer(n.dropDelay)
-	defer timer.Stop()
-	select {
-	case <-n.stopChan:
 		return
-	case <-timer.C:
 	}
-
-	prevStats, err := n.getStats()
-	if err != nil {
-		n.log.Errorf("Error get statistics for interface %s: %s", n.devInfo(), err.Error())
-	}
-	ticker := time.NewTicker(time.Second * 3)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-n.stopChan:
-			n.log.Debugf("Stop watching command received for interface %s, stop watching.", n.devInfo())
-
-			return
-		case <-ticker.C:
-			stats, err := n.getStats()
-			if err != nil {
-				n.log.Errorf("Error get statistics for interface %s: %s", n.devInfo(), err.Error())
-
-				continue
-			}
-			ok, err := n.checkAndUnblock(&prevStats, &stats, trafType)
-			if err != nil {
-				n.log.Errorf("Error check unblock status for interface %s", n.devInfo())
-			}
-			if ok {
-				return
-			}
-			prevStats = stats
+	for _, trafType := range trafficTypes {
+		switch update.get(trafType) {
+		case blockAction:
+			observed := *trafficFields(trafType).Counters(&n.sched.observed)
+			n.startBlock(trafType, &observed, now)
+		case unblockAction:
+			n.finishBlock(trafType, now)
 		}
 	}
 }
 
+// updateDropMap changes drop config of interface, drop config is never changed in dry run
 func (n *netDevWatcher) updateDropMap(update updateDropConfig) error {
-	if update.isEmpty() {
+	if update.isEmpty() || n.getPolicy().dryRun {
 		return nil
 	}
 	n.dropMapMux.Lock()
 	defer n.dropMapMux.Unlock()
-	result, err := n.ebpfProg.GetDevDropCfg(n.netDevIndex)
+	if n.stopped {
+		return ErrNetDevNotFound
+	}
+	result, err := n.getDropCfg()
 	if err != nil {
 		return err
 	}
+	update.apply(&result)
 
-	if update.br != 0 {
-		result.Broadcast = getEBPFAction(update.br)
+	return n.setDropCfg(result)
+}
+
+// applyRateLimits configures kernel token bucket rate limits for all not exempt and not overridden types of traffic
+func (n *netDevWatcher) applyRateLimits() error {
+	if n.getPolicy().dryRun {
+		return nil
 	}
-	if update.ipv4 != 0 {
-		result.IPv4MCast = getEBPFAction(update.ipv4)
+	n.dropMapMux.Lock()
+	defer n.dropMapMux.Unlock()
+	if n.stopped {
+		return ErrNetDevNotFound
 	}
-	if update.ipv6 != 0 {
-		result.IPv6MCast = getEBPFAction(update.ipv6)
+	result, err := n.getDropCfg()
+	if err != nil {
+		return err
 	}
-	if update.other != 0 {
-		result.Multicast = getEBPFAction(update.other)
+	limits := n.getLimits()
+	for _, trafType := range trafficTypes {
+		fields := trafficFields(trafType)
+		*fields.Action(&result), *fields.Rate(&result) = n.rateLimit(trafType, limits.get(trafType))
 	}
 
-	return n.ebpfProg.UpdateDevDropCfg(n.netDevIndex, result)
+	return n.setDropCfg(result)
 }
 
-func (n *netDevWatcher) getCalculateStatsFuc() func(statStruct ebpfloader.PacketCounter) updateDropConfig {
-	var stats ebpfloader.PacketCounter
-
-	return func(curStats ebpfloader.PacketCounter) updateDropConfig {
-		blockStruct := updateDropConfig{}
-		if (curStats.Broadcast.Passed - stats.Broadcast.Passed) > n.blockThreshold {
-			n.log.Debugf("Block broadcast traffic %s", n.devInfo())
-			blockStruct.br = blockAction
-		}
-		if (curStats.IPv4MCast.Passed - stats.IPv4MCast.Passed) > n.blockThreshold {
-			n.log.Debugf("Block IPv4 multicast traffic %s", n.devInfo())
-			blockStruct.ipv4 = blockAction
+// skipOverridden removes actions of manually controlled traffic from update
+func (n *netDevWatcher) skipOverridden(update *updateDropConfig) {
+	for _, trafType := range trafficTypes {
+		if update.get(trafType) != 0 && n.overridden(trafType) {
+			update.set(trafType, 0)
 		}
-		if (curStats.IPv6MCast.Passed - stats.IPv6MCast.Passed) > n.blockThreshold {
-			n.log.Debugf("Block IPv6 multicast traffic %s", n.devInfo())
-			blockStruct.ipv6 = blockAction
-		}
-
-		if (curStats.OtherMcast.Passed - stats.OtherMcast.Passed) > n.blockThreshold {
-			n.log.Debugf("Block other multicast traffic %s", n.devInfo())
-			blockStruct.other = blockAction
-		}
-		stats = curStats
-
-		return blockStruct
 	}
 }
 
-// async function started in separate goroutine
-// calculates statistic every second and make block decisions
-// only one instance of this function must be launched for  specific interface
-func (n *netDevWatcher) startWatching() {
-	calculateState := n.getCalculateStatsFuc()
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-n.stopChan:
-			n.log.Debugf("Stop watching command received for interface %s, stop watching.", n.devInfo())
-
-			return
-
-		case <-ticker.C:
-			stats, err := n.getStats()
-			if err != nil {
-				n.log.Errorf("Error get statistic for interface %s %s", n.devInfo(), err.Error())
-
-				continue
-			}
-			dropConf := calculateState(stats)
-			if !dropConf.isEmpty() {
-				if err := n.updateDropMap(dropConf); err != nil {
-					n.log.Errorf("Error block traffic on interface %s: caused %s", n.devInfo(), err.Error())
-
-					continue
-				}
-				n.startUnblockWatcher(dropConf)
-			}
+// calculateBlocks returns block actions for traffic which exceeds limits,
+// blocked and manually controlled traffic is skipped
+func (n *netDevWatcher) calculateBlocks(prevStats, curStats *ebpfloader.PacketCounter) updateDropConfig {
+	result := updateDropConfig{}
+	limits := n.getLimits()
+	for _, trafType := range trafficTypes {
+		if _, ok := n.sched.blocks[trafType]; ok || n.overridden(trafType) {
+			continue
+		}
+		limit := limits.get(trafType)
+		counters := trafficFields(trafType).Counters
+		if limit.exceeded(counters(curStats).Sub(*counters(prevStats))) {
+			n.log.Debugf("Block %s traffic %s", trafficTypeName(trafType), n.devInfo())
+			result.set(trafType, blockAction)
 		}
 	}
+
+	return result
 }
@@ -3,87 +3,84 @@ package watcher
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
 	"github.com/mythvcode/storm-control/internal/watcher/mocks"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+var testUnblockCheck = unblockCheck{interval: 3 * time.Second, quietWindows: 1}
+
 func createWatcher(t *testing.T) *netDevWatcher {
 	t.Helper()
 
-	return newNetDevWatcher(1, "test_name", 10, 0, mocks.NewMockeBPFProg(t))
+	return newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, 0), testUnblockCheck, backoffConfig{}, mocks.NewMockeBPFProg(t))
 }
 
-func TestAcquireBlockState(t *testing.T) {
+func TestUpdateDropConfigSet(t *testing.T) {
 	tCases := []struct {
 		trafType int
-		statsus  bool
+		expected updateDropConfig
 	}{
 		{
 			trafType: 0,
-			statsus:  false,
+			expected: updateDropConfig{},
 		},
 		{
 			trafType: broadcastType,
-			statsus:  true,
+			expected: updateDropConfig{br: blockAction},
 		},
 		{
 			trafType: ipv4McastType,
-			statsus:  true,
+			expected: updateDropConfig{ipv4: blockAction},
 		},
 		{
 			trafType: ipv6McastType,
-			statsus:  true,
+			expected: updateDropConfig{ipv6: blockAction},
 		},
 		{
 			trafType: otherType,
-			statsus:  true,
+			expected: updateDropConfig{other: blockAction},
 		},
 		{
 			trafType: 100,
-			statsus:  false,
+			expected: updateDropConfig{},
 		},
 	}
 
 	for _, tCase := range tCases {
-		watcher := createWatcher(t)
-		if tCase.statsus == true {
-			require.True(t, watcher.acquireBlockState(tCase.trafType))
-		} else {
-			require.False(t, watcher.acquireBlockState(tCase.trafType))
+		update := updateDropConfig{}
+		update.set(tCase.trafType, blockAction)
+		require.Equal(t, tCase.expected, update)
+		if !tCase.expected.isEmpty() {
+			require.Equal(t, uint8(blockAction), update.get(tCase.trafType))
 		}
 	}
 }
 
-func TestAcquireBlockStateSecondTime(t *testing.T) {
+func TestStartFinishBlock(t *testing.T) {
 	watcher := createWatcher(t)
-	require.True(t, watcher.acquireBlockState(broadcastType))
-	require.False(t, watcher.acquireBlockState(broadcastType))
-}
+	now := time.Now()
+	watcher.startBlock(broadcastType, nil, now)
+	watcher.startBlock(otherType, nil, now)
+	require.Len(t, watcher.sched.blocks, 2)
+	require.Equal(t, now, watcher.sched.blocks[broadcastType].checkAt)
+	// resumed block has unknown block time
+	require.True(t, watcher.sched.blocks[broadcastType].blockedAt.IsZero())
 
-func TestReleaseBlockState(t *testing.T) {
-	watcher := createWatcher(t)
-	require.True(t, watcher.acquireBlockState(broadcastType))
-	require.True(t, watcher.acquireBlockState(ipv4McastType))
-	require.True(t, watcher.acquireBlockState(ipv6McastType))
-	require.True(t, watcher.acquireBlockState(otherType))
-	require.True(t, watcher.dropState.brDropped.Load())
-	require.True(t, watcher.dropState.ipv4McastDropped.Load())
-	require.True(t, watcher.dropState.ipv6McastDropped.Load())
-	require.True(t, watcher.dropState.other.Load())
-
-	require.False(t, watcher.acquireBlockState(broadcastType))
-	require.False(t, watcher.acquireBlockState(ipv4McastType))
-	require.False(t, watcher.acquireBlockState(ipv6McastType))
-	require.False(t, watcher.acquireBlockState(otherType))
+	watcher.finishBlock(broadcastType, now)
+	watcher.finishBlock(ipv4McastType, now)
+	require.NotContains(t, watcher.sched.blocks, broadcastType)
+	require.Contains(t, watcher.sched.blocks, otherType)
 }
 
 func TestDevInfo(t *testing.T) {
 	ebpfProg := mocks.NewMockeBPFProg(t)
 
-	watchr := newNetDevWatcher(1, "test_name", 10, 0, ebpfProg)
+	watchr := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, 0), testUnblockCheck, backoffConfig{}, ebpfProg)
 	res := watchr.devInfo()
 	require.Equal(t, "test_name (1)", res)
 }
@@ -94,7 +91,7 @@ func TestUpdateDropConf(t *testing.T) {
 	ebpfProg.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{Broadcast: 1}).Return(nil)
 	ebpfProg.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{IPv4MCast: 1}).Return(nil)
 	ebpfProg.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{IPv6MCast: 1, Multicast: 1}).Return(nil)
-	watchr := newNetDevWatcher(1, "test_name", 10, 0, ebpfProg)
+	watchr := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, 0), testUnblockCheck, backoffConfig{}, ebpfProg)
 	require.NoError(t, watchr.updateDropMap(updateDropConfig{br: blockAction}))
 	require.NoError(t, watchr.updateDropMap(updateDropConfig{ipv4: blockAction}))
 	require.NoError(t, watchr.updateDropMap(updateDropConfig{ipv6: blockAction, other: blockAction}))
@@ -107,101 +104,293 @@ func TestUpdateDropConf(t *testing.T) {
 
 func TestCalculateStats(t *testing.T) {
 	watcher := createWatcher(t)
-	calcFunc := watcher.getCalculateStatsFuc()
-	blockConf := calcFunc(ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: 100}})
-	require.Equal(t, updateDropConfig{br: blockAction}, blockConf)
-	blockConf = calcFunc(ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: 200}, IPv4MCast: ebpfloader.TrafInfo{Passed: 100}})
-	require.Equal(t, updateDropConfig{br: blockAction, ipv4: blockAction}, blockConf)
-	blockConf = calcFunc(ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: 200}, IPv4MCast: ebpfloader.TrafInfo{Passed: 100}})
-	require.Equal(t, updateDropConfig{}, blockConf)
+	prev := ebpfloader.PacketCounter{}
+	cur := ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: 100}}
+	require.Equal(t, updateDropConfig{br: blockAction}, watcher.calculateBlocks(&prev, &cur))
+	prev, cur = cur, ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: 200}, IPv4MCast: ebpfloader.TrafInfo{Passed: 100}}
+	require.Equal(t, updateDropConfig{br: blockAction, ipv4: blockAction}, watcher.calculateBlocks(&prev, &cur))
+	prev = cur
+	require.Equal(t, updateDropConfig{}, watcher.calculateBlocks(&prev, &cur))
+	// blocked traffic is not blocked again
+	watcher.startBlock(broadcastType, nil, time.Now())
+	prev = ebpfloader.PacketCounter{}
+	require.Equal(t, updateDropConfig{ipv4: blockAction}, watcher.calculateBlocks(&prev, &cur))
+}
+
+func TestIsQuiet(t *testing.T) {
+	watcher := createWatcher(t)
+	tCases := []struct {
+		prev    ebpfloader.PacketCounter
+		cur     ebpfloader.PacketCounter
+		typ     int
+		window  time.Duration
+		isQuiet bool
+	}{
+		{
+			prev:    ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 100}},
+			cur:     ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 129}},
+			typ:     broadcastType,
+			window:  3 * time.Second,
+			isQuiet: true,
+		},
+		{
+			prev:    ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 100}},
+			cur:     ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 130}},
+			typ:     broadcastType,
+			window:  3 * time.Second,
+			isQuiet: false,
+		},
+		// rate is normalised to per second value
+		{
+			prev:    ebpfloader.PacketCounter{IPv4MCast: ebpfloader.TrafInfo{Dropped: 100}},
+			cur:     ebpfloader.PacketCounter{IPv4MCast: ebpfloader.TrafInfo{Dropped: 190}},
+			typ:     ipv4McastType,
+			window:  10 * time.Second,
+			isQuiet: true,
+		},
+		{
+			prev:    ebpfloader.PacketCounter{IPv6MCast: ebpfloader.TrafInfo{Dropped: 100}},
+			cur:     ebpfloader.PacketCounter{IPv6MCast: ebpfloader.TrafInfo{Dropped: 111}},
+			typ:     ipv6McastType,
+			window:  time.Second,
+			isQuiet: false,
+		},
+		{
+			prev:    ebpfloader.PacketCounter{OtherMcast: ebpfloader.TrafInfo{Dropped: 100}},
+			cur:     ebpfloader.PacketCounter{OtherMcast: ebpfloader.TrafInfo{Dropped: 100}},
+			typ:     otherType,
+			window:  time.Second,
+			isQuiet: true,
+		},
+		{
+			prev:    ebpfloader.PacketCounter{},
+			cur:     ebpfloader.PacketCounter{},
+			typ:     otherType,
+			window:  0,
+			isQuiet: false,
+		},
+	}
+	for _, tCase := range tCases {
+		require.Equal(t, tCase.isQuiet, watcher.isQuiet(&tCase.prev, &tCase.cur, tCase.typ, tCase.window))
+	}
 }
 
-func TestCheckUnblockError(t *testing.T) {
+func TestUnblockError(t *testing.T) {
 	ebpfProg := mocks.NewMockeBPFProg(t)
-	watcher := newNetDevWatcher(1, "test_name", 10, 0, ebpfProg)
-	unblock, err := watcher.checkAndUnblock(
-		&ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 100}},
-		&ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 200}},
-		broadcastType,
-	)
-	require.NoError(t, err)
-	require.False(t, unblock)
-	ebpfProg.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil)
+	watcher := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, 0), testUnblockCheck, backoffConfig{}, ebpfProg)
+	ebpfProg.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{Broadcast: 1}, nil)
 	ebpfProg.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{Broadcast: 0}).Return(errors.New("error map drop config"))
-	unblock, err = watcher.checkAndUnblock(
-		&ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 100}},
-		&ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 101}},
-		broadcastType,
-	)
-	require.False(t, unblock)
+	err := watcher.updateDropMap(makeUnblockConfig(broadcastType))
 	require.Error(t, err)
 	require.Equal(t, "error map drop config", err.Error())
 }
 
-func TestCheckUnblock(t *testing.T) {
+func TestUnblock(t *testing.T) {
 	tCases := []struct {
-		initMock     func(dropMock *mocks.MockeBPFProg)
-		unblockCheck func(watcher *netDevWatcher) (bool, error)
+		trafType int
+		expected ebpfloader.DropPKT
 	}{
 		{
-			initMock: func(eBPFProg *mocks.MockeBPFProg) {
-				eBPFProg.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil)
-				eBPFProg.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{Broadcast: 0}).Return(nil)
-			},
-			unblockCheck: func(watcher *netDevWatcher) (bool, error) {
-				return watcher.checkAndUnblock(
-					&ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 100}},
-					&ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Dropped: 101}},
-					broadcastType,
-				)
-			},
-		},
-		{
-			initMock: func(eBPFProg *mocks.MockeBPFProg) {
-				eBPFProg.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil)
-				eBPFProg.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{IPv4MCast: 0}).Return(nil)
-			},
-			unblockCheck: func(watcher *netDevWatcher) (bool, error) {
-				return watcher.checkAndUnblock(
-					&ebpfloader.PacketCounter{IPv4MCast: ebpfloader.TrafInfo{Dropped: 100}},
-					&ebpfloader.PacketCounter{IPv4MCast: ebpfloader.TrafInfo{Dropped: 101}},
-					ipv4McastType,
-				)
-			},
-		},
-		{
-			initMock: func(eBPFProg *mocks.MockeBPFProg) {
-				eBPFProg.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil)
-				eBPFProg.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{IPv6MCast: 0}).Return(nil)
-			},
-			unblockCheck: func(watcher *netDevWatcher) (bool, error) {
-				return watcher.checkAndUnblock(
-					&ebpfloader.PacketCounter{IPv6MCast: ebpfloader.TrafInfo{Dropped: 100}},
-					&ebpfloader.PacketCounter{IPv6MCast: ebpfloader.TrafInfo{Dropped: 101}},
-					ipv6McastType,
-				)
-			},
-		},
-		{
-			initMock: func(eBPFProg *mocks.MockeBPFProg) {
-				eBPFProg.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil)
-				eBPFProg.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{Multicast: 0}).Return(nil)
-			},
-			unblockCheck: func(watcher *netDevWatcher) (bool, error) {
-				return watcher.checkAndUnblock(
-					&ebpfloader.PacketCounter{OtherMcast: ebpfloader.TrafInfo{Dropped: 100}},
-					&ebpfloader.PacketCounter{OtherMcast: ebpfloader.TrafInfo{Dropped: 101}},
-					otherType,
-				)
-			},
+			trafType: broadcastType,
+			expected: ebpfloader.DropPKT{IPv4MCast: 1, IPv6MCast: 1, Multicast: 1},
+		},
+		{
+			trafType: ipv4McastType,
+			expected: ebpfloader.DropPKT{Broadcast: 1, IPv6MCast: 1, Multicast: 1},
+		},
+		{
+			trafType: ipv6McastType,
+			expected: ebpfloader.DropPKT{Broadcast: 1, IPv4MCast: 1, Multicast: 1},
+		},
+		{
+			trafType: otherType,
+			expected: ebpfloader.DropPKT{Broadcast: 1, IPv4MCast: 1, IPv6MCast: 1},
 		},
 	}
 	for _, tCase := range tCases {
 		ebpfProg := mocks.NewMockeBPFProg(t)
-		watcher := newNetDevWatcher(1, "test_name", 10, 0, ebpfProg)
-		tCase.initMock(ebpfProg)
-		unblocked, err := tCase.unblockCheck(watcher)
-		require.NoError(t, err)
-		require.True(t, unblocked)
+		watcher := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, 0), testUnblockCheck, backoffConfig{}, ebpfProg)
+		ebpfProg.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{Broadcast: 1, IPv4MCast: 1, IPv6MCast: 1, Multicast: 1}, nil)
+		ebpfProg.EXPECT().UpdateDevDropCfg(1, tCase.expected).Return(nil)
+		require.NoError(t, watcher.updateDropMap(makeUnblockConfig(tCase.trafType)))
+	}
+}
+
+func TestEvaluateQuietWindows(t *testing.T) {
+	ebpfProg := mocks.NewMockeBPFProg(t)
+	watcher := newNetDevWatcher(
+		1,
+		"test_name",
+		newUniformTrafficLimits(10, 0),
+		unblockCheck{interval: 3 * time.Second, quietWindows: 3},
+		backoffConfig{},
+		ebpfProg,
+	)
+	watcher.policy = netDevPolicy{blockEnabled: true, blockMode: dropMode}
+	eventsMock := mocks.NewMockeventPublisher(t)
+	watcher.events = eventsMock
+	ebpfProg.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	start := time.Now()
+	require.Equal(t, updateDropConfig{}, watcher.evaluate(ebpfloader.PacketCounter{}, start))
+
+	stats := ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: 50, PassedBytes: 3200}}
+	update := watcher.evaluate(stats, start.Add(time.Second))
+	require.Equal(t, updateDropConfig{br: blockAction}, update)
+	eventsMock.EXPECT().Publish(events.Event{
+		Action:      events.ActionBlock,
+		Source:      events.SourceAuto,
+		Interface:   "test_name",
+		Index:       1,
+		TrafficType: "broadcast",
+		Rate:        50,
+		RateBytes:   3200,
+		Threshold:   10,
+	}).Once()
+	watcher.commit(update, start.Add(time.Second), nil)
+
+	// first check starts window, first window is not quiet, unblock requires three quiet windows after it
+	require.Equal(t, updateDropConfig{}, watcher.evaluate(stats, start.Add(2*time.Second)))
+	require.Equal(t, updateDropConfig{}, watcher.evaluate(stats, start.Add(4*time.Second)))
+	eventsMock.EXPECT().Publish(events.Event{
+		Action:      events.ActionRecheckFailed,
+		Source:      events.SourceAuto,
+		Interface:   "test_name",
+		Index:       1,
+		TrafficType: "broadcast",
+	}).Once()
+	stats.Broadcast.Dropped = 1000
+	require.Equal(t, updateDropConfig{}, watcher.evaluate(stats, start.Add(5*time.Second)))
+	require.Equal(t, updateDropConfig{}, watcher.evaluate(stats, start.Add(8*time.Second)))
+	require.Equal(t, updateDropConfig{}, watcher.evaluate(stats, start.Add(11*time.Second)))
+	update = watcher.evaluate(stats, start.Add(14*time.Second))
+	require.Equal(t, updateDropConfig{br: unblockAction}, update)
+
+	// failed update is not confirmed
+	watcher.commit(update, start.Add(14*time.Second), errors.New("error map drop config"))
+	require.Contains(t, watcher.sched.blocks, broadcastType)
+	eventsMock.EXPECT().Publish(events.Event{
+		Action:      events.ActionUnblock,
+		Source:      events.SourceAuto,
+		Interface:   "test_name",
+		Index:       1,
+		TrafficType: "broadcast",
+		Duration:    13 * time.Second,
+	}).Once()
+	watcher.commit(update, start.Add(14*time.Second), nil)
+	require.Empty(t, watcher.sched.blocks)
+	require.Len(t, watcher.history.last(RateHistorySize), 7)
+}
+
+func TestCalculateStatsPerTypeThreshold(t *testing.T) {
+	limits := newUniformTrafficLimits(10, 0)
+	limits.ipv6Mcast = newTrafficLimit(1000, 1000, 0)
+	watcher := newNetDevWatcher(1, "test_name", limits, testUnblockCheck, backoffConfig{}, mocks.NewMockeBPFProg(t))
+	prev := ebpfloader.PacketCounter{}
+	cur := ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 11},
+		IPv6MCast: ebpfloader.TrafInfo{Passed: 500},
+	}
+	blockConf := watcher.calculateBlocks(&prev, &cur)
+	require.Equal(t, updateDropConfig{br: blockAction}, blockConf)
+	prev, cur = cur, ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 12},
+		IPv6MCast: ebpfloader.TrafInfo{Passed: 1501},
 	}
+	blockConf = watcher.calculateBlocks(&prev, &cur)
+	require.Equal(t, updateDropConfig{ipv6: blockAction}, blockConf)
+}
+
+func TestCalculateStatsSubClasses(t *testing.T) {
+	limits := newUniformTrafficLimits(10, 0)
+	limits.arp = newTrafficLimit(1000, 1000, 0)
+	watcher := newNetDevWatcher(1, "test_name", limits, testUnblockCheck, backoffConfig{}, mocks.NewMockeBPFProg(t))
+	// DHCP flood is blocked without blocking of ARP and other broadcast
+	blockConf := watcher.calculateBlocks(&ebpfloader.PacketCounter{}, &ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 5},
+		ARP:       ebpfloader.TrafInfo{Passed: 500},
+		DHCPv4:    ebpfloader.TrafInfo{Passed: 100},
+		MLD:       ebpfloader.TrafInfo{Passed: 11},
+	})
+	require.Equal(t, updateDropConfig{dhcp: blockAction, mld: blockAction}, blockConf)
+	dropCfg := ebpfloader.DropPKT{IPv6RA: ebpfloader.ActionDrop}
+	blockConf.apply(&dropCfg)
+	require.Equal(t, ebpfloader.DropPKT{DHCPv4: ebpfloader.ActionDrop, MLD: ebpfloader.ActionDrop, IPv6RA: ebpfloader.ActionDrop}, dropCfg)
+}
+
+func TestCalculateStatsUnknownUnicast(t *testing.T) {
+	limits := newUniformTrafficLimits(10, 0)
+	limits.unknownUcast = newTrafficLimit(100, 100, 0)
+	watcher := newNetDevWatcher(1, "test_name", limits, testUnblockCheck, backoffConfig{}, mocks.NewMockeBPFProg(t))
+	prev := ebpfloader.PacketCounter{UnknownUcast: ebpfloader.TrafInfo{Passed: 1000}}
+	blockConf := watcher.calculateBlocks(&prev, &ebpfloader.PacketCounter{UnknownUcast: ebpfloader.TrafInfo{Passed: 1100}})
+	require.True(t, blockConf.isEmpty())
+	blockConf = watcher.calculateBlocks(&prev, &ebpfloader.PacketCounter{UnknownUcast: ebpfloader.TrafInfo{Passed: 1101}})
+	require.Equal(t, updateDropConfig{ucast: blockAction}, blockConf)
+	dropCfg := ebpfloader.DropPKT{}
+	blockConf.apply(&dropCfg)
+	require.Equal(t, ebpfloader.DropPKT{UnknownUcast: ebpfloader.ActionDrop}, dropCfg)
+}
+
+func TestCalculateStatsExempt(t *testing.T) {
+	limits := newUniformTrafficLimits(10, 0)
+	limits.setExempt(ipv4McastType)
+	watcher := newNetDevWatcher(1, "test_name", limits, testUnblockCheck, backoffConfig{}, mocks.NewMockeBPFProg(t))
+	prev := ebpfloader.PacketCounter{}
+	cur := ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 11},
+		IPv4MCast: ebpfloader.TrafInfo{Passed: 500},
+	}
+	blockConf := watcher.calculateBlocks(&prev, &cur)
+	require.Equal(t, updateDropConfig{br: blockAction}, blockConf)
+}
+
+func TestCalculateStatsBytesThreshold(t *testing.T) {
+	limits := newUniformTrafficLimits(100, 0)
+	limits.ipv4Mcast.blockThresholdBytes = 10000
+	watcher := newNetDevWatcher(1, "test_name", limits, testUnblockCheck, backoffConfig{}, mocks.NewMockeBPFProg(t))
+	prev := ebpfloader.PacketCounter{}
+	// bytes threshold is disabled for broadcast
+	cur := ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 10, PassedBytes: 1000000},
+		IPv4MCast: ebpfloader.TrafInfo{Passed: 10, PassedBytes: 9000},
+	}
+	blockConf := watcher.calculateBlocks(&prev, &cur)
+	require.Equal(t, updateDropConfig{}, blockConf)
+	prev, cur = cur, ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 20, PassedBytes: 2000000},
+		IPv4MCast: ebpfloader.TrafInfo{Passed: 20, PassedBytes: 20000},
+	}
+	blockConf = watcher.calculateBlocks(&prev, &cur)
+	require.Equal(t, updateDropConfig{ipv4: blockAction}, blockConf)
+}
+
+func TestIsQuietBytes(t *testing.T) {
+	limits := newUniformTrafficLimits(100, 0)
+	limits.broadcast.blockThresholdBytes = 10000
+	limits.broadcast.unblockThresholdBytes = 5000
+	watcher := newNetDevWatcher(1, "test_name", limits, testUnblockCheck, backoffConfig{}, mocks.NewMockeBPFProg(t))
+	prev := ebpfloader.PacketCounter{}
+	require.True(t, watcher.isQuiet(&prev, &ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Dropped: 10, DroppedBytes: 4000},
+	}, broadcastType, time.Second))
+	require.False(t, watcher.isQuiet(&prev, &ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Dropped: 10, DroppedBytes: 5000},
+	}, broadcastType, time.Second))
+	require.False(t, watcher.isQuiet(&prev, &ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Dropped: 100, DroppedBytes: 0},
+	}, broadcastType, time.Second))
+}
+
+func TestResumeBlocks(t *testing.T) {
+	ebpfMock := mocks.NewMockeBPFProg(t)
+	watcher := newNetDevWatcher(1, "test_name", newUniformTrafficLimits(10, time.Hour), testUnblockCheck, backoffConfig{}, ebpfMock)
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{
+		Broadcast: ebpfloader.ActionDrop,
+		Multicast: ebpfloader.ActionRateLimit,
+	}, nil)
+	now := time.Now()
+	watcher.resumeBlocks(now)
+	require.Len(t, watcher.sched.blocks, 1)
+	require.Equal(t, now.Add(time.Hour), watcher.sched.blocks[broadcastType].checkAt)
+	require.NotContains(t, watcher.sched.blocks, otherType)
 }
@@ -0,0 +1,117 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const netlinkReadBufferSize = 1 << 16
+
+// subscribeNetlink opens NETLINK_ROUTE socket subscribed to RTNLGRP_LINK group
+// and converts RTM_NEWLINK/RTM_DELLINK notifications to link events.
+// Returned channel is closed when done channel is closed or socket read fails.
+func subscribeNetlink(done <-chan struct{}) (<-chan linkEvent, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, fmt.Errorf("create netlink socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: unix.RTMGRP_LINK}); err != nil {
+		unix.Close(fd)
+
+		return nil, fmt.Errorf("bind netlink socket: %w", err)
+	}
+	// non blocking mode allows to use go runtime poller, so Close unblocks Read
+	if err := unix.SetNonblock(fd, true); err != nil {
+		unix.Close(fd)
+
+		return nil, fmt.Errorf("set netlink socket non blocking mode: %w", err)
+	}
+	sock := os.NewFile(uintptr(fd), "netlink")
+
+	events := make(chan linkEvent, linkEventsBuffer)
+	go func() {
+		<-done
+		sock.Close()
+	}()
+	go readNetlink(sock, events, done)
+
+	return events, nil
+}
+
+func readNetlink(sock *os.File, events chan<- linkEvent, done <-chan struct{}) {
+	defer close(events)
+	buf := make([]byte, netlinkReadBufferSize)
+	for {
+		readBytes, err := sock.Read(buf)
+		if err != nil {
+			// kernel drops notifications in case of socket buffer overflow,
+			// full resync is required to restore consistent state
+			if errors.Is(err, syscall.ENOBUFS) {
+				if !sendLinkEvent(events, linkEvent{eventType: linkResync}, done) {
+					return
+				}
+
+				continue
+			}
+
+			return
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:readBytes])
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			event, ok := parseLinkMessage(&msg)
+			if !ok {
+				continue
+			}
+			if !sendLinkEvent(events, event, done) {
+				return
+			}
+		}
+	}
+}
+
+func sendLinkEvent(events chan<- linkEvent, event linkEvent, done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return false
+	case events <- event:
+		return true
+	}
+}
+
+func parseLinkMessage(msg *syscall.NetlinkMessage) (linkEvent, bool) {
+	var event linkEvent
+	switch msg.Header.Type {
+	case syscall.RTM_NEWLINK:
+		event.eventType = linkAdded
+	case syscall.RTM_DELLINK:
+		event.eventType = linkRemoved
+	default:
+		return event, false
+	}
+	if len(msg.Data) < syscall.SizeofIfInfomsg {
+		return event, false
+	}
+	ifInfo := (*syscall.IfInfomsg)(unsafe.Pointer(&msg.Data[0])) //nolint:gosec
+	event.index = int(ifInfo.Index)
+
+	attrs, err := syscall.ParseNetlinkRouteAttr(msg)
+	if err != nil {
+		return event, false
+	}
+	for _, attr := range attrs {
+		if attr.Attr.Type == syscall.IFLA_IFNAME {
+			event.name = strings.TrimRight(string(attr.Value), "\x00")
+		}
+	}
+
+	return event, true
+}
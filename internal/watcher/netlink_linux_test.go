@@ -0,0 +1,50 @@
+package watcher
+
+import (
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func makeLinkMessage(t *testing.T, msgType uint16, index int32, name string) syscall.NetlinkMessage {
+	t.Helper()
+	ifInfo := syscall.IfInfomsg{Family: syscall.AF_UNSPEC, Index: index}
+	data := make([]byte, 0, syscall.SizeofIfInfomsg+syscall.SizeofRtAttr+len(name)+1)
+	data = append(data, (*[syscall.SizeofIfInfomsg]byte)(unsafe.Pointer(&ifInfo))[:]...)
+
+	attrValue := append([]byte(name), 0)
+	attr := syscall.RtAttr{Len: uint16(syscall.SizeofRtAttr + len(attrValue)), Type: syscall.IFLA_IFNAME}
+	data = append(data, (*[syscall.SizeofRtAttr]byte)(unsafe.Pointer(&attr))[:]...)
+	data = append(data, attrValue...)
+	// attributes are aligned to 4 bytes
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+
+	return syscall.NetlinkMessage{
+		Header: syscall.NlMsghdr{Type: msgType, Len: uint32(syscall.NLMSG_HDRLEN + len(data))},
+		Data:   data,
+	}
+}
+
+func TestParseLinkMessage(t *testing.T) {
+	msg := makeLinkMessage(t, syscall.RTM_NEWLINK, 15, "tap15")
+	event, ok := parseLinkMessage(&msg)
+	require.True(t, ok)
+	require.Equal(t, linkEvent{eventType: linkAdded, index: 15, name: "tap15"}, event)
+
+	msg = makeLinkMessage(t, syscall.RTM_DELLINK, 16, "tap16")
+	event, ok = parseLinkMessage(&msg)
+	require.True(t, ok)
+	require.Equal(t, linkEvent{eventType: linkRemoved, index: 16, name: "tap16"}, event)
+
+	msg = makeLinkMessage(t, syscall.RTM_NEWADDR, 16, "tap16")
+	_, ok = parseLinkMessage(&msg)
+	require.False(t, ok)
+
+	msg = syscall.NetlinkMessage{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWLINK}, Data: []byte{1, 2}}
+	_, ok = parseLinkMessage(&msg)
+	require.False(t, ok)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package watcher
+
+import "errors"
+
+func subscribeNetlink(<-chan struct{}) (<-chan linkEvent, error) {
+	return nil, errors.New("netlink is not supported on this platform")
+}
@@ -0,0 +1,308 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+)
+
+const (
+	defaultPolicyName      = "default"
+	defaultRecheckInterval = 3 * time.Second
+)
+
+// block modes
+const (
+	// traffic is dropped by watcher decision when threshold is exceeded
+	dropMode = "drop"
+	// traffic above threshold is dropped by kernel token bucket
+	rateLimitMode = "rate_limit"
+)
+
+// trafficTypeNames are traffic types by name
+var trafficTypeNames = makeTrafficTypeNames()
+
+// traffic types are numbered from 1 in order of ebpfloader.TrafficTypes
+func makeTrafficTypeNames() map[string]int {
+	result := make(map[string]int, len(ebpfloader.TrafficTypes))
+	for i, trafType := range ebpfloader.TrafficTypes {
+		result[trafType.Name] = i + 1
+	}
+
+	return result
+}
+
+// trafficFields returns name and accessors of counters and drop config of traffic type
+func trafficFields(trafType int) ebpfloader.TrafficType {
+	return ebpfloader.TrafficTypes[trafType-1]
+}
+
+// netDevPolicy is resolved watcher settings for interface
+type netDevPolicy struct {
+	name           string
+	interfaceName  string
+	interfaceRegEx *regexp.Regexp
+	interfaceIndex int
+	blockEnabled   bool
+	blockMode      string
+	attachMode     string
+	// decisions of drop mode are made but not applied
+	dryRun bool
+	// traffic is evaluated and blocked by VLAN
+	vlanAware bool
+	// number of reported sources with highest rate, 0 if top talkers are not tracked
+	topTalkers int
+	// allowed addresses of unicast check, global addresses are used if nil
+	allowedMACs []net.HardwareAddr
+	limits      trafficLimits
+}
+
+func (p *netDevPolicy) match(netDevIndex int, netDevName string) bool {
+	if p.interfaceName != "" && p.interfaceName != netDevName {
+		return false
+	}
+	if p.interfaceRegEx != nil && !p.interfaceRegEx.MatchString(netDevName) {
+		return false
+	}
+	if p.interfaceIndex != 0 && p.interfaceIndex != netDevIndex {
+		return false
+	}
+
+	return true
+}
+
+// overrideLimit replaces limit values with not zero values of overrides
+func overrideLimit(limit config.TrafficLimit, overrides ...config.TrafficLimit) config.TrafficLimit {
+	for _, override := range overrides {
+		if override.BlockThreshold != 0 {
+			limit.BlockThreshold = override.BlockThreshold
+		}
+		if override.BlockThresholdBytes != 0 {
+			limit.BlockThresholdBytes = override.BlockThresholdBytes
+		}
+		if override.BlockDelay != 0 {
+			limit.BlockDelay = override.BlockDelay
+		}
+		if override.BlockBurst != 0 {
+			limit.BlockBurst = override.BlockBurst
+		}
+	}
+
+	return limit
+}
+
+// overrideLimits applies common limit and after that type specific limits to each type of traffic
+func overrideLimits(limits config.TrafficLimits, common config.TrafficLimit, types config.TrafficLimits) config.TrafficLimits {
+	return config.TrafficLimits{
+		Broadcast:      overrideLimit(limits.Broadcast, common, types.Broadcast),
+		IPv4Multicast:  overrideLimit(limits.IPv4Multicast, common, types.IPv4Multicast),
+		IPv6Multicast:  overrideLimit(limits.IPv6Multicast, common, types.IPv6Multicast),
+		OtherMulticast: overrideLimit(limits.OtherMulticast, common, types.OtherMulticast),
+		ARP:            overrideLimit(limits.ARP, common, types.ARP),
+		DHCPv4:         overrideLimit(limits.DHCPv4, common, types.DHCPv4),
+		IPv6ND:         overrideLimit(limits.IPv6ND, common, types.IPv6ND),
+		MLD:            overrideLimit(limits.MLD, common, types.MLD),
+		IPv6RA:         overrideLimit(limits.IPv6RA, common, types.IPv6RA),
+		UnknownUnicast: overrideLimit(limits.UnknownUnicast, common, types.UnknownUnicast),
+	}
+}
+
+func globalLimits(cfg config.WatcherConfig) config.TrafficLimits {
+	return overrideLimits(
+		config.TrafficLimits{},
+		config.TrafficLimit{
+			BlockThreshold:      cfg.BlockThreshold,
+			BlockThresholdBytes: cfg.BlockThresholdBytes,
+			BlockDelay:          cfg.BlockDelay,
+			BlockBurst:          cfg.BlockBurst,
+		},
+		cfg.TrafficLimits,
+	)
+}
+
+func trafficTypeName(trafType int) string {
+	if trafType < 1 || trafType > len(ebpfloader.TrafficTypes) {
+		return "unknown"
+	}
+
+	return trafficFields(trafType).Name
+}
+
+func getUnblockThreshold(cfg config.UnblockConfig, blockThreshold uint64) uint64 {
+	if cfg.Threshold != 0 {
+		return cfg.Threshold
+	}
+
+	return getUnblockThresholdByRatio(cfg, blockThreshold)
+}
+
+func getUnblockThresholdByRatio(cfg config.UnblockConfig, blockThreshold uint64) uint64 {
+	if cfg.ThresholdRatio <= 0 {
+		return blockThreshold
+	}
+
+	return uint64(float64(blockThreshold) * cfg.ThresholdRatio)
+}
+
+func makeUnblockCheck(cfg config.UnblockConfig) unblockCheck {
+	result := unblockCheck{
+		interval:     time.Duration(cfg.RecheckInterval) * time.Second,
+		quietWindows: cfg.QuietWindows,
+	}
+	if result.interval <= 0 {
+		result.interval = defaultRecheckInterval
+	}
+	if result.quietWindows < 1 {
+		result.quietWindows = 1
+	}
+
+	return result
+}
+
+func makeTrafficLimits(limits config.TrafficLimits, unblockCfg config.UnblockConfig) trafficLimits {
+	makeLimit := func(limit config.TrafficLimit) trafficLimit {
+		result := newTrafficLimit(
+			limit.BlockThreshold,
+			getUnblockThreshold(unblockCfg, limit.BlockThreshold),
+			time.Duration(limit.BlockDelay)*time.Second,
+		)
+		result.burst = limit.BlockBurst
+		result.blockThresholdBytes = limit.BlockThresholdBytes
+		result.unblockThresholdBytes = getUnblockThresholdByRatio(unblockCfg, limit.BlockThresholdBytes)
+
+		return result
+	}
+
+	return trafficLimits{
+		broadcast:    makeLimit(limits.Broadcast),
+		ipv4Mcast:    makeLimit(limits.IPv4Multicast),
+		ipv6Mcast:    makeLimit(limits.IPv6Multicast),
+		other:        makeLimit(limits.OtherMulticast),
+		arp:          makeLimit(limits.ARP),
+		dhcpv4:       makeLimit(limits.DHCPv4),
+		nd:           makeLimit(limits.IPv6ND),
+		mld:          makeLimit(limits.MLD),
+		ra:           makeLimit(limits.IPv6RA),
+		unknownUcast: makeLimit(limits.UnknownUnicast),
+	}
+}
+
+func checkBlockMode(blockMode string) error {
+	if blockMode != dropMode && blockMode != rateLimitMode {
+		return fmt.Errorf("unknown block mode %s", blockMode)
+	}
+
+	return nil
+}
+
+func makeDefaultPolicy(cfg config.WatcherConfig) netDevPolicy {
+	return netDevPolicy{
+		name:         defaultPolicyName,
+		blockEnabled: cfg.BlockEnabled,
+		blockMode:    cfg.BlockMode,
+		attachMode:   cfg.AttachMode,
+		dryRun:       cfg.DryRun,
+		topTalkers:   topTalkersCount(cfg.TopTalkers),
+		limits:       makeTrafficLimits(globalLimits(cfg), cfg.Unblock),
+	}
+}
+
+func topTalkersCount(cfg config.TopTalkersConfig) int {
+	if !cfg.Enabled {
+		return 0
+	}
+
+	return cfg.Count
+}
+
+func makePolicy(cfg config.WatcherConfig, policyCfg config.Policy) (netDevPolicy, error) {
+	policy := netDevPolicy{
+		name:           policyCfg.Name,
+		interfaceName:  policyCfg.InterfaceName,
+		interfaceIndex: policyCfg.InterfaceIndex,
+		blockEnabled:   cfg.BlockEnabled,
+		blockMode:      cfg.BlockMode,
+		attachMode:     cfg.AttachMode,
+		dryRun:         cfg.DryRun,
+		vlanAware:      policyCfg.VLANAware,
+		topTalkers:     topTalkersCount(cfg.TopTalkers),
+	}
+	if policyCfg.InterfaceName == "" && policyCfg.InterfaceRegEx == "" && policyCfg.InterfaceIndex == 0 {
+		return netDevPolicy{}, errors.New("at least one of interface_name, interface_regex or interface_index must be specified")
+	}
+	if policyCfg.InterfaceRegEx != "" {
+		regExp, err := regexp.Compile(policyCfg.InterfaceRegEx)
+		if err != nil {
+			return netDevPolicy{}, err
+		}
+		policy.interfaceRegEx = regExp
+	}
+	if policyCfg.BlockEnabled != nil {
+		policy.blockEnabled = *policyCfg.BlockEnabled
+	}
+	if policyCfg.DryRun != nil {
+		policy.dryRun = *policyCfg.DryRun
+	}
+	if policyCfg.BlockMode != "" {
+		if err := checkBlockMode(policyCfg.BlockMode); err != nil {
+			return netDevPolicy{}, err
+		}
+		policy.blockMode = policyCfg.BlockMode
+	}
+	if policyCfg.AttachMode != "" {
+		if err := ebpfloader.CheckAttachMode(policyCfg.AttachMode); err != nil {
+			return netDevPolicy{}, err
+		}
+		policy.attachMode = policyCfg.AttachMode
+	}
+	policy.limits = makeTrafficLimits(
+		overrideLimits(
+			globalLimits(cfg),
+			config.TrafficLimit{
+				BlockThreshold:      policyCfg.BlockThreshold,
+				BlockThresholdBytes: policyCfg.BlockThresholdBytes,
+				BlockDelay:          policyCfg.BlockDelay,
+				BlockBurst:          policyCfg.BlockBurst,
+			},
+			policyCfg.TrafficLimits,
+		),
+		cfg.Unblock,
+	)
+	if policyCfg.AllowedMACs != nil {
+		allowedMACs, err := parseAllowedMACs(policyCfg.AllowedMACs)
+		if err != nil {
+			return netDevPolicy{}, err
+		}
+		policy.allowedMACs = allowedMACs
+	}
+	for _, typeName := range policyCfg.Exempt {
+		trafType, ok := trafficTypeNames[typeName]
+		if !ok {
+			return netDevPolicy{}, fmt.Errorf("unknown traffic type %s", typeName)
+		}
+		policy.limits.setExempt(trafType)
+	}
+
+	return policy, nil
+}
+
+func makePolicies(cfg config.WatcherConfig) ([]netDevPolicy, error) {
+	result := make([]netDevPolicy, 0, len(cfg.Policies))
+	for index, policyCfg := range cfg.Policies {
+		policy, err := makePolicy(cfg, policyCfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %d (%s): %w", index, policyCfg.Name, err)
+		}
+		if policy.name == "" {
+			policy.name = fmt.Sprintf("policy-%d", index)
+		}
+		result = append(result, policy)
+	}
+
+	return result, nil
+}
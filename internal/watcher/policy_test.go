@@ -0,0 +1,211 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrafficTypeNames(t *testing.T) {
+	require.Len(t, trafficTypeNames, len(trafficTypes))
+	require.Equal(t, broadcastType, trafficTypeNames["broadcast"])
+	require.Equal(t, otherType, trafficTypeNames["other_multicast"])
+	require.Equal(t, unknownUcastType, trafficTypeNames["unknown_unicast"])
+	require.Equal(t, "ipv6_ra", trafficTypeName(raType))
+	require.Equal(t, "unknown", trafficTypeName(0))
+	cfg := ebpfloader.DropPKT{}
+	*trafficFields(ndType).Action(&cfg) = ebpfloader.ActionDrop
+	require.Equal(t, ebpfloader.DropPKT{IPv6ND: ebpfloader.ActionDrop}, cfg)
+}
+
+func TestDefaultPolicyLimits(t *testing.T) {
+	policy := makeDefaultPolicy(config.WatcherConfig{
+		BlockThreshold: 100,
+		BlockDelay:     10,
+		BlockEnabled:   true,
+		TrafficLimits: config.TrafficLimits{
+			Broadcast:     config.TrafficLimit{BlockThreshold: 20},
+			IPv6Multicast: config.TrafficLimit{BlockThreshold: 1000, BlockDelay: 3},
+			DHCPv4:        config.TrafficLimit{BlockThreshold: 5},
+		},
+	})
+	require.Equal(t, defaultPolicyName, policy.name)
+	require.True(t, policy.blockEnabled)
+	require.Equal(t, trafficLimit{blockThreshold: 20, unblockThreshold: 20, dropDelay: 10 * time.Second}, policy.limits.broadcast)
+	require.Equal(t, trafficLimit{blockThreshold: 100, unblockThreshold: 100, dropDelay: 10 * time.Second}, policy.limits.ipv4Mcast)
+	require.Equal(t, trafficLimit{blockThreshold: 1000, unblockThreshold: 1000, dropDelay: 3 * time.Second}, policy.limits.ipv6Mcast)
+	require.Equal(t, policy.limits.ipv4Mcast, policy.limits.other)
+	// sub-classes do not inherit limits of parent type
+	require.Equal(t, policy.limits.ipv4Mcast, policy.limits.arp)
+	require.Equal(t, trafficLimit{blockThreshold: 5, unblockThreshold: 5, dropDelay: 10 * time.Second}, policy.limits.dhcpv4)
+	require.Equal(t, policy.limits.ipv4Mcast, policy.limits.nd)
+}
+
+func TestPolicyLimits(t *testing.T) {
+	blockEnabled := false
+	policy, err := makePolicy(
+		config.WatcherConfig{
+			BlockThreshold: 100,
+			BlockDelay:     10,
+			BlockEnabled:   true,
+			TrafficLimits: config.TrafficLimits{
+				IPv6Multicast: config.TrafficLimit{BlockThreshold: 1000, BlockDelay: 3},
+			},
+		},
+		config.Policy{
+			Name:           "routers",
+			InterfaceRegEx: "^tapr",
+			BlockEnabled:   &blockEnabled,
+			BlockThreshold: 5000,
+			TrafficLimits: config.TrafficLimits{
+				OtherMulticast: config.TrafficLimit{BlockThreshold: 10},
+			},
+			Exempt: []string{"ipv4_multicast"},
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, "routers", policy.name)
+	require.False(t, policy.blockEnabled)
+	require.Equal(t, trafficLimit{blockThreshold: 5000, unblockThreshold: 5000, dropDelay: 10 * time.Second}, policy.limits.broadcast)
+	require.Equal(t, trafficLimit{blockThreshold: 5000, unblockThreshold: 5000, dropDelay: 10 * time.Second, exempt: true}, policy.limits.ipv4Mcast)
+	// policy common threshold overrides global type specific threshold
+	require.Equal(t, trafficLimit{blockThreshold: 5000, unblockThreshold: 5000, dropDelay: 3 * time.Second}, policy.limits.ipv6Mcast)
+	require.Equal(t, trafficLimit{blockThreshold: 10, unblockThreshold: 10, dropDelay: 10 * time.Second}, policy.limits.other)
+}
+
+func TestInvalidPolicies(t *testing.T) {
+	tCases := []config.Policy{
+		{Name: "no match"},
+		{Name: "bad regex", InterfaceRegEx: "[a-"},
+		{Name: "bad exempt", InterfaceName: "tap1", Exempt: []string{"unicast"}},
+		{Name: "bad mode", InterfaceName: "tap1", BlockMode: "police"},
+	}
+	for _, tCase := range tCases {
+		_, err := makePolicies(config.WatcherConfig{Policies: []config.Policy{tCase}})
+		require.Error(t, err, tCase.Name)
+	}
+}
+
+func TestPolicyMatch(t *testing.T) {
+	policies, err := makePolicies(config.WatcherConfig{
+		Policies: []config.Policy{
+			{Name: "by-name", InterfaceName: "tap1"},
+			{Name: "by-index", InterfaceIndex: 5},
+			{InterfaceRegEx: "^tap1"},
+			{Name: "name-and-index", InterfaceName: "tap7", InterfaceIndex: 8},
+		},
+	})
+	require.NoError(t, err)
+	watcher, _ := makeTestWatcher(t)
+	watcher.policies = policies
+
+	require.Equal(t, "by-name", watcher.findPolicy(1, "tap1").name)
+	require.Equal(t, "by-name", watcher.findPolicy(5, "tap1").name)
+	require.Equal(t, "by-index", watcher.findPolicy(5, "tap5").name)
+	require.Equal(t, "policy-2", watcher.findPolicy(123, "tap123").name)
+	require.Equal(t, defaultPolicyName, watcher.findPolicy(7, "tap7").name)
+	require.Equal(t, "name-and-index", watcher.findPolicy(8, "tap7").name)
+}
+
+func TestUnblockSettings(t *testing.T) {
+	require.Equal(t, uint64(50), getUnblockThreshold(config.UnblockConfig{ThresholdRatio: 0.5}, 100))
+	require.Equal(t, uint64(100), getUnblockThreshold(config.UnblockConfig{}, 100))
+	require.Equal(t, uint64(7), getUnblockThreshold(config.UnblockConfig{Threshold: 7, ThresholdRatio: 0.5}, 100))
+
+	require.Equal(t,
+		unblockCheck{interval: 5 * time.Second, quietWindows: 2},
+		makeUnblockCheck(config.UnblockConfig{RecheckInterval: 5, QuietWindows: 2}),
+	)
+	require.Equal(t,
+		unblockCheck{interval: defaultRecheckInterval, quietWindows: 1},
+		makeUnblockCheck(config.UnblockConfig{}),
+	)
+}
+
+func TestPolicyBlockMode(t *testing.T) {
+	cfg := config.WatcherConfig{BlockMode: dropMode, BlockThreshold: 100, BlockBurst: 150}
+	require.Equal(t, dropMode, makeDefaultPolicy(cfg).blockMode)
+	require.Equal(t, uint64(150), makeDefaultPolicy(cfg).limits.broadcast.burst)
+	policy, err := makePolicy(cfg, config.Policy{
+		InterfaceName: "tap1",
+		BlockMode:     rateLimitMode,
+		TrafficLimits: config.TrafficLimits{Broadcast: config.TrafficLimit{BlockBurst: 1000}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, rateLimitMode, policy.blockMode)
+	require.Equal(t, uint64(1000), policy.limits.broadcast.burst)
+	require.Equal(t, uint64(150), policy.limits.ipv4Mcast.burst)
+	require.Error(t, checkBlockMode("police"))
+}
+
+func TestPolicyBytesThreshold(t *testing.T) {
+	cfg := config.WatcherConfig{
+		BlockThreshold:      100,
+		BlockThresholdBytes: 100000,
+		TrafficLimits:       config.TrafficLimits{IPv6Multicast: config.TrafficLimit{BlockThresholdBytes: 5000}},
+		Unblock:             config.UnblockConfig{Threshold: 10, ThresholdRatio: 0.5},
+	}
+	policy := makeDefaultPolicy(cfg)
+	require.Equal(t, uint64(100000), policy.limits.broadcast.blockThresholdBytes)
+	// absolute unblock threshold is in packets, bytes unblock threshold uses ratio
+	require.Equal(t, uint64(10), policy.limits.broadcast.unblockThreshold)
+	require.Equal(t, uint64(50000), policy.limits.broadcast.unblockThresholdBytes)
+	require.Equal(t, uint64(5000), policy.limits.ipv6Mcast.blockThresholdBytes)
+
+	policy, err := makePolicy(cfg, config.Policy{InterfaceName: "tap1", BlockThresholdBytes: 20000})
+	require.NoError(t, err)
+	require.Equal(t, uint64(20000), policy.limits.broadcast.blockThresholdBytes)
+	require.Equal(t, uint64(20000), policy.limits.ipv6Mcast.blockThresholdBytes)
+}
+
+func TestPolicyAttachMode(t *testing.T) {
+	cfg := config.WatcherConfig{AttachMode: ebpfloader.AttachModeAuto}
+	require.Equal(t, ebpfloader.AttachModeAuto, makeDefaultPolicy(cfg).attachMode)
+	policy, err := makePolicy(cfg, config.Policy{InterfaceName: "eth0", AttachMode: ebpfloader.AttachModeNative})
+	require.NoError(t, err)
+	require.Equal(t, ebpfloader.AttachModeNative, policy.attachMode)
+	policy, err = makePolicy(cfg, config.Policy{InterfaceName: "tap1"})
+	require.NoError(t, err)
+	require.Equal(t, ebpfloader.AttachModeAuto, policy.attachMode)
+	_, err = makePolicy(cfg, config.Policy{InterfaceName: "tap1", AttachMode: "driver"})
+	require.Error(t, err)
+	// offloaded program can not share maps with host
+	_, err = makePolicy(cfg, config.Policy{InterfaceName: "tap1", AttachMode: "offload"})
+	require.EqualError(t, err, "unknown xdp attach mode offload")
+}
+
+func TestPolicyDryRun(t *testing.T) {
+	cfg := config.WatcherConfig{DryRun: true}
+	require.True(t, makeDefaultPolicy(cfg).dryRun)
+	policy, err := makePolicy(cfg, config.Policy{InterfaceName: "tap1"})
+	require.NoError(t, err)
+	require.True(t, policy.dryRun)
+	dryRun := false
+	policy, err = makePolicy(cfg, config.Policy{InterfaceName: "tap1", DryRun: &dryRun})
+	require.NoError(t, err)
+	require.False(t, policy.dryRun)
+}
+
+func TestPolicyVLANAware(t *testing.T) {
+	cfg := config.WatcherConfig{}
+	require.False(t, makeDefaultPolicy(cfg).vlanAware)
+	policy, err := makePolicy(cfg, config.Policy{InterfaceRegEx: "^bond", VLANAware: true})
+	require.NoError(t, err)
+	require.True(t, policy.vlanAware)
+	require.True(t, needReattach(makeDefaultPolicy(cfg), policy))
+}
+
+func TestPolicyTopTalkers(t *testing.T) {
+	cfg := config.WatcherConfig{TopTalkers: config.TopTalkersConfig{Count: 5}}
+	require.Zero(t, makeDefaultPolicy(cfg).topTalkers)
+	cfg.TopTalkers.Enabled = true
+	require.Equal(t, 5, makeDefaultPolicy(cfg).topTalkers)
+	policy, err := makePolicy(cfg, config.Policy{InterfaceRegEx: "^tap"})
+	require.NoError(t, err)
+	require.Equal(t, 5, policy.topTalkers)
+	// tracking is enabled without reattach
+	require.False(t, needReattach(makeDefaultPolicy(config.WatcherConfig{}), policy))
+}
@@ -0,0 +1,125 @@
+package watcher
+
+import (
+	"sync"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
+)
+
+// RateHistorySize is number of per second samples kept for every interface,
+// it is max window of peak and average rates in seconds
+const RateHistorySize = 300
+
+// TrafficRate is passed traffic per second
+type TrafficRate struct {
+	Packets float64
+	Bytes   float64
+}
+
+// WindowRates contains peak and average rates over window by traffic type
+type WindowRates struct {
+	// window in seconds
+	Window  int
+	Peak    map[string]TrafficRate
+	Average map[string]TrafficRate
+}
+
+// NetDevRates contains per second rates of passed traffic of watched interface
+type NetDevRates struct {
+	Index int
+	Name  string
+	// rate of last second by traffic type
+	Latest  map[string]TrafficRate
+	Windows []WindowRates
+	// sources with highest broadcast and multicast rate of last second, set if top talkers are tracked
+	TopTalkers []events.Talker
+}
+
+// rateHistory is ring buffer of per second traffic differences
+type rateHistory struct {
+	mux     sync.Mutex
+	samples []ebpfloader.PacketCounter
+	// position of next sample
+	next  int
+	count int
+}
+
+func newRateHistory(size int) *rateHistory {
+	return &rateHistory{samples: make([]ebpfloader.PacketCounter, size)}
+}
+
+func (r *rateHistory) add(sample ebpfloader.PacketCounter) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	r.count = min(r.count+1, len(r.samples))
+}
+
+// last returns up to window latest samples, newest sample is first
+func (r *rateHistory) last(window int) []ebpfloader.PacketCounter {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	result := make([]ebpfloader.PacketCounter, 0, min(window, r.count))
+	for i := 1; i <= min(window, r.count); i++ {
+		result = append(result, r.samples[(r.next-i+len(r.samples))%len(r.samples)])
+	}
+
+	return result
+}
+
+// windowRates returns peak and average rates of traffic type, peak of packets and bytes
+// can be observed in different seconds. Average is calculated over collected samples if
+// watcher runs less than window.
+func windowRates(samples []ebpfloader.PacketCounter, trafType int) (TrafficRate, TrafficRate) {
+	var peak, average TrafficRate
+	if len(samples) == 0 {
+		return peak, average
+	}
+	for i := range samples {
+		info := *trafficFields(trafType).Counters(&samples[i])
+		peak.Packets = max(peak.Packets, float64(info.Passed))
+		peak.Bytes = max(peak.Bytes, float64(info.PassedBytes))
+		average.Packets += float64(info.Passed)
+		average.Bytes += float64(info.PassedBytes)
+	}
+	average.Packets /= float64(len(samples))
+	average.Bytes /= float64(len(samples))
+
+	return peak, average
+}
+
+// rates returns latest rates and rates over windows in seconds
+func (n *netDevWatcher) rates(windows []int) NetDevRates {
+	result := NetDevRates{
+		Index:      n.netDevIndex,
+		Name:       n.netDevName,
+		Latest:     make(map[string]TrafficRate, len(trafficTypeNames)),
+		Windows:    make([]WindowRates, 0, len(windows)),
+		TopTalkers: n.getTopTalkers(),
+	}
+	// rates are zero until first sample
+	var latest ebpfloader.PacketCounter
+	if samples := n.history.last(1); len(samples) != 0 {
+		latest = samples[0]
+	}
+	for name, trafType := range trafficTypeNames {
+		info := *trafficFields(trafType).Counters(&latest)
+		result.Latest[name] = TrafficRate{Packets: float64(info.Passed), Bytes: float64(info.PassedBytes)}
+	}
+	for _, window := range windows {
+		samples := n.history.last(window)
+		windowResult := WindowRates{
+			Window:  window,
+			Peak:    make(map[string]TrafficRate, len(trafficTypeNames)),
+			Average: make(map[string]TrafficRate, len(trafficTypeNames)),
+		}
+		for name, trafType := range trafficTypeNames {
+			windowResult.Peak[name], windowResult.Average[name] = windowRates(samples, trafType)
+		}
+		result.Windows = append(result.Windows, windowResult)
+	}
+
+	return result
+}
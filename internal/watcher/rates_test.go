@@ -0,0 +1,47 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/stretchr/testify/require"
+)
+
+func broadcastSample(packets, bytes uint64) ebpfloader.PacketCounter {
+	return ebpfloader.PacketCounter{Broadcast: ebpfloader.TrafInfo{Passed: packets, PassedBytes: bytes}}
+}
+
+func TestRateHistory(t *testing.T) {
+	history := newRateHistory(3)
+	require.Empty(t, history.last(3))
+	for i := range uint64(5) {
+		history.add(broadcastSample(i, 0))
+	}
+	// oldest samples are overwritten, newest sample is first
+	require.Equal(t, []ebpfloader.PacketCounter{broadcastSample(4, 0), broadcastSample(3, 0), broadcastSample(2, 0)}, history.last(10))
+	require.Equal(t, []ebpfloader.PacketCounter{broadcastSample(4, 0)}, history.last(1))
+}
+
+func TestNetDevRates(t *testing.T) {
+	watcher := createWatcher(t)
+	rates := watcher.rates([]int{2})
+	require.Equal(t, TrafficRate{}, rates.Latest["broadcast"])
+	require.Equal(t, TrafficRate{}, rates.Windows[0].Average["broadcast"])
+
+	watcher.history.add(broadcastSample(100, 200))
+	watcher.history.add(broadcastSample(500, 100))
+	watcher.history.add(broadcastSample(300, 600))
+	rates = watcher.rates([]int{2, 10})
+	require.Equal(t, 1, rates.Index)
+	require.Equal(t, "test_name", rates.Name)
+	require.Equal(t, TrafficRate{Packets: 300, Bytes: 600}, rates.Latest["broadcast"])
+	require.Equal(t, TrafficRate{}, rates.Latest["ipv4_multicast"])
+	require.Len(t, rates.Windows, 2)
+
+	require.Equal(t, 2, rates.Windows[0].Window)
+	require.Equal(t, TrafficRate{Packets: 500, Bytes: 600}, rates.Windows[0].Peak["broadcast"])
+	require.Equal(t, TrafficRate{Packets: 400, Bytes: 350}, rates.Windows[0].Average["broadcast"])
+	// average over collected samples if window is longer than history
+	require.Equal(t, 10, rates.Windows[1].Window)
+	require.Equal(t, TrafficRate{Packets: 300, Bytes: 300}, rates.Windows[1].Average["broadcast"])
+}
@@ -0,0 +1,122 @@
+package watcher
+
+import (
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+)
+
+// schedulerInterval is interval of counters read and evaluation of all watched interfaces
+const schedulerInterval = time.Second
+
+// dropUpdate is drop map change of interface made by scheduler
+type dropUpdate struct {
+	devWatcher *netDevWatcher
+	update     updateDropConfig
+	cfg        ebpfloader.DropPKT
+	err        error
+}
+
+// runScheduler reads counters of all interfaces every second and evaluates
+// state of every watched interface until watcher is stopped
+func (w *Watcher) runScheduler() {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.closed:
+			return
+		case <-ticker.C:
+			stats, err := w.ebpfProg.GetCounterStat()
+			if err != nil {
+				w.log.Errorf("Error get statistic: %s", err.Error())
+
+				continue
+			}
+			w.schedule(stats, time.Now())
+		}
+	}
+}
+
+// schedule evaluates state of all watched interfaces and VLANs of VLAN aware interfaces
+// by counters read at now and applies drop map changes of all interfaces by one batch update.
+// Top sources reported by block events are updated before evaluation.
+func (w *Watcher) schedule(stats ebpfloader.CounterStat, now time.Time) {
+	w.devMux.RLock()
+	devWatchers := make([]*netDevWatcher, 0, len(w.devWatcherMap))
+	vlanNetDevs := make(map[uint32]*netDevWatcher)
+	for _, devWatcher := range w.devWatcherMap {
+		devWatchers = append(devWatchers, devWatcher)
+		if devWatcher.vlanAware() {
+			vlanNetDevs[uint32(devWatcher.index())] = devWatcher //nolint:gosec
+		}
+	}
+	w.devMux.RUnlock()
+
+	w.updateTopTalkers(devWatchers)
+	updates := w.evaluateVLANs(vlanNetDevs, now)
+	for _, devWatcher := range devWatchers {
+		// counters of just attached interface appear on next read
+		counters, ok := stats[uint32(devWatcher.index())] //nolint:gosec
+		if !ok {
+			continue
+		}
+		if update := devWatcher.evaluate(counters, now); !update.isEmpty() {
+			updates = append(updates, dropUpdate{devWatcher: devWatcher, update: update})
+		}
+	}
+	w.applyDropUpdates(updates)
+	for _, update := range updates {
+		update.devWatcher.commit(update.update, now, update.err)
+	}
+}
+
+// applyDropUpdates writes drop configs of all changed interfaces by one batch update.
+// If batch update fails interfaces are updated one by one to find failed ones.
+// Drop configs of VLANs are always updated one by one.
+func (w *Watcher) applyDropUpdates(updates []dropUpdate) {
+	if len(updates) == 0 {
+		return
+	}
+	batch := make(ebpfloader.DropConf, len(updates))
+	for i := range updates {
+		update := &updates[i]
+		update.devWatcher.dropMapMux.Lock()
+		if update.devWatcher.stopped {
+			update.err = ErrNetDevNotFound
+
+			continue
+		}
+		// override may be set after evaluation, manual action must not be replaced
+		update.devWatcher.skipOverridden(&update.update)
+		// decisions of dry run are confirmed without drop config change
+		if update.update.isEmpty() || update.devWatcher.getPolicy().dryRun {
+			continue
+		}
+		update.cfg, update.err = update.devWatcher.getDropCfg()
+		if update.err != nil {
+			continue
+		}
+		update.update.apply(&update.cfg)
+		if update.devWatcher.vlan != nil {
+			update.err = update.devWatcher.setDropCfg(update.cfg)
+
+			continue
+		}
+		batch[uint32(update.devWatcher.index())] = update.cfg //nolint:gosec
+	}
+	if len(batch) != 0 {
+		if err := w.ebpfProg.UpdateDevDropCfgs(batch); err != nil {
+			w.log.Warningf("Error batch update of drop config, update interfaces one by one: %s", err.Error())
+			for i := range updates {
+				_, ok := batch[uint32(updates[i].devWatcher.index())] //nolint:gosec
+				if ok && updates[i].err == nil && updates[i].devWatcher.vlan == nil {
+					updates[i].err = w.ebpfProg.UpdateDevDropCfg(updates[i].devWatcher.index(), updates[i].cfg)
+				}
+			}
+		}
+	}
+	for i := range updates {
+		updates[i].devWatcher.dropMapMux.Unlock()
+	}
+}
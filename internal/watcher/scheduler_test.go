@@ -0,0 +1,153 @@
+package watcher
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
+	"github.com/mythvcode/storm-control/internal/watcher/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := netDevPolicy{name: "default", blockEnabled: true, blockMode: dropMode, limits: newUniformTrafficLimits(10, time.Hour)}
+	watcher.devWatcherMap[1] = watcher.makeNetDevWatcher(1, "tap1", policy)
+	watcher.devWatcherMap[5] = watcher.makeNetDevWatcher(5, "tap5", policy)
+	// monitored interface is not blocked
+	watcher.devWatcherMap[123] = watcher.makeNetDevWatcher(123, "tap123", netDevPolicy{name: "default", limits: newUniformTrafficLimits(10, 0)})
+	now := time.Now()
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().GetDevDropCfg(5).Return(ebpfloader.DropPKT{}, nil).Once()
+	// counters of interface 5 are not read yet
+	watcher.schedule(ebpfloader.CounterStat{1: {}, 123: {}}, now)
+	require.Empty(t, watcher.devWatcherMap[5].history.last(1))
+	watcher.schedule(ebpfloader.CounterStat{1: {}, 5: {}, 123: {}}, now.Add(time.Second))
+
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{Multicast: ebpfloader.ActionDrop}, nil).Once()
+	ebpfMock.EXPECT().GetDevDropCfg(5).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfgs(ebpfloader.DropConf{
+		1: {Broadcast: ebpfloader.ActionDrop, Multicast: ebpfloader.ActionDrop},
+		5: {IPv6MCast: ebpfloader.ActionDrop},
+	}).Return(nil).Once()
+	watcher.schedule(ebpfloader.CounterStat{
+		1:   {Broadcast: ebpfloader.TrafInfo{Passed: 100}},
+		5:   {IPv6MCast: ebpfloader.TrafInfo{Passed: 100}},
+		123: {Broadcast: ebpfloader.TrafInfo{Passed: 100}},
+	}, now.Add(2*time.Second))
+	require.Contains(t, watcher.devWatcherMap[1].sched.blocks, broadcastType)
+	require.Contains(t, watcher.devWatcherMap[5].sched.blocks, ipv6McastType)
+	require.Empty(t, watcher.devWatcherMap[123].sched.blocks)
+	require.Equal(t, uint64(100), watcher.devWatcherMap[123].history.last(1)[0].Broadcast.Passed)
+}
+
+func TestScheduleDryRun(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	// dry run makes block decisions even if blocks are disabled
+	policy := netDevPolicy{name: "default", dryRun: true, limits: newUniformTrafficLimits(10, time.Hour)}
+	devWatcher := watcher.makeNetDevWatcher(1, "tap1", policy)
+	watcher.devWatcherMap[1] = devWatcher
+	eventsMock := mocks.NewMockeventPublisher(t)
+	devWatcher.events = eventsMock
+	now := time.Now()
+	// blocks left by previous instance are not resumed in dry run
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{Broadcast: ebpfloader.ActionDrop}, nil).Once()
+	watcher.schedule(ebpfloader.CounterStat{1: {}}, now)
+	require.Empty(t, devWatcher.sched.blocks)
+
+	// drop map is not changed by dry run decisions
+	eventsMock.EXPECT().Publish(events.Event{
+		Action:      events.ActionBlock,
+		Source:      events.SourceAuto,
+		Interface:   "tap1",
+		Index:       1,
+		TrafficType: "broadcast",
+		Rate:        100,
+		Threshold:   10,
+		Duration:    time.Hour,
+		DryRun:      true,
+	}).Once()
+	watcher.schedule(ebpfloader.CounterStat{1: {Broadcast: ebpfloader.TrafInfo{Passed: 100}}}, now.Add(time.Second))
+	require.Contains(t, devWatcher.sched.blocks, broadcastType)
+	require.True(t, devWatcher.state().DryRun)
+}
+
+func TestApplyDropUpdates(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := makeDefaultPolicy(watcher.config)
+	updates := []dropUpdate{
+		{devWatcher: watcher.makeNetDevWatcher(1, "tap1", policy), update: updateDropConfig{br: blockAction}},
+		{devWatcher: watcher.makeNetDevWatcher(5, "tap5", policy), update: updateDropConfig{ipv4: unblockAction}},
+		{devWatcher: watcher.makeNetDevWatcher(123, "tap123", policy), update: updateDropConfig{other: blockAction}},
+		{devWatcher: watcher.makeNetDevWatcher(7, "tap7", policy), update: updateDropConfig{br: blockAction}},
+	}
+	// drop map of stopped watcher is not changed
+	updates[3].devWatcher.stop()
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().GetDevDropCfg(5).Return(ebpfloader.DropPKT{IPv4MCast: ebpfloader.ActionDrop}, nil).Once()
+	ebpfMock.EXPECT().GetDevDropCfg(123).Return(ebpfloader.DropPKT{}, errors.New("lookup error")).Once()
+	batch := ebpfloader.DropConf{1: {Broadcast: ebpfloader.ActionDrop}, 5: {}}
+	ebpfMock.EXPECT().UpdateDevDropCfgs(batch).Return(errors.New("batch error")).Once()
+	// interfaces are updated one by one after batch error
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, batch[1]).Return(nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(5, batch[5]).Return(errors.New("update error")).Once()
+	watcher.applyDropUpdates(updates)
+	require.NoError(t, updates[0].err)
+	require.EqualError(t, updates[1].err, "update error")
+	require.EqualError(t, updates[2].err, "lookup error")
+	require.ErrorIs(t, updates[3].err, ErrNetDevNotFound)
+}
+
+func TestApplyDropUpdatesOverridden(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := netDevPolicy{name: "default", blockEnabled: true, blockMode: dropMode, limits: newUniformTrafficLimits(10, time.Hour)}
+	devWatcher := watcher.makeNetDevWatcher(1, "tap1", policy)
+	now := time.Now()
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	devWatcher.evaluate(ebpfloader.PacketCounter{}, now)
+	update := devWatcher.evaluate(ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 100},
+		IPv4MCast: ebpfloader.TrafInfo{Passed: 100},
+	}, now.Add(time.Second))
+	require.Equal(t, updateDropConfig{br: blockAction, ipv4: blockAction}, update)
+
+	// manual unblock is applied after evaluation and before drop map update
+	devWatcher.manual[broadcastType] = manualOverride{action: ManualUnblock, until: now.Add(time.Hour)}
+	updates := []dropUpdate{{devWatcher: devWatcher, update: update}}
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfgs(ebpfloader.DropConf{1: {IPv4MCast: ebpfloader.ActionDrop}}).Return(nil).Once()
+	watcher.applyDropUpdates(updates)
+	require.NoError(t, updates[0].err)
+	require.Equal(t, updateDropConfig{ipv4: blockAction}, updates[0].update)
+	devWatcher.commit(updates[0].update, now.Add(time.Second), updates[0].err)
+	require.NotContains(t, devWatcher.sched.blocks, broadcastType)
+	require.Contains(t, devWatcher.sched.blocks, ipv4McastType)
+
+	// drop map is not read if all actions are overridden
+	devWatcher.manual[otherType] = manualOverride{action: ManualExempt}
+	updates = []dropUpdate{{devWatcher: devWatcher, update: updateDropConfig{other: blockAction}}}
+	watcher.applyDropUpdates(updates)
+	require.NoError(t, updates[0].err)
+	require.True(t, updates[0].update.isEmpty())
+}
+
+func TestRunScheduler(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	watcher.devWatcherMap[1] = watcher.makeNetDevWatcher(1, "tap1", makeDefaultPolicy(watcher.config))
+	var once sync.Once
+	read := make(chan struct{})
+	ebpfMock.EXPECT().GetCounterStat().Run(func() {
+		once.Do(func() { close(read) })
+	}).Return(ebpfloader.CounterStat{1: {}}, nil)
+	go watcher.runScheduler()
+	defer close(watcher.closed)
+
+	select {
+	case <-read:
+	case <-time.After(3 * time.Second):
+		require.Fail(t, "counters are not read by scheduler")
+	}
+}
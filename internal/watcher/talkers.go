@@ -0,0 +1,125 @@
+package watcher
+
+import (
+	"net"
+	"slices"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
+)
+
+// topTalkers sorts sources by packets rate and returns count sources with highest rate,
+// sources with equal rate are sorted by address
+func topTalkers(talkers []events.Talker, count int) []events.Talker {
+	slices.SortFunc(talkers, func(a, b events.Talker) int {
+		if a.Rate != b.Rate {
+			if a.Rate > b.Rate {
+				return -1
+			}
+
+			return 1
+		}
+		if a.MAC < b.MAC {
+			return -1
+		}
+		if a.MAC > b.MAC {
+			return 1
+		}
+
+		return 0
+	})
+
+	return talkers[:min(count, len(talkers))]
+}
+
+// setTopTalkers replaces top sources of interface, watchers of VLANs get sources of whole interface
+func (n *netDevWatcher) setTopTalkers(talkers []events.Talker) {
+	n.talkersMux.Lock()
+	n.talkers = talkers
+	n.talkersMux.Unlock()
+	for _, vlanWatcher := range n.vlanWatchers() {
+		vlanWatcher.setTopTalkers(talkers)
+	}
+}
+
+// getTopTalkers returns top sources of last second, result must not be modified
+func (n *netDevWatcher) getTopTalkers() []events.Talker {
+	n.talkersMux.Lock()
+	defer n.talkersMux.Unlock()
+
+	return n.talkers
+}
+
+// talkerRate returns frames from source since previous read,
+// counter of evicted and created again source is counted from zero
+func talkerRate(cur, prev ebpfloader.TalkerCounter) ebpfloader.TalkerCounter {
+	if cur.Packets < prev.Packets {
+		return cur
+	}
+
+	return ebpfloader.TalkerCounter{Packets: cur.Packets - prev.Packets, Bytes: cur.Bytes - prev.Bytes}
+}
+
+// updateTopTalkers reads counters by source address and sets top sources of last second
+// to interfaces with top talkers tracking. Counters are read only if such interfaces are watched,
+// rates are calculated starting from second read.
+func (w *Watcher) updateTopTalkers(devWatchers []*netDevWatcher) {
+	netDevs := make(map[uint32]*netDevWatcher)
+	for _, devWatcher := range devWatchers {
+		if devWatcher.getPolicy().topTalkers > 0 {
+			netDevs[uint32(devWatcher.index())] = devWatcher //nolint:gosec
+		} else if devWatcher.getTopTalkers() != nil {
+			devWatcher.setTopTalkers(nil)
+		}
+	}
+	if len(netDevs) == 0 {
+		w.prevTalkers = nil
+
+		return
+	}
+	stats, err := w.ebpfProg.GetTalkerStat()
+	if err != nil {
+		w.log.Errorf("Error get top talkers statistic: %s", err.Error())
+
+		return
+	}
+	prevTalkers := w.prevTalkers
+	w.prevTalkers = stats
+	if prevTalkers == nil {
+		return
+	}
+	talkers := make(map[uint32][]events.Talker, len(netDevs))
+	for key, counter := range stats {
+		if _, ok := netDevs[key.Ifindex]; !ok {
+			continue
+		}
+		rate := talkerRate(counter, prevTalkers[key])
+		if rate.Packets == 0 {
+			continue
+		}
+		talkers[key.Ifindex] = append(talkers[key.Ifindex], events.Talker{
+			MAC:       net.HardwareAddr(key.Addr[:]).String(),
+			Rate:      rate.Packets,
+			RateBytes: rate.Bytes,
+		})
+	}
+	for index, devWatcher := range netDevs {
+		devWatcher.setTopTalkers(topTalkers(talkers[index], devWatcher.getPolicy().topTalkers))
+	}
+}
+
+// syncTalkerNetDevs writes set of interfaces with top talkers tracking to kernel map if it was changed
+func (w *Watcher) syncTalkerNetDevs() {
+	netDevs := w.netDevIndexes(func(devWatcher *netDevWatcher) bool {
+		return devWatcher.getPolicy().topTalkers > 0
+	})
+	if slices.Equal(netDevs, w.talkerNetDevs) {
+		return
+	}
+	if err := w.ebpfProg.UpdateTalkerNetDevs(netDevs); err != nil {
+		w.log.Errorf("Error update interfaces with top talkers tracking: %s", err.Error())
+
+		return
+	}
+	w.talkerNetDevs = netDevs
+}
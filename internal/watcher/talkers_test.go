@@ -0,0 +1,117 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
+	"github.com/mythvcode/storm-control/internal/watcher/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopTalkers(t *testing.T) {
+	talkers := []events.Talker{
+		{MAC: "fa:16:3e:00:00:03", Rate: 10},
+		{MAC: "fa:16:3e:00:00:02", Rate: 500},
+		{MAC: "fa:16:3e:00:00:04", Rate: 500},
+		{MAC: "fa:16:3e:00:00:01", Rate: 1},
+	}
+	require.Equal(t, []events.Talker{
+		{MAC: "fa:16:3e:00:00:02", Rate: 500},
+		{MAC: "fa:16:3e:00:00:04", Rate: 500},
+		{MAC: "fa:16:3e:00:00:03", Rate: 10},
+	}, topTalkers(talkers, 3))
+	require.Len(t, topTalkers(talkers, 10), 4)
+	require.Empty(t, topTalkers(nil, 3))
+}
+
+func TestTalkerRate(t *testing.T) {
+	require.Equal(t,
+		ebpfloader.TalkerCounter{Packets: 5, Bytes: 320},
+		talkerRate(ebpfloader.TalkerCounter{Packets: 15, Bytes: 960}, ebpfloader.TalkerCounter{Packets: 10, Bytes: 640}),
+	)
+	// source was evicted and counted again
+	require.Equal(t,
+		ebpfloader.TalkerCounter{Packets: 3, Bytes: 192},
+		talkerRate(ebpfloader.TalkerCounter{Packets: 3, Bytes: 192}, ebpfloader.TalkerCounter{Packets: 10, Bytes: 640}),
+	)
+}
+
+func TestScheduleTopTalkers(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := netDevPolicy{name: "default", blockEnabled: true, blockMode: dropMode, topTalkers: 2, limits: newUniformTrafficLimits(100, time.Hour)}
+	devWatcher := watcher.makeNetDevWatcher(1, "tap1", policy)
+	eventsMock := mocks.NewMockeventPublisher(t)
+	devWatcher.events = eventsMock
+	watcher.devWatcherMap[1] = devWatcher
+	otherWatcher := watcher.makeNetDevWatcher(2, "tap2", makeDefaultPolicy(watcher.config))
+	watcher.devWatcherMap[2] = otherWatcher
+	now := time.Now()
+
+	// rates are calculated starting from second read
+	ebpfMock.EXPECT().GetTalkerStat().Return(ebpfloader.TalkerStat{
+		{Ifindex: 1, Addr: [6]uint8{0xfa, 0x16, 0x3e, 0, 0, 1}}: {Packets: 10, Bytes: 640},
+		{Ifindex: 1, Addr: [6]uint8{0xfa, 0x16, 0x3e, 0, 0, 2}}: {Packets: 1000, Bytes: 64000},
+	}, nil).Once()
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	watcher.schedule(ebpfloader.CounterStat{1: {}}, now)
+	require.Nil(t, devWatcher.getTopTalkers())
+
+	ebpfMock.EXPECT().GetTalkerStat().Return(ebpfloader.TalkerStat{
+		{Ifindex: 1, Addr: [6]uint8{0xfa, 0x16, 0x3e, 0, 0, 1}}: {Packets: 15, Bytes: 960},
+		{Ifindex: 1, Addr: [6]uint8{0xfa, 0x16, 0x3e, 0, 0, 2}}: {Packets: 1000, Bytes: 64000},
+		// new sources are counted from zero
+		{Ifindex: 1, Addr: [6]uint8{0xfa, 0x16, 0x3e, 0, 0, 3}}: {Packets: 300, Bytes: 19200},
+		{Ifindex: 1, Addr: [6]uint8{0xfa, 0x16, 0x3e, 0, 0, 4}}: {Packets: 1, Bytes: 64},
+		// interface without tracking
+		{Ifindex: 2, Addr: [6]uint8{0xfa, 0x16, 0x3e, 0, 0, 5}}: {Packets: 500, Bytes: 32000},
+	}, nil).Once()
+	talkers := []events.Talker{
+		{MAC: "fa:16:3e:00:00:03", Rate: 300, RateBytes: 19200},
+		{MAC: "fa:16:3e:00:00:01", Rate: 5, RateBytes: 320},
+	}
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfgs(ebpfloader.DropConf{1: {Broadcast: ebpfloader.ActionDrop}}).Return(nil).Once()
+	eventsMock.EXPECT().Publish(events.Event{
+		Action:      events.ActionBlock,
+		Source:      events.SourceAuto,
+		Interface:   "tap1",
+		Index:       1,
+		TrafficType: "broadcast",
+		Rate:        321,
+		Threshold:   100,
+		TopTalkers:  talkers,
+		Duration:    time.Hour,
+	}).Once()
+	watcher.schedule(ebpfloader.CounterStat{1: {Broadcast: ebpfloader.TrafInfo{Passed: 321}}}, now.Add(time.Second))
+	require.Equal(t, talkers, devWatcher.getTopTalkers())
+	require.Equal(t, talkers, devWatcher.state().TopTalkers)
+	require.Nil(t, otherWatcher.getTopTalkers())
+
+	// counters are not read if tracking is disabled
+	require.NoError(t, devWatcher.updateSettings(makeDefaultPolicy(watcher.config), devWatcher.getUnblockCheck(), devWatcher.backoff.getConfig()))
+	watcher.schedule(ebpfloader.CounterStat{}, now.Add(2*time.Second))
+	require.Nil(t, devWatcher.getTopTalkers())
+	require.Nil(t, watcher.prevTalkers)
+}
+
+func TestSyncTalkerNetDevs(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := makeDefaultPolicy(watcher.config)
+	watcher.devWatcherMap[1] = watcher.makeNetDevWatcher(1, "tap1", policy)
+	// set is not written while top talkers are not tracked
+	watcher.syncTalkerNetDevs()
+
+	policy.topTalkers = 5
+	watcher.devWatcherMap[4] = watcher.makeNetDevWatcher(4, "tap4", policy)
+	watcher.devWatcherMap[2] = watcher.makeNetDevWatcher(2, "tap2", policy)
+	ebpfMock.EXPECT().UpdateTalkerNetDevs([]int{2, 4}).Return(nil).Once()
+	watcher.syncTalkerNetDevs()
+	watcher.syncTalkerNetDevs()
+
+	delete(watcher.devWatcherMap, 2)
+	delete(watcher.devWatcherMap, 4)
+	ebpfMock.EXPECT().UpdateTalkerNetDevs([]int(nil)).Return(nil).Once()
+	watcher.syncTalkerNetDevs()
+}
@@ -0,0 +1,128 @@
+package watcher
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+)
+
+// length of Ethernet address, other hardware addresses are not used by unicast check
+const macLen = 6
+
+// parseAllowedMACs parses allowed addresses of unicast check
+func parseAllowedMACs(macs []string) ([]net.HardwareAddr, error) {
+	result := make([]net.HardwareAddr, 0, len(macs))
+	for _, mac := range macs {
+		addr, err := net.ParseMAC(strings.TrimSpace(mac))
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed MAC address: %w", err)
+		}
+		if len(addr) != macLen {
+			return nil, fmt.Errorf("allowed MAC address %s is not Ethernet address", mac)
+		}
+		result = append(result, addr)
+	}
+
+	return result, nil
+}
+
+// fdbEntry is address of Linux bridge forwarding database
+type fdbEntry struct {
+	// index of bridge port, index of bridge for addresses of bridge itself
+	index int
+	// index of bridge
+	master int
+	addr   net.HardwareAddr
+	// local address of bridge or port, other entries are learned from traffic or added by user
+	permanent bool
+}
+
+// makeKnownMACs returns addresses of unicast check for watched ports of Linux bridges.
+// Addresses learned by bridge on port (guest addresses) and allowed addresses of port policy
+// are expected source addresses of port. All addresses of bridge and allowed addresses of its ports
+// are known destination addresses of bridge.
+func (w *Watcher) makeKnownMACs() (ebpfloader.KnownMACs, error) {
+	if !w.config.UnicastCheck.Enabled || len(w.devWatcherMap) == 0 {
+		return ebpfloader.KnownMACs{}, nil
+	}
+	entries, err := listFDB()
+	if err != nil {
+		return ebpfloader.KnownMACs{}, err
+	}
+	bridgeAddrs := make(map[int][]net.HardwareAddr)
+	bridges := make(map[int]int)
+	sources := make(map[int][]net.HardwareAddr)
+	for _, entry := range entries {
+		bridgeAddrs[entry.master] = append(bridgeAddrs[entry.master], entry.addr)
+		if _, ok := w.devWatcherMap[entry.index]; !ok || entry.index == entry.master {
+			continue
+		}
+		bridges[entry.index] = entry.master
+		// local address of port is address of host side of interface, not address of guest
+		if !entry.permanent {
+			sources[entry.index] = append(sources[entry.index], entry.addr)
+		}
+	}
+	result := ebpfloader.KnownMACs{
+		Bridges:      make(map[int]int, len(bridges)),
+		Sources:      make(map[int][]net.HardwareAddr, len(bridges)),
+		Destinations: make(map[int][]net.HardwareAddr),
+	}
+	for ndev, bridge := range bridges {
+		allowed := w.devWatcherMap[ndev].getPolicy().allowedMACs
+		if allowed == nil {
+			allowed = w.allowedMACs
+		}
+		// check is not enabled for interface without expected addresses
+		if len(sources[ndev]) == 0 && len(allowed) == 0 {
+			continue
+		}
+		result.Bridges[ndev] = bridge
+		result.Sources[ndev] = uniqueMACs(append(sources[ndev], allowed...))
+		if _, ok := result.Destinations[bridge]; !ok {
+			result.Destinations[bridge] = bridgeAddrs[bridge]
+		}
+		result.Destinations[bridge] = append(result.Destinations[bridge], allowed...)
+	}
+	for bridge, destinations := range result.Destinations {
+		result.Destinations[bridge] = uniqueMACs(destinations)
+	}
+
+	return result, nil
+}
+
+// uniqueMACs sorts addresses and removes duplicates, so equal sets of addresses are equal slices
+func uniqueMACs(addrs []net.HardwareAddr) []net.HardwareAddr {
+	result := slices.Clone(addrs)
+	slices.SortFunc(result, func(a, b net.HardwareAddr) int {
+		return bytes.Compare(a, b)
+	})
+
+	return slices.CompactFunc(result, func(a, b net.HardwareAddr) bool {
+		return bytes.Equal(a, b)
+	})
+}
+
+// syncKnownMACs writes addresses of unicast check to kernel map if they were changed
+func (w *Watcher) syncKnownMACs() {
+	known, err := w.makeKnownMACs()
+	if err != nil {
+		w.log.Errorf("Error get addresses of unicast check: %s", err.Error())
+
+		return
+	}
+	if reflect.DeepEqual(known, w.knownMACs) {
+		return
+	}
+	if err := w.ebpfProg.UpdateKnownMACs(known); err != nil {
+		w.log.Errorf("Error update addresses of unicast check: %s", err.Error())
+
+		return
+	}
+	w.knownMACs = known
+}
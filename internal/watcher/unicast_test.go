@@ -0,0 +1,158 @@
+package watcher
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAllowedMACs(t *testing.T) {
+	macs, err := parseAllowedMACs([]string{"fa:16:3e:00:00:01", " FA-16-3E-00-00-02"})
+	require.NoError(t, err)
+	require.Equal(t, []net.HardwareAddr{
+		{0xfa, 0x16, 0x3e, 0x00, 0x00, 0x01},
+		{0xfa, 0x16, 0x3e, 0x00, 0x00, 0x02},
+	}, macs)
+	_, err = parseAllowedMACs([]string{"fa:16:3e:00:00"})
+	require.Error(t, err)
+	// EUI-64 is not Ethernet address
+	_, err = parseAllowedMACs([]string{"02:00:5e:10:00:00:00:01"})
+	require.Error(t, err)
+	// config with invalid address is rejected
+	_, err = makeReloadRequest(config.WatcherConfig{
+		BlockMode:    dropMode,
+		AttachMode:   ebpfloader.AttachModeAuto,
+		UnicastCheck: config.UnicastCheckConfig{Enabled: true, AllowedMACs: []string{"invalid"}},
+	})
+	require.Error(t, err)
+
+	// global addresses are used by policy without allowed addresses
+	policyCfg := config.Policy{InterfaceName: "tap1"}
+	policy, err := makePolicy(config.WatcherConfig{}, policyCfg)
+	require.NoError(t, err)
+	require.Nil(t, policy.allowedMACs)
+	policyCfg.AllowedMACs = []string{}
+	policy, err = makePolicy(config.WatcherConfig{}, policyCfg)
+	require.NoError(t, err)
+	require.Equal(t, []net.HardwareAddr{}, policy.allowedMACs)
+	policyCfg.AllowedMACs = []string{"invalid"}
+	_, err = makePolicy(config.WatcherConfig{}, policyCfg)
+	require.Error(t, err)
+}
+
+var (
+	testBridgeMAC  = net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x10}
+	testGatewayMAC = net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0xfe}
+	testGuestMAC   = net.HardwareAddr{0xfa, 0x16, 0x3e, 0x00, 0x00, 0x01}
+	testTapMAC     = net.HardwareAddr{0xfe, 0x16, 0x3e, 0x00, 0x00, 0x01}
+	testSilentMAC  = net.HardwareAddr{0xfe, 0x16, 0x3e, 0x00, 0x00, 0x05}
+	testAllowedMAC = net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0xaa}
+)
+
+// setTestFDB sets forwarding database of bridge 10 with watched ports 1 and 5 and uplink 7,
+// bridge 30 has not watched port 20
+func setTestFDB(t *testing.T) {
+	t.Helper()
+	listFDB = func() ([]fdbEntry, error) {
+		return []fdbEntry{
+			{index: 10, master: 10, addr: testBridgeMAC, permanent: true},
+			{index: 1, master: 10, addr: testTapMAC, permanent: true},
+			{index: 1, master: 10, addr: testGuestMAC},
+			// same address in other VLAN
+			{index: 1, master: 10, addr: testGuestMAC},
+			{index: 5, master: 10, addr: testSilentMAC, permanent: true},
+			{index: 7, master: 10, addr: testGatewayMAC},
+			{index: 20, master: 30, addr: net.HardwareAddr{0xfa, 0x16, 0x3e, 0x00, 0x00, 0x20}},
+		}, nil
+	}
+	t.Cleanup(func() { listFDB = readBridgeFDB })
+}
+
+func TestMakeKnownMACs(t *testing.T) {
+	watcher, _ := makeTestWatcher(t)
+	setTestFDB(t)
+	watcher.config.UnicastCheck.Enabled = true
+	watcher.devWatcherMap[1] = watcher.makeNetDevWatcher(1, "tap1", makeDefaultPolicy(watcher.config))
+	watcher.devWatcherMap[5] = watcher.makeNetDevWatcher(5, "tap5", makeDefaultPolicy(watcher.config))
+	known, err := watcher.makeKnownMACs()
+	require.NoError(t, err)
+	// guest address learned by bridge is expected source, address of tap is not
+	require.Equal(t, map[int][]net.HardwareAddr{1: {testGuestMAC}}, known.Sources)
+	// check is not enabled for port without learned addresses
+	require.Equal(t, map[int]int{1: 10}, known.Bridges)
+	// gateway learned on uplink is known destination of bridge, addresses of other bridges are not used
+	require.Equal(t, map[int][]net.HardwareAddr{
+		10: {testBridgeMAC, testGatewayMAC, testGuestMAC, testTapMAC, testSilentMAC},
+	}, known.Destinations)
+	require.False(t, isUnknownUnicast(known, 1, testGuestMAC, testGatewayMAC))
+	require.False(t, isUnknownUnicast(known, 1, testGuestMAC, testBridgeMAC))
+	require.True(t, isUnknownUnicast(known, 1, testGuestMAC, testAllowedMAC))
+	// spoofed source
+	require.True(t, isUnknownUnicast(known, 1, testTapMAC, testGatewayMAC))
+	require.False(t, isUnknownUnicast(known, 5, testSilentMAC, testAllowedMAC))
+
+	// allowed addresses are expected sources of every port and known destinations of bridge
+	watcher.allowedMACs = []net.HardwareAddr{testAllowedMAC}
+	known, err = watcher.makeKnownMACs()
+	require.NoError(t, err)
+	require.Equal(t, map[int]int{1: 10, 5: 10}, known.Bridges)
+	require.Equal(t, map[int][]net.HardwareAddr{
+		1: {testAllowedMAC, testGuestMAC},
+		5: {testAllowedMAC},
+	}, known.Sources)
+	require.Contains(t, known.Destinations[10], testAllowedMAC)
+
+	// allowed addresses of policy replace global allowed addresses
+	policy := makeDefaultPolicy(watcher.config)
+	policy.allowedMACs = []net.HardwareAddr{}
+	watcher.devWatcherMap[5] = watcher.makeNetDevWatcher(5, "tap5", policy)
+	known, err = watcher.makeKnownMACs()
+	require.NoError(t, err)
+	require.Equal(t, map[int]int{1: 10}, known.Bridges)
+}
+
+// isUnknownUnicast checks frame by addresses of unicast check in the same way as kernel program
+func isUnknownUnicast(known ebpfloader.KnownMACs, ndev int, src, dst net.HardwareAddr) bool {
+	bridge, ok := known.Bridges[ndev]
+	if !ok {
+		return false
+	}
+	if !slices.ContainsFunc(known.Sources[ndev], func(addr net.HardwareAddr) bool { return bytes.Equal(addr, src) }) {
+		return true
+	}
+
+	return !slices.ContainsFunc(known.Destinations[bridge], func(addr net.HardwareAddr) bool { return bytes.Equal(addr, dst) })
+}
+
+func TestSyncKnownMACs(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	setTestFDB(t)
+	watcher.devWatcherMap[1] = watcher.makeNetDevWatcher(1, "tap1", makeDefaultPolicy(watcher.config))
+
+	// map is not changed while check is disabled
+	watcher.syncKnownMACs()
+
+	watcher.config.UnicastCheck.Enabled = true
+	ebpfMock.EXPECT().UpdateKnownMACs(mock.Anything).Return(nil).Once()
+	watcher.syncKnownMACs()
+	// not changed addresses are not written again
+	watcher.syncKnownMACs()
+
+	// addresses which are not read are not changed
+	listFDB = func() ([]fdbEntry, error) {
+		return nil, errors.New("fdb error")
+	}
+	watcher.syncKnownMACs()
+
+	watcher.config.UnicastCheck.Enabled = false
+	ebpfMock.EXPECT().UpdateKnownMACs(ebpfloader.KnownMACs{}).Return(nil).Once()
+	watcher.syncKnownMACs()
+	watcher.syncKnownMACs()
+}
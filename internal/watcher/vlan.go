@@ -0,0 +1,122 @@
+package watcher
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/logger"
+)
+
+// vlanAware checks that traffic of interface is evaluated and blocked by VLAN,
+// interface watcher only keeps manual overrides and rates of all VLANs
+func (n *netDevWatcher) vlanAware() bool {
+	return n.vlan == nil && n.getPolicy().vlanAware
+}
+
+// getDropCfg returns drop config of interface or VLAN
+func (n *netDevWatcher) getDropCfg() (ebpfloader.DropPKT, error) {
+	if n.vlan != nil {
+		return n.ebpfProg.GetVLANDropCfg(n.netDevIndex, *n.vlan)
+	}
+
+	return n.ebpfProg.GetDevDropCfg(n.netDevIndex)
+}
+
+// setDropCfg writes drop config of interface or VLAN
+func (n *netDevWatcher) setDropCfg(cfg ebpfloader.DropPKT) error {
+	if n.vlan != nil {
+		return n.ebpfProg.UpdateVLANDropCfg(n.netDevIndex, *n.vlan, cfg)
+	}
+
+	return n.ebpfProg.UpdateDevDropCfg(n.netDevIndex, cfg)
+}
+
+// vlanWatchers returns watchers of all seen VLANs
+func (n *netDevWatcher) vlanWatchers() []*netDevWatcher {
+	n.vlansMux.Lock()
+	defer n.vlansMux.Unlock()
+	result := make([]*netDevWatcher, 0, len(n.vlans))
+	for _, vlanWatcher := range n.vlans {
+		result = append(result, vlanWatcher)
+	}
+
+	return result
+}
+
+// vlanWatcher returns watcher of VLAN with settings of interface, watcher is created on first call for VLAN.
+// In rate limit mode kernel rate limits of new VLAN are configured. Nil is returned for stopped interface watcher.
+func (n *netDevWatcher) vlanWatcher(vlan uint16) *netDevWatcher {
+	n.vlansMux.Lock()
+	defer n.vlansMux.Unlock()
+	if result, ok := n.vlans[vlan]; ok {
+		return result
+	}
+	n.dropMapMux.Lock()
+	stopped := n.stopped
+	n.dropMapMux.Unlock()
+	if stopped {
+		return nil
+	}
+	policy := n.getPolicy()
+	result := newNetDevWatcher(n.netDevIndex, n.netDevName, policy.limits, n.getUnblockCheck(), n.backoff.getConfig(), n.ebpfProg)
+	result.policy = policy
+	result.vlan = &vlan
+	result.parent = n
+	result.events = n.events
+	result.log = n.log.With(slog.Int(logger.VLAN, int(vlan)))
+	n.vlans[vlan] = result
+	n.log.Infof("Start watch VLAN %d of interface %s", vlan, n.devInfo())
+	if policy.blockEnabled && policy.blockMode == rateLimitMode {
+		if err := result.applyRateLimits(); err != nil {
+			result.log.Errorf("Error set rate limits for %s: %s", result.devInfo(), err.Error())
+		}
+	}
+
+	return result
+}
+
+// evaluateVLANs evaluates VLANs of VLAN aware interfaces by index,
+// counters of VLANs are read only if VLAN aware interfaces are watched
+func (w *Watcher) evaluateVLANs(netDevs map[uint32]*netDevWatcher, now time.Time) []dropUpdate {
+	if len(netDevs) == 0 {
+		return nil
+	}
+	stats, err := w.ebpfProg.GetVLANCounterStat()
+	if err != nil {
+		w.log.Errorf("Error get VLAN statistic: %s", err.Error())
+
+		return nil
+	}
+	var result []dropUpdate
+	for key, counters := range stats {
+		devWatcher, ok := netDevs[key.Ifindex]
+		if !ok {
+			continue
+		}
+		vlanWatcher := devWatcher.vlanWatcher(key.VLAN)
+		if vlanWatcher == nil {
+			continue
+		}
+		if update := vlanWatcher.evaluate(counters, now); !update.isEmpty() {
+			result = append(result, dropUpdate{devWatcher: vlanWatcher, update: update})
+		}
+	}
+
+	return result
+}
+
+// syncVLANNetDevs writes set of VLAN aware interfaces to kernel map if it was changed
+func (w *Watcher) syncVLANNetDevs() {
+	netDevs := w.netDevIndexes((*netDevWatcher).vlanAware)
+	if slices.Equal(netDevs, w.vlanNetDevs) {
+		return
+	}
+	if err := w.ebpfProg.UpdateVLANNetDevs(netDevs); err != nil {
+		w.log.Errorf("Error update VLAN aware interfaces: %s", err.Error())
+
+		return
+	}
+	w.vlanNetDevs = netDevs
+}
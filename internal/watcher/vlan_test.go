@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
+	"github.com/mythvcode/storm-control/internal/watcher/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleVLAN(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := netDevPolicy{name: "trunk", blockEnabled: true, blockMode: dropMode, vlanAware: true, limits: newUniformTrafficLimits(10, time.Hour)}
+	devWatcher := watcher.makeNetDevWatcher(1, "trunk1", policy)
+	eventsMock := mocks.NewMockeventPublisher(t)
+	devWatcher.events = eventsMock
+	watcher.devWatcherMap[1] = devWatcher
+	now := time.Now()
+	ebpfMock.EXPECT().GetVLANCounterStat().Return(ebpfloader.VLANCounterStat{
+		{Ifindex: 1, VLAN: 10}: {},
+		{Ifindex: 1, VLAN: 20}: {},
+		// not watched interface
+		{Ifindex: 7, VLAN: 10}: {},
+	}, nil).Once()
+	ebpfMock.EXPECT().GetVLANDropCfg(1, uint16(10)).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().GetVLANDropCfg(1, uint16(20)).Return(ebpfloader.DropPKT{}, nil).Once()
+	watcher.schedule(ebpfloader.CounterStat{1: {}}, now)
+	require.Len(t, devWatcher.vlanWatchers(), 2)
+
+	// interface is not blocked by traffic of all VLANs, only storming VLAN is blocked
+	vlan := uint16(10)
+	ebpfMock.EXPECT().GetVLANCounterStat().Return(ebpfloader.VLANCounterStat{
+		{Ifindex: 1, VLAN: 10}: {Broadcast: ebpfloader.TrafInfo{Passed: 100}},
+		{Ifindex: 1, VLAN: 20}: {Broadcast: ebpfloader.TrafInfo{Passed: 5}},
+	}, nil).Once()
+	ebpfMock.EXPECT().GetVLANDropCfg(1, vlan).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().UpdateVLANDropCfg(1, vlan, ebpfloader.DropPKT{Broadcast: ebpfloader.ActionDrop}).Return(nil).Once()
+	eventsMock.EXPECT().Publish(events.Event{
+		Action:      events.ActionBlock,
+		Source:      events.SourceAuto,
+		Interface:   "trunk1",
+		Index:       1,
+		VLAN:        &vlan,
+		TrafficType: "broadcast",
+		Rate:        100,
+		Threshold:   10,
+		Duration:    time.Hour,
+	}).Once()
+	watcher.schedule(ebpfloader.CounterStat{1: {Broadcast: ebpfloader.TrafInfo{Passed: 105}}}, now.Add(time.Second))
+	require.Empty(t, devWatcher.sched.blocks)
+	require.Contains(t, devWatcher.vlanWatcher(10).sched.blocks, broadcastType)
+	require.Empty(t, devWatcher.vlanWatcher(20).sched.blocks)
+
+	// VLANs of stopped interface are not evaluated
+	devWatcher.stop()
+	require.Nil(t, devWatcher.vlanWatcher(30))
+}
+
+func TestVLANRateLimits(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := netDevPolicy{name: "trunk", blockEnabled: true, blockMode: rateLimitMode, vlanAware: true, limits: newUniformTrafficLimits(10, time.Hour)}
+	devWatcher := watcher.makeNetDevWatcher(1, "trunk1", policy)
+	// traffic of interface is not limited, manual block is applied to interface
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{ARP: ebpfloader.ActionDrop}).Return(nil).Once()
+	require.NoError(t, devWatcher.manualBlock(arpType, 0))
+
+	// every VLAN is limited by interface limits
+	var vlanCfg ebpfloader.DropPKT
+	ebpfMock.EXPECT().GetVLANDropCfg(1, uint16(10)).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().UpdateVLANDropCfg(1, uint16(10), mock.Anything).RunAndReturn(
+		func(_ int, _ uint16, cfg ebpfloader.DropPKT) error {
+			vlanCfg = cfg
+
+			return nil
+		},
+	).Once()
+	require.NotNil(t, devWatcher.vlanWatcher(10))
+	require.Equal(t, ebpfloader.ActionRateLimit, vlanCfg.Broadcast)
+	require.Equal(t, ebpfloader.RateLimit{Rate: 10}, vlanCfg.BroadcastRate)
+	// manual block of interface is followed by VLAN
+	require.Equal(t, ebpfloader.ActionDrop, vlanCfg.ARP)
+
+	// manual unblock of interface passes traffic of VLAN without kernel rate limit
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{ARP: ebpfloader.ActionDrop}, nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{ARP: ebpfloader.ActionDrop}).Return(nil).Once()
+	ebpfMock.EXPECT().GetVLANDropCfg(1, uint16(10)).Return(vlanCfg, nil).Once()
+	ebpfMock.EXPECT().UpdateVLANDropCfg(1, uint16(10), mock.Anything).RunAndReturn(
+		func(_ int, _ uint16, cfg ebpfloader.DropPKT) error {
+			vlanCfg = cfg
+
+			return nil
+		},
+	).Once()
+	require.NoError(t, devWatcher.manualUnblock(broadcastType, time.Hour))
+	require.Equal(t, ebpfloader.ActionPass, vlanCfg.Broadcast)
+	require.Equal(t, ebpfloader.RateLimit{}, vlanCfg.BroadcastRate)
+	require.Equal(t, ebpfloader.ActionDrop, vlanCfg.ARP)
+}
+
+func TestManualUnblockVLAN(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := netDevPolicy{name: "trunk", blockEnabled: true, blockMode: dropMode, vlanAware: true, limits: newUniformTrafficLimits(10, time.Hour)}
+	devWatcher := watcher.makeNetDevWatcher(1, "trunk1", policy)
+	vlanWatcher := devWatcher.vlanWatcher(10)
+	vlanWatcher.sched.blocks[broadcastType] = &blockState{checkAt: time.Now().Add(time.Hour)}
+
+	// block of VLAN is removed by manual unblock of interface
+	ebpfMock.EXPECT().GetDevDropCfg(1).Return(ebpfloader.DropPKT{}, nil).Once()
+	ebpfMock.EXPECT().UpdateDevDropCfg(1, ebpfloader.DropPKT{}).Return(nil).Once()
+	ebpfMock.EXPECT().GetVLANDropCfg(1, uint16(10)).Return(ebpfloader.DropPKT{
+		Broadcast: ebpfloader.ActionDrop,
+		ARP:       ebpfloader.ActionDrop,
+	}, nil).Once()
+	ebpfMock.EXPECT().UpdateVLANDropCfg(1, uint16(10), ebpfloader.DropPKT{ARP: ebpfloader.ActionDrop}).Return(nil).Once()
+	require.NoError(t, devWatcher.manualUnblock(broadcastType, time.Hour))
+
+	// VLAN does not block or unblock manually controlled traffic
+	require.True(t, vlanWatcher.overridden(broadcastType))
+	require.Empty(t, vlanWatcher.checkUnblock(&ebpfloader.PacketCounter{}, time.Now()))
+	require.NotContains(t, vlanWatcher.sched.blocks, broadcastType)
+	require.Empty(t, vlanWatcher.calculateBlocks(&ebpfloader.PacketCounter{}, &ebpfloader.PacketCounter{
+		Broadcast: ebpfloader.TrafInfo{Passed: 100},
+	}))
+}
+
+func TestSyncVLANNetDevs(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policy := makeDefaultPolicy(watcher.config)
+	watcher.devWatcherMap[1] = watcher.makeNetDevWatcher(1, "tap1", policy)
+	// set is not written while there are no VLAN aware interfaces
+	watcher.syncVLANNetDevs()
+
+	policy.vlanAware = true
+	watcher.devWatcherMap[5] = watcher.makeNetDevWatcher(5, "trunk5", policy)
+	watcher.devWatcherMap[3] = watcher.makeNetDevWatcher(3, "trunk3", policy)
+	ebpfMock.EXPECT().UpdateVLANNetDevs([]int{3, 5}).Return(nil).Once()
+	watcher.syncVLANNetDevs()
+	watcher.syncVLANNetDevs()
+
+	delete(watcher.devWatcherMap, 3)
+	delete(watcher.devWatcherMap, 5)
+	ebpfMock.EXPECT().UpdateVLANNetDevs([]int(nil)).Return(nil).Once()
+	watcher.syncVLANNetDevs()
+}
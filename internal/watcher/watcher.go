@@ -1,35 +1,127 @@
 package watcher
 
 import (
+	"fmt"
 	"log/slog"
 	"net"
 	"regexp"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mythvcode/storm-control/internal/config"
 	"github.com/mythvcode/storm-control/internal/ebpfloader"
+	"github.com/mythvcode/storm-control/internal/events"
 	"github.com/mythvcode/storm-control/internal/logger"
 )
 
 type eBPFProg interface {
-	AttachXDP(devIndex int) error
+	AttachXDP(devIndex int, mode string) (string, error)
 	DetachXDP(ndev int) error
 	ForceDetachXDP(devIndex int)
-	GetDevStat(devIndex int) (ebpfloader.PacketCounter, error)
+	AttachedNetDevs() []int
+	GetCounterStat() (ebpfloader.CounterStat, error)
 	GetDevDropCfg(devIndex int) (ebpfloader.DropPKT, error)
 	UpdateDevDropCfg(devIndex int, cfg ebpfloader.DropPKT) error
+	UpdateDevDropCfgs(cfgs ebpfloader.DropConf) error
+	UpdateKnownMACs(known ebpfloader.KnownMACs) error
+	UpdateVLANNetDevs(netDevs []int) error
+	GetVLANCounterStat() (ebpfloader.VLANCounterStat, error)
+	GetVLANDropCfg(devIndex int, vlan uint16) (ebpfloader.DropPKT, error)
+	UpdateVLANDropCfg(devIndex int, vlan uint16, cfg ebpfloader.DropPKT) error
+	UpdateTalkerNetDevs(netDevs []int) error
+	GetTalkerStat() (ebpfloader.TalkerStat, error)
 	Close()
 }
+
+// eventPublisher delivers block and unblock events to notification sinks
+type eventPublisher interface {
+	Publish(event events.Event)
+}
+
+const (
+	linkEventsBuffer      = 1024
+	defaultResyncInterval = time.Minute
+	defaultLearnInterval  = 5 * time.Second
+)
+
+const (
+	linkAdded = iota + 1
+	linkRemoved
+	linkResync
+)
+
+type linkEvent struct {
+	eventType int
+	index     int
+	name      string
+}
+
+// NetDevState describes state of watched interface
+type NetDevState struct {
+	Index     int    `json:"index"`
+	Name      string `json:"name"`
+	Policy    string `json:"policy"`
+	BlockMode string `json:"block_mode"`
+	// block decisions are made but not applied
+	DryRun bool `json:"dry_run"`
+	// block duration backoff level by traffic type
+	BackoffLevels map[string]int `json:"backoff_levels"`
+	// manual overrides by traffic type
+	Overrides map[string]OverrideState `json:"overrides"`
+	// sources with highest broadcast and multicast rate of last second, set if top talkers are tracked
+	TopTalkers []events.Talker `json:"top_talkers,omitempty"`
+}
+
 type Watcher struct {
+	// devWatcherMap is modified only by watcher goroutine,
+	// devMux protects it from concurrent reads by other components
+	devMux        sync.RWMutex
 	devWatcherMap map[int]*netDevWatcher
-	ebpfProg      eBPFProg
-	config        config.WatcherConfig
-	closed        chan struct{}
-	netDevReg     *regexp.Regexp
-	log           *logger.Logger
+	// netDevNames is copy of interface names of devWatcherMap by index,
+	// it is replaced on every change of devWatcherMap and read without locks
+	netDevNames atomic.Pointer[map[int]string]
+	ebpfProg    eBPFProg
+	events      eventPublisher
+	config      config.WatcherConfig
+	policies    []netDevPolicy
+	closed      chan struct{}
+	netDevReg   *regexp.Regexp
+	// program is pinned to bpffs and stays attached after stop
+	keepAttached bool
+	reloadChan   chan reloadRequest
+	attachChan   chan attachRequest
+	// interfaces attached or detached manually regardless of device list and regexp,
+	// modified by watcher goroutine under devMux
+	attachOverrides map[string]bool
+	// allowed addresses of unicast check and content of known MACs map after last sync,
+	// used only by watcher goroutine
+	allowedMACs []net.HardwareAddr
+	knownMACs   ebpfloader.KnownMACs
+	// sorted indexes of VLAN aware interfaces after last sync, used only by watcher goroutine
+	vlanNetDevs []int
+	// sorted indexes of interfaces with top talkers tracking after last sync, used only by watcher goroutine
+	talkerNetDevs []int
+	// counters by source address of previous read, used only by scheduler
+	prevTalkers ebpfloader.TalkerStat
+	log         *logger.Logger
+}
+
+// reloadRequest is validated watcher settings applied by watcher goroutine
+type reloadRequest struct {
+	config      config.WatcherConfig
+	netDevReg   *regexp.Regexp
+	policies    []netDevPolicy
+	allowedMACs []net.HardwareAddr
+	done        chan struct{}
 }
 
-var listInterfaces = net.Interfaces
+var (
+	listInterfaces      = net.Interfaces
+	subscribeLinkEvents = subscribeNetlink
+	listFDB             = readBridgeFDB
+)
 
 func isDevExist(devLis []net.Interface, index int) bool {
 	for _, dev := range devLis {
@@ -41,43 +133,105 @@ func isDevExist(devLis []net.Interface, index int) bool {
 	return false
 }
 
-func New(cfg config.StormControlConfig, prog eBPFProg) (*Watcher, error) {
-	regExp, err := regexp.Compile(cfg.Watcher.DevRegEx)
+// makeReloadRequest validates watcher config
+func makeReloadRequest(cfg config.WatcherConfig) (reloadRequest, error) {
+	regExp, err := regexp.Compile(cfg.DevRegEx)
+	if err != nil {
+		return reloadRequest{}, err
+	}
+	if err := checkBlockMode(cfg.BlockMode); err != nil {
+		return reloadRequest{}, err
+	}
+	if err := ebpfloader.CheckAttachMode(cfg.AttachMode); err != nil {
+		return reloadRequest{}, err
+	}
+	if cfg.TopTalkers.Enabled && cfg.TopTalkers.Count < 1 {
+		return reloadRequest{}, fmt.Errorf("top talkers count %d must be positive", cfg.TopTalkers.Count)
+	}
+	policies, err := makePolicies(cfg)
+	if err != nil {
+		return reloadRequest{}, err
+	}
+	allowedMACs, err := parseAllowedMACs(cfg.UnicastCheck.AllowedMACs)
+	if err != nil {
+		return reloadRequest{}, err
+	}
+
+	return reloadRequest{
+		config:      cfg,
+		netDevReg:   regExp,
+		policies:    policies,
+		allowedMACs: allowedMACs,
+		done:        make(chan struct{}),
+	}, nil
+}
+
+func New(cfg config.StormControlConfig, prog eBPFProg, publisher eventPublisher) (*Watcher, error) {
+	settings, err := makeReloadRequest(cfg.Watcher)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Watcher{
-		devWatcherMap: make(map[int]*netDevWatcher),
-		ebpfProg:      prog,
-		config:        cfg.Watcher,
-		netDevReg:     regExp,
-		closed:        make(chan struct{}),
-		log:           logger.GetLogger().With(slog.String(logger.Component, "Watcher")),
+		devWatcherMap:   make(map[int]*netDevWatcher),
+		ebpfProg:        prog,
+		events:          publisher,
+		config:          settings.config,
+		policies:        settings.policies,
+		allowedMACs:     settings.allowedMACs,
+		netDevReg:       settings.netDevReg,
+		closed:          make(chan struct{}),
+		keepAttached:    cfg.EBPF.Pin,
+		reloadChan:      make(chan reloadRequest),
+		attachChan:      make(chan attachRequest),
+		attachOverrides: make(map[string]bool),
+		log:             logger.GetLogger().With(slog.String(logger.Component, "Watcher")),
 	}, nil
 }
 
-func (w *Watcher) makeNetDevWatcher(netDev int, netDevName string) *netDevWatcher {
-	return newNetDevWatcher(
+// findPolicy returns first matched policy for interface or default policy
+func (w *Watcher) findPolicy(netDevIndex int, netDevName string) netDevPolicy {
+	for _, policy := range w.policies {
+		if policy.match(netDevIndex, netDevName) {
+			return policy
+		}
+	}
+
+	return makeDefaultPolicy(w.config)
+}
+
+func (w *Watcher) makeNetDevWatcher(netDev int, netDevName string, policy netDevPolicy) *netDevWatcher {
+	result := newNetDevWatcher(
 		netDev,
 		netDevName,
-		w.config.BlockThreshold,
-		time.Duration(w.config.BlockDelay)*time.Second,
+		policy.limits,
+		makeUnblockCheck(w.config.Unblock),
+		makeBackoffConfig(w.config.Backoff),
 		w.ebpfProg,
 	)
+	result.policy = policy
+	result.events = w.events
+	if policy.dryRun {
+		result.log = result.log.With(slog.Bool(logger.DryRun, true))
+	}
+
+	return result
 }
 
-func (w *Watcher) findStaticNetDevices(allNetDevices []net.Interface) []net.Interface {
-	result := make([]net.Interface, 0, 1)
-	for _, netDev := range allNetDevices {
-		for _, staticNetDevName := range w.config.StaticDevList {
-			if netDev.Name == staticNetDevName {
-				result = append(result, netDev)
-			}
+func (w *Watcher) matchNetDev(netDevName string) bool {
+	if attach, ok := w.attachOverrides[netDevName]; ok {
+		return attach
+	}
+	if len(w.config.StaticDevList) == 0 {
+		return w.netDevReg.MatchString(netDevName)
+	}
+	for _, staticNetDevName := range w.config.StaticDevList {
+		if netDevName == staticNetDevName {
+			return true
 		}
 	}
 
-	return result
+	return false
 }
 
 func (w *Watcher) getNetDevicesForAttach() ([]net.Interface, error) {
@@ -86,19 +240,84 @@ func (w *Watcher) getNetDevicesForAttach() ([]net.Interface, error) {
 	if err != nil {
 		return result, err
 	}
-	if len(w.config.StaticDevList) == 0 {
-		for _, netDev := range allNetDevs {
-			if w.netDevReg.MatchString(netDev.Name) {
-				result = append(result, netDev)
-			}
+	for _, netDev := range allNetDevs {
+		if w.matchNetDev(netDev.Name) {
+			result = append(result, netDev)
 		}
-	} else {
-		result = w.findStaticNetDevices(allNetDevs)
 	}
 
 	return result, nil
 }
 
+func (w *Watcher) attachNetDev(netDevIndex int, netDevName string) {
+	if _, ok := w.devWatcherMap[netDevIndex]; ok {
+		return
+	}
+	policy := w.findPolicy(netDevIndex, netDevName)
+	attachMode, err := w.ebpfProg.AttachXDP(netDevIndex, policy.attachMode)
+	if err != nil {
+		w.log.Errorf("Error attach program to device %d %s %s", netDevIndex, netDevName, err.Error())
+
+		return
+	}
+	w.log.Infof("Attach program to %s (%d), policy %s, xdp mode %s, dry run %t, vlan aware %t", netDevName, netDevIndex, policy.name, attachMode, policy.dryRun, policy.vlanAware)
+	nDevWatcher := w.makeNetDevWatcher(netDevIndex, netDevName, policy)
+	w.devMux.Lock()
+	w.devWatcherMap[netDevIndex] = nDevWatcher
+	w.updateNetDevNames()
+	w.devMux.Unlock()
+	// in rate limit mode kernel program drops traffic above threshold by itself,
+	// in drop mode traffic is blocked by scheduler
+	if policy.blockEnabled && policy.blockMode == rateLimitMode {
+		if err := nDevWatcher.applyRateLimits(); err != nil {
+			w.log.Errorf("Error set rate limits for device %s: %s", nDevWatcher.devInfo(), err.Error())
+		}
+	}
+}
+
+func (w *Watcher) detachNetDev(devWatcher *netDevWatcher) {
+	devWatcher.stop()
+	w.devMux.Lock()
+	delete(w.devWatcherMap, devWatcher.index())
+	w.updateNetDevNames()
+	w.devMux.Unlock()
+	if err := w.ebpfProg.DetachXDP(devWatcher.index()); err != nil {
+		w.log.Errorf("Error detach xdp program from interface %s: %s", devWatcher.netDevName, err.Error())
+		w.ebpfProg.ForceDetachXDP(devWatcher.index())
+	}
+}
+
+// netDevIndexes returns sorted indexes of watched interfaces matched by filter
+func (w *Watcher) netDevIndexes(filter func(devWatcher *netDevWatcher) bool) []int {
+	var result []int
+	for index, devWatcher := range w.devWatcherMap {
+		if filter(devWatcher) {
+			result = append(result, index)
+		}
+	}
+	slices.Sort(result)
+
+	return result
+}
+
+// updateNetDevNames replaces cache of interface names, devMux must be locked
+func (w *Watcher) updateNetDevNames() {
+	names := make(map[int]string, len(w.devWatcherMap))
+	for index, devWatcher := range w.devWatcherMap {
+		names[index] = devWatcher.netDevName
+	}
+	w.netDevNames.Store(&names)
+}
+
+// NetDevNames returns names of watched interfaces by index, result must not be modified
+func (w *Watcher) NetDevNames() map[int]string {
+	if names := w.netDevNames.Load(); names != nil {
+		return *names
+	}
+
+	return nil
+}
+
 func (w *Watcher) findAndAttachNetDev() {
 	netDevices, err := w.getNetDevicesForAttach()
 	if err != nil {
@@ -107,20 +326,7 @@ func (w *Watcher) findAndAttachNetDev() {
 		return
 	}
 	for _, nDev := range netDevices {
-		if _, ok := w.devWatcherMap[nDev.Index]; !ok {
-			w.log.Infof("Attach program to %s (%d)", nDev.Name, nDev.Index)
-			if err := w.ebpfProg.AttachXDP(nDev.Index); err != nil {
-				w.log.Errorf("Error attach program to device %d %s %s", nDev.Index, nDev.Name, err.Error())
-
-				continue
-			}
-			nDevWatcher := w.makeNetDevWatcher(nDev.Index, nDev.Name)
-			w.devWatcherMap[nDev.Index] = nDevWatcher
-			// do not start net device watcher process in case drop action disabled
-			if w.config.BlockEnabled {
-				go nDevWatcher.startWatching()
-			}
-		}
+		w.attachNetDev(nDev.Index, nDev.Name)
 	}
 }
 
@@ -134,35 +340,185 @@ func (w *Watcher) cleanNetDev() {
 	for _, devWatcher := range w.devWatcherMap {
 		if !isDevExist(allNetDev, devWatcher.index()) {
 			w.log.Infof("Interface %s not found stop watch process", devWatcher.devInfo())
-			devWatcher.stop()
-			delete(w.devWatcherMap, devWatcher.index())
-			if err := w.ebpfProg.DetachXDP(devWatcher.index()); err != nil {
-				w.log.Errorf("Error detach xdp program from interface %s: %s", devWatcher.netDevName, err.Error())
-				w.ebpfProg.ForceDetachXDP(devWatcher.index())
-			}
+			w.detachNetDev(devWatcher)
+		} else if !w.matchNetDev(devWatcher.netDevName) {
+			w.log.Infof("Interface %s does not match config anymore, stop watch process", devWatcher.devInfo())
+			w.detachNetDev(devWatcher)
+		}
+	}
+}
+
+// detachUnwatched detaches program from interfaces which were attached by previous
+// instance of program (pinned links) but are not watched anymore
+func (w *Watcher) detachUnwatched() {
+	for _, netDevIndex := range w.ebpfProg.AttachedNetDevs() {
+		if _, ok := w.devWatcherMap[netDevIndex]; ok {
+			continue
+		}
+		w.log.Infof("Detach program from not watched interface %d", netDevIndex)
+		if err := w.ebpfProg.DetachXDP(netDevIndex); err != nil {
+			w.log.Errorf("Error detach xdp program from interface %d: %s", netDevIndex, err.Error())
+			w.ebpfProg.ForceDetachXDP(netDevIndex)
 		}
 	}
 }
 
+// needReattach checks that policy change can not be applied to running watcher
+func needReattach(oldPolicy, newPolicy netDevPolicy) bool {
+	return oldPolicy.blockEnabled != newPolicy.blockEnabled ||
+		oldPolicy.blockMode != newPolicy.blockMode ||
+		oldPolicy.dryRun != newPolicy.dryRun ||
+		oldPolicy.vlanAware != newPolicy.vlanAware ||
+		oldPolicy.attachMode != newPolicy.attachMode
+}
+
+// applyReload applies new settings to watched interfaces and attaches/detaches
+// interfaces according to new device list or regexp
+func (w *Watcher) applyReload(req reloadRequest) {
+	w.config = req.config
+	w.netDevReg = req.netDevReg
+	w.policies = req.policies
+	w.allowedMACs = req.allowedMACs
+	devWatchers := make([]*netDevWatcher, 0, len(w.devWatcherMap))
+	for _, devWatcher := range w.devWatcherMap {
+		devWatchers = append(devWatchers, devWatcher)
+	}
+	for _, devWatcher := range devWatchers {
+		// not matched interfaces are detached by resync
+		if !w.matchNetDev(devWatcher.netDevName) {
+			continue
+		}
+		policy := w.findPolicy(devWatcher.index(), devWatcher.netDevName)
+		if needReattach(devWatcher.getPolicy(), policy) {
+			w.log.Infof("Block or attach mode of %s changed, reattach program", devWatcher.devInfo())
+			w.detachNetDev(devWatcher)
+			w.attachNetDev(devWatcher.index(), devWatcher.netDevName)
+
+			continue
+		}
+		err := devWatcher.updateSettings(policy, makeUnblockCheck(w.config.Unblock), makeBackoffConfig(w.config.Backoff))
+		if err != nil {
+			w.log.Errorf("Error apply new settings to %s: %s", devWatcher.devInfo(), err.Error())
+		}
+	}
+	w.resync()
+}
+
+// Reload validates config and applies it to running watcher.
+// Invalid config is rejected and current config is kept.
+func (w *Watcher) Reload(cfg config.StormControlConfig) error {
+	req, err := makeReloadRequest(cfg.Watcher)
+	if err != nil {
+		return err
+	}
+	select {
+	case w.reloadChan <- req:
+	case <-w.closed:
+		return ErrWatcherStopped
+	}
+	select {
+	case <-req.done:
+	case <-w.closed:
+	}
+
+	return nil
+}
+
+// resync makes full comparison of host interfaces with attached interfaces
+func (w *Watcher) resync() {
+	w.findAndAttachNetDev()
+	w.cleanNetDev()
+}
+
+func (w *Watcher) handleLinkEvent(event linkEvent) {
+	switch event.eventType {
+	case linkAdded:
+		if w.matchNetDev(event.name) {
+			w.attachNetDev(event.index, event.name)
+		}
+	case linkRemoved:
+		if devWatcher, ok := w.devWatcherMap[event.index]; ok {
+			w.log.Infof("Interface %s removed stop watch process", devWatcher.devInfo())
+			w.detachNetDev(devWatcher)
+		}
+	case linkResync:
+		w.log.Warningf("Netlink notifications lost, resync interfaces")
+		w.resync()
+	}
+}
+
+// learnInterval returns interval of reading addresses of unicast check from bridge forwarding database
+func (w *Watcher) learnInterval() time.Duration {
+	if w.config.UnicastCheck.LearnInterval <= 0 {
+		return defaultLearnInterval
+	}
+
+	return time.Duration(w.config.UnicastCheck.LearnInterval) * time.Second
+}
+
+// startDynamicWatcher attaches and detaches interfaces on netlink notifications.
+// Full resync is done periodically in case some notifications were lost.
+// If netlink subscription is not available, interfaces are polled every second.
+// Addresses of unicast check, VLAN aware interfaces and interfaces with top talkers tracking
+// are synced after every change of watched interfaces, addresses learned by bridges are synced every learn interval.
 func (w *Watcher) startDynamicWatcher() {
-	ticker := time.NewTicker(time.Second)
+	resyncInterval := time.Duration(w.config.ResyncInterval) * time.Second
+	if resyncInterval <= 0 {
+		resyncInterval = defaultResyncInterval
+	}
+	linkEvents, err := subscribeLinkEvents(w.closed)
+	if err != nil {
+		w.log.Warningf("Error subscribe to netlink notifications, fallback to polling: %s", err.Error())
+		resyncInterval = time.Second
+	}
+	w.resync()
+	w.detachUnwatched()
+	w.syncKnownMACs()
+	w.syncVLANNetDevs()
+	w.syncTalkerNetDevs()
+
+	ticker := time.NewTicker(resyncInterval)
 	defer ticker.Stop()
+	learnTicker := time.NewTicker(w.learnInterval())
+	defer learnTicker.Stop()
 	for {
 		select {
 		case <-w.closed:
 			return
 		case <-ticker.C:
-			w.findAndAttachNetDev()
-			w.cleanNetDev()
+			w.resync()
+		case <-learnTicker.C:
+		case req := <-w.reloadChan:
+			w.log.Infof("Apply new configuration")
+			w.applyReload(req)
+			learnTicker.Reset(w.learnInterval())
+			close(req.done)
+		case req := <-w.attachChan:
+			req.done <- w.applyAttach(req)
+		case event, ok := <-linkEvents:
+			if !ok {
+				w.log.Warningf("Netlink subscription closed, fallback to polling")
+				linkEvents = nil
+				ticker.Reset(time.Second)
+
+				continue
+			}
+			w.handleLinkEvent(event)
 		}
+		w.syncKnownMACs()
+		w.syncVLANNetDevs()
+		w.syncTalkerNetDevs()
 	}
 }
 
 func (w *Watcher) Start() {
 	w.log.Infof("Start device watcher")
-	if !w.config.BlockEnabled {
+	if w.config.DryRun {
+		w.log.Warningf("Dry run mode, block decisions are not applied!")
+	} else if !w.config.BlockEnabled {
 		w.log.Warningf("Block action disabled!")
 	}
+	go w.runScheduler()
 	w.startDynamicWatcher()
 }
 
@@ -176,9 +532,37 @@ func (w *Watcher) Stop() {
 func (w *Watcher) stopDevWatchers() {
 	for _, devWatcher := range w.devWatcherMap {
 		devWatcher.stop()
+		if w.keepAttached {
+			continue
+		}
 		if err := w.ebpfProg.DetachXDP(devWatcher.index()); err != nil {
 			w.log.Errorf("Error detach xdp program from interface %s: %s", devWatcher.netDevName, err.Error())
 			w.ebpfProg.ForceDetachXDP(devWatcher.index())
 		}
 	}
 }
+
+// GetNetDevStates returns state of all watched interfaces
+func (w *Watcher) GetNetDevStates() []NetDevState {
+	w.devMux.RLock()
+	defer w.devMux.RUnlock()
+	result := make([]NetDevState, 0, len(w.devWatcherMap))
+	for _, devWatcher := range w.devWatcherMap {
+		result = append(result, devWatcher.state())
+	}
+
+	return result
+}
+
+// GetNetDevRates returns rates of passed traffic of all watched interfaces,
+// windows are in seconds and must not exceed RateHistorySize
+func (w *Watcher) GetNetDevRates(windows []int) []NetDevRates {
+	w.devMux.RLock()
+	defer w.devMux.RUnlock()
+	result := make([]NetDevRates, 0, len(w.devWatcherMap))
+	for _, devWatcher := range w.devWatcherMap {
+		result = append(result, devWatcher.rates(windows))
+	}
+
+	return result
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/mythvcode/storm-control/internal/config"
+	"github.com/mythvcode/storm-control/internal/ebpfloader"
 	"github.com/mythvcode/storm-control/internal/logger"
 	"github.com/mythvcode/storm-control/internal/watcher/mocks"
 	"github.com/stretchr/testify/require"
@@ -68,12 +69,14 @@ func makeTestWatcher(t *testing.T) (*Watcher, *mocks.MockeBPFProg) {
 	ebpMock := mocks.NewMockeBPFProg(t)
 
 	return &Watcher{
-		devWatcherMap: make(map[int]*netDevWatcher),
-		ebpfProg:      ebpMock,
-		config:        config.WatcherConfig{DevRegEx: netDevRegexp, BlockEnabled: false},
-		netDevReg:     regexp.MustCompile(netDevRegexp),
-		log:           logger.GetLogger(),
-		closed:        make(chan struct{}),
+		devWatcherMap:   make(map[int]*netDevWatcher),
+		ebpfProg:        ebpMock,
+		config:          config.WatcherConfig{DevRegEx: netDevRegexp, BlockEnabled: false, AttachMode: ebpfloader.AttachModeAuto},
+		netDevReg:       regexp.MustCompile(netDevRegexp),
+		log:             logger.GetLogger(),
+		closed:          make(chan struct{}),
+		attachChan:      make(chan attachRequest),
+		attachOverrides: make(map[string]bool),
 	}, ebpMock
 }
 
@@ -126,22 +129,22 @@ func TestFindDevices(t *testing.T) {
 
 func TestAttachProgram(t *testing.T) {
 	watcher, ebpfMock := makeTestWatcher(t)
-	ebpfMock.EXPECT().AttachXDP(1).Return(nil)
-	ebpfMock.EXPECT().AttachXDP(123).Return(nil)
-	ebpfMock.EXPECT().AttachXDP(5).Return(nil)
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
 	watcher.findAndAttachNetDev()
-	ebpfMock.AssertNotCalled(t, "AttachXDP", 100)
-	ebpfMock.AssertCalled(t, "AttachXDP", 1)
-	ebpfMock.AssertCalled(t, "AttachXDP", 123)
-	ebpfMock.AssertCalled(t, "AttachXDP", 5)
+	ebpfMock.AssertNotCalled(t, "AttachXDP", 100, ebpfloader.AttachModeAuto)
+	ebpfMock.AssertCalled(t, "AttachXDP", 1, ebpfloader.AttachModeAuto)
+	ebpfMock.AssertCalled(t, "AttachXDP", 123, ebpfloader.AttachModeAuto)
+	ebpfMock.AssertCalled(t, "AttachXDP", 5, ebpfloader.AttachModeAuto)
 	ebpfMock.AssertNumberOfCalls(t, "AttachXDP", 3)
 }
 
 func TestDetachProg(t *testing.T) {
 	watcher, ebpfMock := makeTestWatcher(t)
-	ebpfMock.EXPECT().AttachXDP(1).Return(nil)
-	ebpfMock.EXPECT().AttachXDP(123).Return(nil)
-	ebpfMock.EXPECT().AttachXDP(5).Return(nil)
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
 	watcher.findAndAttachNetDev()
 	listInterfaces = func() ([]net.Interface, error) {
 		return []net.Interface{
@@ -159,9 +162,9 @@ func TestDetachProg(t *testing.T) {
 
 func TestForceDetachProg(t *testing.T) {
 	watcher, ebpfMock := makeTestWatcher(t)
-	ebpfMock.EXPECT().AttachXDP(1).Return(nil)
-	ebpfMock.EXPECT().AttachXDP(123).Return(nil)
-	ebpfMock.EXPECT().AttachXDP(5).Return(nil)
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
 	watcher.findAndAttachNetDev()
 	listInterfaces = func() ([]net.Interface, error) {
 		return []net.Interface{
@@ -180,9 +183,9 @@ func TestForceDetachProg(t *testing.T) {
 
 func TestStopWatcher(t *testing.T) {
 	watcher, ebpfMock := makeTestWatcher(t)
-	ebpfMock.EXPECT().AttachXDP(1).Return(nil)
-	ebpfMock.EXPECT().AttachXDP(123).Return(nil)
-	ebpfMock.EXPECT().AttachXDP(5).Return(nil)
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
 	watcher.findAndAttachNetDev()
 	ebpfMock.EXPECT().DetachXDP(1).Return(errors.New("Error detach program"))
 	ebpfMock.EXPECT().ForceDetachXDP(1)
@@ -191,3 +194,195 @@ func TestStopWatcher(t *testing.T) {
 	ebpfMock.EXPECT().Close()
 	watcher.Stop()
 }
+
+func TestHandleLinkEvents(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	ebpfMock.EXPECT().AttachXDP(10, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	watcher.handleLinkEvent(linkEvent{eventType: linkAdded, index: 10, name: "tap10"})
+	// interface state change produces new link notification for already attached interface
+	watcher.handleLinkEvent(linkEvent{eventType: linkAdded, index: 10, name: "tap10"})
+	watcher.handleLinkEvent(linkEvent{eventType: linkAdded, index: 11, name: "eth0"})
+	ebpfMock.AssertNumberOfCalls(t, "AttachXDP", 1)
+	require.Contains(t, watcher.devWatcherMap, 10)
+	require.Equal(t, map[int]string{10: "tap10"}, watcher.NetDevNames())
+
+	watcher.handleLinkEvent(linkEvent{eventType: linkRemoved, index: 11, name: "eth0"})
+	ebpfMock.EXPECT().DetachXDP(10).Return(nil)
+	watcher.handleLinkEvent(linkEvent{eventType: linkRemoved, index: 10, name: "tap10"})
+	ebpfMock.AssertNumberOfCalls(t, "DetachXDP", 1)
+	require.Empty(t, watcher.devWatcherMap)
+	require.Empty(t, watcher.NetDevNames())
+}
+
+func TestHandleLinkResyncEvent(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	watcher.handleLinkEvent(linkEvent{eventType: linkResync})
+	ebpfMock.AssertNumberOfCalls(t, "AttachXDP", 3)
+}
+
+func TestDynamicWatcherLinkEvents(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	watcher.config.ResyncInterval = 3600
+	linkEvents := make(chan linkEvent)
+	subscribeLinkEvents = func(<-chan struct{}) (<-chan linkEvent, error) {
+		return linkEvents, nil
+	}
+	defer func() { subscribeLinkEvents = subscribeNetlink }()
+
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(7, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	// interface 42 was attached by previous instance and is not watched anymore
+	ebpfMock.EXPECT().AttachedNetDevs().Return([]int{1, 5, 42, 123})
+	ebpfMock.EXPECT().DetachXDP(42).Return(nil)
+	stopped := make(chan struct{})
+	go func() {
+		watcher.startDynamicWatcher()
+		close(stopped)
+	}()
+	linkEvents <- linkEvent{eventType: linkAdded, index: 7, name: "tap7"}
+	// unbuffered channel guarantees that first event was handled
+	linkEvents <- linkEvent{eventType: linkAdded, index: 100, name: "notTap"}
+	close(watcher.closed)
+	<-stopped
+	ebpfMock.AssertNumberOfCalls(t, "AttachXDP", 4)
+	ebpfMock.AssertNumberOfCalls(t, "DetachXDP", 1)
+}
+
+func TestStopKeepAttached(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	watcher.keepAttached = true
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().Close().Return()
+	watcher.findAndAttachNetDev()
+	watcher.Stop()
+	ebpfMock.AssertNotCalled(t, "DetachXDP", 1)
+}
+
+func TestAttachWithPolicy(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	policies, err := makePolicies(config.WatcherConfig{
+		BlockThreshold: 100,
+		AttachMode:     ebpfloader.AttachModeAuto,
+		Policies: []config.Policy{
+			{InterfaceName: "tap5", BlockThreshold: 1000, AttachMode: ebpfloader.AttachModeNative},
+		},
+	})
+	require.NoError(t, err)
+	watcher.policies = policies
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeNative).Return(ebpfloader.AttachModeNative, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	watcher.findAndAttachNetDev()
+	require.Equal(t, uint64(1000), watcher.devWatcherMap[5].limits.broadcast.blockThreshold)
+	require.Equal(t, uint64(0), watcher.devWatcherMap[1].limits.broadcast.blockThreshold)
+}
+
+func TestAttachRateLimitMode(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	blockEnabled := true
+	policies, err := makePolicies(config.WatcherConfig{
+		BlockThreshold: 100,
+		BlockMode:      dropMode,
+		AttachMode:     ebpfloader.AttachModeAuto,
+		Policies: []config.Policy{
+			{
+				InterfaceName: "tap5",
+				BlockMode:     rateLimitMode,
+				BlockBurst:    500,
+				BlockEnabled:  &blockEnabled,
+				TrafficLimits: config.TrafficLimits{ARP: config.TrafficLimit{BlockThreshold: 20}},
+				Exempt:        []string{"ipv6_multicast", "mld"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	watcher.policies = policies
+	watcher.config.StaticDevList = []string{"tap5"}
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().GetDevDropCfg(5).Return(ebpfloader.DropPKT{}, nil)
+	ebpfMock.EXPECT().UpdateDevDropCfg(5, ebpfloader.DropPKT{
+		Broadcast:        ebpfloader.ActionRateLimit,
+		IPv4MCast:        ebpfloader.ActionRateLimit,
+		IPv6MCast:        ebpfloader.ActionPass,
+		Multicast:        ebpfloader.ActionRateLimit,
+		ARP:              ebpfloader.ActionRateLimit,
+		DHCPv4:           ebpfloader.ActionRateLimit,
+		IPv6ND:           ebpfloader.ActionRateLimit,
+		MLD:              ebpfloader.ActionPass,
+		IPv6RA:           ebpfloader.ActionRateLimit,
+		UnknownUcast:     ebpfloader.ActionRateLimit,
+		BroadcastRate:    ebpfloader.RateLimit{Rate: 100, Burst: 500},
+		IPv4MCastRate:    ebpfloader.RateLimit{Rate: 100, Burst: 500},
+		MulticastRate:    ebpfloader.RateLimit{Rate: 100, Burst: 500},
+		ARPRate:          ebpfloader.RateLimit{Rate: 20, Burst: 500},
+		DHCPv4Rate:       ebpfloader.RateLimit{Rate: 100, Burst: 500},
+		IPv6NDRate:       ebpfloader.RateLimit{Rate: 100, Burst: 500},
+		IPv6RARate:       ebpfloader.RateLimit{Rate: 100, Burst: 500},
+		UnknownUcastRate: ebpfloader.RateLimit{Rate: 100, Burst: 500},
+	}).Return(nil)
+	watcher.findAndAttachNetDev()
+}
+
+func TestReloadInvalidConfig(t *testing.T) {
+	watcher, _ := makeTestWatcher(t)
+	cfg := config.StormControlConfig{
+		Watcher: config.WatcherConfig{DevRegEx: "^tap(", BlockMode: dropMode, AttachMode: ebpfloader.AttachModeAuto},
+	}
+	require.Error(t, watcher.Reload(cfg))
+	cfg.Watcher.DevRegEx = "^tap."
+	cfg.Watcher.BlockMode = "police"
+	require.Error(t, watcher.Reload(cfg))
+	cfg.Watcher.BlockMode = dropMode
+	cfg.Watcher.TopTalkers = config.TopTalkersConfig{Enabled: true}
+	require.Error(t, watcher.Reload(cfg))
+	require.Equal(t, "^tap.", watcher.netDevReg.String())
+	require.Equal(t, config.WatcherConfig{DevRegEx: "^tap.", AttachMode: ebpfloader.AttachModeAuto}, watcher.config)
+}
+
+func TestReloadStoppedWatcher(t *testing.T) {
+	watcher, _ := makeTestWatcher(t)
+	close(watcher.closed)
+	require.Error(t, watcher.Reload(config.StormControlConfig{
+		Watcher: config.WatcherConfig{DevRegEx: "^tap.", BlockMode: dropMode, AttachMode: ebpfloader.AttachModeAuto},
+	}))
+}
+
+func TestApplyReload(t *testing.T) {
+	watcher, ebpfMock := makeTestWatcher(t)
+	watcher.config.BlockMode = dropMode
+	ebpfMock.EXPECT().AttachXDP(1, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(123, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeAuto).Return(ebpfloader.AttachModeGeneric, nil)
+	watcher.findAndAttachNetDev()
+	tap1Watcher := watcher.devWatcherMap[1]
+
+	req, err := makeReloadRequest(config.WatcherConfig{
+		BlockThreshold: 500,
+		BlockMode:      dropMode,
+		AttachMode:     ebpfloader.AttachModeAuto,
+		StaticDevList:  []string{"tap1", "tap5"},
+		Policies: []config.Policy{
+			{InterfaceName: "tap5", AttachMode: ebpfloader.AttachModeNative},
+		},
+	})
+	require.NoError(t, err)
+	// tap123 does not match anymore, tap5 attach mode changed
+	ebpfMock.EXPECT().DetachXDP(123).Return(nil)
+	ebpfMock.EXPECT().DetachXDP(5).Return(nil)
+	ebpfMock.EXPECT().AttachXDP(5, ebpfloader.AttachModeNative).Return(ebpfloader.AttachModeNative, nil)
+	watcher.applyReload(req)
+
+	require.Len(t, watcher.devWatcherMap, 2)
+	// not affected interface is not reattached
+	require.Same(t, tap1Watcher, watcher.devWatcherMap[1])
+	require.Equal(t, uint64(500), watcher.devWatcherMap[1].getLimits().broadcast.blockThreshold)
+	require.Equal(t, ebpfloader.AttachModeNative, watcher.devWatcherMap[5].getPolicy().attachMode)
+	ebpfMock.AssertNumberOfCalls(t, "AttachXDP", 4)
+}